@@ -1,16 +1,23 @@
 package main
 
 import (
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	"context"
+	"errors"
+	"kursach/internal/auth"
 	"kursach/internal/config"
 	"kursach/internal/http-server/handlers"
-	"kursach/internal/http-server/middleware/logger"
+	"kursach/internal/http-server/router"
 	"kursach/internal/logger/sl"
+	"kursach/internal/media"
+	"kursach/internal/ranking"
 	"kursach/internal/storage/postgres"
+	"kursach/internal/timeline"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 const (
@@ -33,66 +40,140 @@ func main() {
 		log.Error("failed to initialize storage", sl.Err(err))
 		os.Exit(1)
 	}
-	router := chi.NewRouter()
+	defer db.Close()
 
-	router.Use(middleware.RequestID)
-	router.Use(logger.New(log))
-	router.Use(middleware.Recoverer)
-	router.Use(middleware.URLFormat)
-	userHandler := handlers.UserHandler{UserStorage: postgres.NewUserStorage(db.DB())}
-	updateUserHandler := handlers.UpdateUserHandler{UserStorage: postgres.NewUserStorage(db.DB())}
-	postHandler := handlers.PostHandler{PostStorage: postgres.NewPostStorage(db.DB()), UserStorage: postgres.NewUserStorage(db.DB())}
-	notificationHandler := handlers.NotificationHandler{NotificationStorage: postgres.NewNotificationStorage(db.DB())}
-	fs := http.FileServer(http.Dir("./uploads"))
-	http.Handle("/static/", http.StripPrefix("/static/", fs))
-
-	router.Post("/register", userHandler.Register)
-	router.Post("/auth", userHandler.Login)
-	router.Patch("/users", updateUserHandler.ServeHTTP)
-	router.Get("/users", userHandler.GetUserInfoHandler)
-
-	router.Post("/posts", postHandler.AddPost)
-	router.Get("/posts", postHandler.GetPostsHandler)
-	router.Delete("/posts", postHandler.DeletePostHandler)
+	// kursach migrate up|down|baseline|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), log, db, os.Args[2:]); err != nil {
+			log.Error("migration failed", sl.Err(err))
+			os.Exit(1)
+		}
+		return
+	}
 
-	router.Post("/likes", postHandler.AddLikeHandler)
-	router.Delete("/likes", postHandler.RemoveLikeHandler)
+	// kursach media rewrite-urls [--dry-run]
+	if len(os.Args) > 1 && os.Args[1] == "media" {
+		if err := runMedia(context.Background(), log, db, os.Args[2:]); err != nil {
+			log.Error("media command failed", sl.Err(err))
+			os.Exit(1)
+		}
+		return
+	}
 
-	router.Post("/comments", postHandler.AddCommentHandler)
-	router.Delete("/comments", postHandler.DeleteCommentHandler)
+	// kursach timeline backfill
+	if len(os.Args) > 1 && os.Args[1] == "timeline" {
+		if err := runTimeline(context.Background(), log, db, cfg.Timeline, os.Args[2:]); err != nil {
+			log.Error("timeline command failed", sl.Err(err))
+			os.Exit(1)
+		}
+		return
+	}
 
-	router.Post("/token", handlers.ValidateTokenHandler(*postgres.NewUserStorage(db.DB())))
+	// kursach gc-media [--dry-run] [--grace 24h]
+	if len(os.Args) > 1 && os.Args[1] == "gc-media" {
+		if err := runGCMedia(context.Background(), log, db, cfg.Media, os.Args[2:]); err != nil {
+			log.Error("media gc failed", sl.Err(err))
+			os.Exit(1)
+		}
+		return
+	}
 
-	router.Get("/notifications", notificationHandler.GetUnreadNotificationsHandler)
+	if cfg.Postgres.MigrateOnStartup {
+		if _, err := migrateUp(context.Background(), log, db); err != nil {
+			log.Error("migration failed", sl.Err(err))
+			os.Exit(1)
+		}
+	}
+	keys, err := auth.NewKeys(cfg.JWT)
+	if err != nil {
+		log.Error("failed to load jwt keys", sl.Err(err))
+		os.Exit(1)
+	}
 
-	router.Post("/blocks", postHandler.AddBlockHandler)
-	router.Get("/blocks", postHandler.CheckBlockHandler)
-	router.Delete("/blocks", postHandler.RemoveBlockHandler)
+	mediaStore, err := media.New(context.Background(), cfg.Media)
+	if err != nil {
+		log.Error("failed to initialize media storage", sl.Err(err))
+		os.Exit(1)
+	}
 
-	router.Post("/follows", postHandler.AddFollowHandler)
-	router.Delete("/follows", postHandler.RemoveFollowHandler)
-	router.Get("/follows", postHandler.GetFollowingsHandler)
+	mediaURLs, err := media.NewURLs(cfg.Media)
+	if err != nil {
+		log.Error("invalid media config", sl.Err(err))
+		os.Exit(1)
+	}
 
-	router.Post("/favorites", postHandler.AddToFavoritesHandler)
-	router.Delete("/favorites", postHandler.RemoveFromFavoritesHandler)
-	router.Get("/favorites", postHandler.GetFavoritePostsHandler)
+	mediaImages := media.NewImageProcessor(cfg.Media)
 
-	router.Post("/reports", postHandler.CreateReportHandler)
+	rankingFeed, err := ranking.NewFeed(cfg.Ranking)
+	if err != nil {
+		log.Error("invalid ranking config", sl.Err(err))
+		os.Exit(1)
+	}
 
-	router.Get("/tags", postHandler.GetAllTagsHandler)
+	postStorage := postgres.NewPostStorage(db.DB())
+	fanout := timeline.New(log, postStorage, cfg.Timeline.FanoutLimit, cfg.Timeline.QueueSize)
+
+	healthHandler := &handlers.HealthHandler{DB: db, Media: mediaStore}
+	api := router.New(router.Deps{
+		Log:           log,
+		Keys:          keys,
+		Users:         postgres.NewUserStorage(db.DB()),
+		Posts:         postStorage,
+		Notifications: postgres.NewNotificationStorage(db.DB()),
+		Health:        healthHandler,
+		Media:         mediaStore,
+		Images:        mediaImages,
+		URLs:          mediaURLs,
+		Timeline:      fanout,
+		Ranking:       rankingFeed,
+		AccessTTL:     cfg.JWT.AccessTTL,
+		RefreshTTL:    cfg.JWT.RefreshTTL,
+	})
 
-	router.Handle("/static/*", http.StripPrefix("/static/", fs))
-	http.Handle("/", withCORS(router))
 	srv := &http.Server{
 		Addr:         cfg.HTTPServer.Address,
-		Handler:      withCORS(router),
+		Handler:      withCORS(api),
 		ReadTimeout:  cfg.HTTPServer.Timeout,
 		WriteTimeout: cfg.HTTPServer.Timeout,
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
 	}
-	if err = srv.ListenAndServe(); err != nil {
-		log.Error("failed to start server", sl.Err(err))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go runMediaJanitor(ctx, log, db, mediaStore, cfg.Media.GC)
+	go fanout.Run(ctx)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info("server listening", slog.String("address", cfg.HTTPServer.Address))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			log.Error("failed to start server", sl.Err(err))
+			os.Exit(1)
+		}
+	case <-ctx.Done():
+		stop()
+		log.Info("shutting down", slog.Duration("drain_delay", cfg.HTTPServer.DrainDelay))
+
+		// Сначала /readyz начинает отвечать 503, затем дожидаемся текущих запросов
+		healthHandler.SetDraining()
+		time.Sleep(cfg.HTTPServer.DrainDelay)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.ShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error("failed to shut down gracefully", sl.Err(err))
+		}
 	}
+
 	log.Info("Server stopped", slog.String("address", cfg.HTTPServer.Address))
 }
 
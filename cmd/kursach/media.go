@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"kursach/internal/config"
+	"kursach/internal/logger/sl"
+	"kursach/internal/media"
+	"kursach/internal/storage/postgres"
+	"log/slog"
+	"time"
+)
+
+func runMedia(ctx context.Context, log *slog.Logger, db *postgres.Storage, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: kursach media rewrite-urls [--dry-run]")
+	}
+
+	switch args[0] {
+	case "rewrite-urls":
+		dryRun := len(args) > 1 && args[1] == "--dry-run"
+		// Старые записи вида "localhost:8082/static/posts/x.png" превращаются в ключи "posts/x.png"
+		result, err := db.RewriteMediaURLs(ctx, media.KeyFromLegacyURL, dryRun)
+		if err != nil {
+			return err
+		}
+		log.Info("media urls rewritten",
+			slog.Int("posts", result.Posts),
+			slog.Int("avatars", result.Avatars),
+			slog.Bool("dry_run", dryRun),
+		)
+		return nil
+	}
+
+	return fmt.Errorf("unknown media command %q", args[0])
+}
+
+// runGCMedia — kursach gc-media [--dry-run] [--grace 24h]: один проход сборщика файлов без ссылок.
+func runGCMedia(ctx context.Context, log *slog.Logger, db *postgres.Storage, cfg config.Media, args []string) error {
+	fs := flag.NewFlagSet("gc-media", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only report unreferenced objects")
+	grace := fs.Duration("grace", cfg.GC.GracePeriod, "keep unreferenced objects younger than this")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: kursach gc-media [--dry-run] [--grace 24h]")
+	}
+
+	store, err := media.New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	result, err := media.CollectGarbage(ctx, store, db.ReferencedMediaKeys, *grace, *dryRun, func(key string, info media.ObjectInfo) {
+		log.Info("unreferenced media",
+			slog.String("key", key),
+			slog.Int64("size", info.Size),
+			slog.Time("modified", info.ModTime),
+		)
+	})
+	logGCResult(log, result, *dryRun)
+	return err
+}
+
+// runMediaJanitor периодически удаляет файлы без ссылок, пока не отменён ctx.
+func runMediaJanitor(ctx context.Context, log *slog.Logger, db *postgres.Storage, store media.Store, cfg config.MediaGC) {
+	if cfg.Interval <= 0 {
+		return
+	}
+	log = log.With(slog.String("component", "media-gc"))
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		result, err := media.CollectGarbage(ctx, store, db.ReferencedMediaKeys, cfg.GracePeriod, false, nil)
+		if err != nil && ctx.Err() == nil {
+			log.Error("media gc failed", sl.Err(err))
+		}
+		logGCResult(log, result, false)
+	}
+}
+
+func logGCResult(log *slog.Logger, result media.GCResult, dryRun bool) {
+	log.Info("media gc finished",
+		slog.Int("scanned", result.Scanned),
+		slog.Int("orphans", result.Orphans),
+		slog.Int("deleted", result.Deleted),
+		slog.Int64("bytes", result.Bytes),
+		slog.Bool("dry_run", dryRun),
+	)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kursach/internal/storage/postgres"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+func runMigrate(ctx context.Context, log *slog.Logger, db *postgres.Storage, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: kursach migrate up|down [steps]|baseline [version]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		_, err := migrateUp(ctx, log, db)
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid steps %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := db.MigrateDown(ctx, steps)
+		for _, m := range reverted {
+			log.Info("migration reverted", slog.Int("version", m.Version), slog.String("name", m.Name))
+		}
+		return err
+
+	// Существующая база со схемой 0001: отмечаем её применённой, не выполняя
+	case "baseline":
+		version := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid version %q", args[1])
+			}
+			version = n
+		}
+		marked, err := db.MigrateBaseline(ctx, version)
+		for _, m := range marked {
+			log.Info("migration marked as applied", slog.Int("version", m.Version), slog.String("name", m.Name))
+		}
+		return err
+
+	case "status":
+		statuses, err := db.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return tw.Flush()
+	}
+
+	return fmt.Errorf("unknown migrate command %q", args[0])
+}
+
+// migrateUp применяет миграции. Раскладка старых постов по лентам — отдельная
+// команда kursach timeline backfill, чтобы не задерживать запуск сервера.
+func migrateUp(ctx context.Context, log *slog.Logger, db *postgres.Storage) ([]postgres.Migration, error) {
+	applied, err := db.MigrateUp(ctx)
+	for _, m := range applied {
+		log.Info("migration applied", slog.Int("version", m.Version), slog.String("name", m.Name))
+	}
+	if err == nil && len(applied) == 0 {
+		log.Info("schema is up to date")
+	}
+	return applied, err
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kursach/internal/config"
+	"kursach/internal/storage/postgres"
+	"log/slog"
+)
+
+func runTimeline(ctx context.Context, log *slog.Logger, db *postgres.Storage, cfg config.Timeline, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: kursach timeline backfill")
+	}
+
+	switch args[0] {
+	// Посты, созданные до timeline_entries или выпавшие из очереди раскладки.
+	// Можно запускать повторно: разложенные и пропущенные посты не перебираются
+	case "backfill":
+		fannedOut, err := postgres.NewPostStorage(db.DB()).BackfillTimeline(ctx, cfg.FanoutLimit)
+		log.Info("timeline backfilled", slog.Int("posts", fannedOut))
+		return err
+	}
+
+	return fmt.Errorf("unknown timeline command %q", args[0])
+}
@@ -1,28 +1,59 @@
 package auth
 
 import (
-	"github.com/dgrijalva/jwt-go"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
-var secretKey = []byte("secret_key")
+var ErrInvalidToken = errors.New("invalid token")
 
-// Claims структура для JWT
+// Claims — содержимое access-токена.
+// Subject — идентификатор пользователя, ID (jti) — идентификатор сессии в user_tokens.
 type Claims struct {
-	UserID int `json:"user_id"`
-	jwt.StandardClaims
+	Roles []string `json:"roles,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID возвращает идентификатор пользователя из sub.
+func (c *Claims) UserID() int {
+	userID, _ := strconv.Atoi(c.Subject)
+	return userID
 }
 
-// Генерация JWT
-func GenerateToken(userID int, expiresAt time.Time) (string, error) {
+// Issue выпускает access-токен, подписанный текущим ключом.
+func (k *Keys) Issue(userID int, sessionID string, roles []string, expiresAt time.Time) (string, error) {
+	now := time.Now()
 	claims := Claims{
-		UserID: userID,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expiresAt.Unix(),
-			IssuedAt:  time.Now().Unix(),
+		Roles: roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			Issuer:    k.issuer,
+			Audience:  jwt.ClaimStrings{k.audience},
+			ID:        sessionID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secretKey)
+	token := jwt.NewWithClaims(k.signing.method, claims)
+	token.Header["kid"] = k.signing.id
+	return token.SignedString(k.signing.sign)
+}
+
+// Verify проверяет подпись любым из активных ключей, а также exp, nbf, iat, iss и aud.
+func (k *Keys) Verify(tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := k.parser.ParseWithClaims(tokenStr, claims, k.keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if !token.Valid || claims.UserID() == 0 || claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
 }
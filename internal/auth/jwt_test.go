@@ -0,0 +1,216 @@
+package auth_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"kursach/internal/auth"
+	"kursach/internal/config"
+)
+
+// sign подписывает произвольные claims секретом HS256 с заданным kid.
+func sign(t *testing.T, kid string, secret []byte, claims jwt.Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func validClaims() auth.Claims {
+	now := time.Now()
+	return auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "42",
+			Issuer:    testIssuer,
+			Audience:  jwt.ClaimStrings{testAudience},
+			ID:        "session-1",
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	}
+}
+
+func TestVerify(t *testing.T) {
+	rsaPrivate, rsaPublic := rsaKeyFiles(t)
+	publicPEM, err := os.ReadFile(rsaPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("secret")
+	keys := newKeys(t, "hs",
+		config.JWTKey{ID: "hs", Algorithm: "HS256", Secret: string(secret)},
+		config.JWTKey{ID: "rs", Algorithm: "RS256", PrivateKeyPath: rsaPrivate},
+	)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		token   func() string
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			token: func() string { return sign(t, "hs", secret, validClaims()) },
+		},
+		{
+			name: "within leeway",
+			token: func() string {
+				c := validClaims()
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(-2 * time.Second))
+				return sign(t, "hs", secret, c)
+			},
+		},
+		{
+			name: "expired",
+			token: func() string {
+				c := validClaims()
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+				return sign(t, "hs", secret, c)
+			},
+			wantErr: jwt.ErrTokenExpired,
+		},
+		{
+			name: "without exp",
+			token: func() string {
+				c := validClaims()
+				c.ExpiresAt = nil
+				return sign(t, "hs", secret, c)
+			},
+			wantErr: jwt.ErrTokenRequiredClaimMissing,
+		},
+		{
+			name: "not yet valid",
+			token: func() string {
+				c := validClaims()
+				c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute))
+				return sign(t, "hs", secret, c)
+			},
+			wantErr: jwt.ErrTokenNotValidYet,
+		},
+		{
+			name: "issued in the future",
+			token: func() string {
+				c := validClaims()
+				c.IssuedAt = jwt.NewNumericDate(now.Add(time.Minute))
+				return sign(t, "hs", secret, c)
+			},
+			wantErr: jwt.ErrTokenUsedBeforeIssued,
+		},
+		{
+			name: "wrong issuer",
+			token: func() string {
+				c := validClaims()
+				c.Issuer = "someone-else"
+				return sign(t, "hs", secret, c)
+			},
+			wantErr: jwt.ErrTokenInvalidIssuer,
+		},
+		{
+			name: "wrong audience",
+			token: func() string {
+				c := validClaims()
+				c.Audience = jwt.ClaimStrings{"other-api"}
+				return sign(t, "hs", secret, c)
+			},
+			wantErr: jwt.ErrTokenInvalidAudience,
+		},
+		{
+			name:    "unknown kid",
+			token:   func() string { return sign(t, "retired", secret, validClaims()) },
+			wantErr: auth.ErrUnknownKey,
+		},
+		{
+			name:    "missing kid",
+			token:   func() string { return sign(t, "", secret, validClaims()) },
+			wantErr: auth.ErrUnknownKey,
+		},
+		{
+			name:    "wrong secret",
+			token:   func() string { return sign(t, "hs", []byte("guess"), validClaims()) },
+			wantErr: jwt.ErrSignatureInvalid,
+		},
+		{
+			// HS256, подписанный публичным RSA-ключом как секретом, с kid RS256-ключа
+			name:    "HS256 token with RS256 kid",
+			token:   func() string { return sign(t, "rs", publicPEM, validClaims()) },
+			wantErr: jwt.ErrSignatureInvalid,
+		},
+		{
+			name: "alg none",
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims())
+				token.Header["kid"] = "hs"
+				s, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return s
+			},
+			wantErr: auth.ErrInvalidToken,
+		},
+		{
+			name: "without session id",
+			token: func() string {
+				c := validClaims()
+				c.ID = ""
+				return sign(t, "hs", secret, c)
+			},
+			wantErr: auth.ErrInvalidToken,
+		},
+		{
+			name: "without user id",
+			token: func() string {
+				c := validClaims()
+				c.Subject = "not-a-number"
+				return sign(t, "hs", secret, c)
+			},
+			wantErr: auth.ErrInvalidToken,
+		},
+		{
+			name:    "garbage",
+			token:   func() string { return "not.a.token" },
+			wantErr: auth.ErrInvalidToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := keys.Verify(tt.token())
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				if claims.UserID() != 42 || claims.ID != "session-1" {
+					t.Errorf("Verify: got %+v", claims)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) || !errors.Is(err, auth.ErrInvalidToken) {
+				t.Fatalf("Verify: error %v, want %v wrapped in ErrInvalidToken", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIssueExpiry(t *testing.T) {
+	keys := newKeys(t, "hs", config.JWTKey{ID: "hs", Secret: "secret"})
+
+	token, err := keys.Issue(1, "s", nil, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.Verify(token); !errors.Is(err, jwt.ErrTokenExpired) {
+		t.Fatalf("Verify already expired token: error %v, want ErrTokenExpired", err)
+	}
+}
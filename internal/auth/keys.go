@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+	"kursach/internal/config"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+type key struct {
+	id     string
+	method jwt.SigningMethod
+	// sign — ключ подписи (nil, если ключ используется только для проверки)
+	sign interface{}
+	// verify — ключ проверки подписи
+	verify interface{}
+}
+
+// Keys хранит ключ, которым подписываются новые токены, и все ключи,
+// которыми токены принимаются. Ключ выбирается по заголовку kid.
+type Keys struct {
+	signing  *key
+	verify   map[string]*key
+	issuer   string
+	audience string
+	parser   *jwt.Parser
+}
+
+func NewKeys(cfg config.JWT) (*Keys, error) {
+	const op = "auth.NewKeys"
+
+	keys := &Keys{
+		verify:   make(map[string]*key, len(cfg.Keys)),
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		parser: jwt.NewParser(
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithAudience(cfg.Audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+			jwt.WithLeeway(cfg.Leeway),
+		),
+	}
+	for _, kc := range cfg.Keys {
+		if _, exists := keys.verify[kc.ID]; exists {
+			return nil, fmt.Errorf("%s: duplicate key id %q", op, kc.ID)
+		}
+		k, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %q: %w", op, kc.ID, err)
+		}
+		keys.verify[kc.ID] = k
+	}
+
+	signing, ok := keys.verify[cfg.SigningKeyID]
+	if !ok {
+		return nil, fmt.Errorf("%s: signing key %q is not configured", op, cfg.SigningKeyID)
+	}
+	if signing.sign == nil {
+		return nil, fmt.Errorf("%s: signing key %q has no private key", op, cfg.SigningKeyID)
+	}
+	keys.signing = signing
+
+	return keys, nil
+}
+
+func loadKey(kc config.JWTKey) (*key, error) {
+	k := &key{id: kc.ID}
+
+	switch kc.Algorithm {
+	case "", jwt.SigningMethodHS256.Alg():
+		if kc.Secret == "" {
+			return nil, errors.New("secret is required for HS256")
+		}
+		k.method = jwt.SigningMethodHS256
+		k.sign = []byte(kc.Secret)
+		k.verify = k.sign
+
+	case jwt.SigningMethodRS256.Alg():
+		k.method = jwt.SigningMethodRS256
+		if kc.PrivateKeyPath != "" {
+			private, err := parsePEMFile(kc.PrivateKeyPath, jwt.ParseRSAPrivateKeyFromPEM)
+			if err != nil {
+				return nil, err
+			}
+			k.sign = private
+			k.verify = &private.PublicKey
+		}
+		if kc.PublicKeyPath != "" {
+			public, err := parsePEMFile(kc.PublicKeyPath, jwt.ParseRSAPublicKeyFromPEM)
+			if err != nil {
+				return nil, err
+			}
+			k.verify = public
+		}
+
+	case jwt.SigningMethodEdDSA.Alg():
+		k.method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyPath != "" {
+			parsed, err := parsePEMFile(kc.PrivateKeyPath, jwt.ParseEdPrivateKeyFromPEM)
+			if err != nil {
+				return nil, err
+			}
+			private, ok := parsed.(ed25519.PrivateKey)
+			if !ok {
+				return nil, errors.New("private key is not an Ed25519 key")
+			}
+			k.sign = private
+			k.verify = private.Public()
+		}
+		if kc.PublicKeyPath != "" {
+			parsed, err := parsePEMFile(kc.PublicKeyPath, jwt.ParseEdPublicKeyFromPEM)
+			if err != nil {
+				return nil, err
+			}
+			public, ok := parsed.(ed25519.PublicKey)
+			if !ok {
+				return nil, errors.New("public key is not an Ed25519 key")
+			}
+			k.verify = public
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", kc.Algorithm)
+	}
+
+	if k.verify == nil {
+		return nil, errors.New("private_key_path or public_key_path is required")
+	}
+	return k, nil
+}
+
+func parsePEMFile[T any](path string, parse func([]byte) (T, error)) (T, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return parse(data)
+}
+
+// keyFunc выбирает ключ проверки по kid и сверяет алгоритм,
+// чтобы нельзя было подсунуть токен, подписанный другим методом.
+func (k *Keys) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	verifyKey, ok := k.verify[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != verifyKey.method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return verifyKey.verify, nil
+}
+
+// JWK — публичный ключ в формате RFC 7517.
+type JWK struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// PublicJWKs возвращает асимметричные ключи проверки, чтобы другие сервисы
+// могли проверять токены, не зная секретов. HMAC-ключи не публикуются.
+func (k *Keys) PublicJWKs() []JWK {
+	jwks := make([]JWK, 0, len(k.verify))
+	for _, vk := range k.verify {
+		jwk := JWK{KeyID: vk.id, Algorithm: vk.method.Alg(), Use: "sig"}
+		switch public := vk.verify.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
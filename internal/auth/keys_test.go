@@ -0,0 +1,257 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"kursach/internal/auth"
+	"kursach/internal/config"
+)
+
+const (
+	testIssuer   = "kursach"
+	testAudience = "kursach-api"
+)
+
+// newKeys собирает Keys из набора ключей; signing — kid ключа подписи.
+func newKeys(t *testing.T, signing string, keys ...config.JWTKey) *auth.Keys {
+	t.Helper()
+
+	k, err := auth.NewKeys(jwtConfig(signing, keys...))
+	if err != nil {
+		t.Fatalf("NewKeys: %v", err)
+	}
+	return k
+}
+
+func jwtConfig(signing string, keys ...config.JWTKey) config.JWT {
+	return config.JWT{
+		SigningKeyID: signing,
+		Keys:         keys,
+		Issuer:       testIssuer,
+		Audience:     testAudience,
+		Leeway:       5 * time.Second,
+	}
+}
+
+func hsKey(id, secret string) config.JWTKey {
+	return config.JWTKey{ID: id, Algorithm: "HS256", Secret: secret}
+}
+
+// writePEM сохраняет блок PEM во временный файл и возвращает путь.
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// rsaKeyFiles создаёт пару RSA-ключей и возвращает пути к PEM-файлам.
+func rsaKeyFiles(t *testing.T) (privatePath, publicPath string) {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "rsa.key", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(private)),
+		writePEM(t, "rsa.pub", "PUBLIC KEY", public)
+}
+
+// edKeyFiles создаёт пару Ed25519-ключей и возвращает пути к PEM-файлам.
+func edKeyFiles(t *testing.T) (privatePath, publicPath string) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "ed.key", "PRIVATE KEY", privateDER), writePEM(t, "ed.pub", "PUBLIC KEY", publicDER)
+}
+
+func TestNewKeysErrors(t *testing.T) {
+	_, rsaPublic := rsaKeyFiles(t)
+
+	tests := []struct {
+		name    string
+		cfg     config.JWT
+		wantErr string
+	}{
+		{
+			name:    "signing key not configured",
+			cfg:     jwtConfig("missing", hsKey("a", "secret")),
+			wantErr: `signing key "missing" is not configured`,
+		},
+		{
+			name:    "duplicate kid",
+			cfg:     jwtConfig("a", hsKey("a", "one"), hsKey("a", "two")),
+			wantErr: `duplicate key id "a"`,
+		},
+		{
+			name:    "HS256 without secret",
+			cfg:     jwtConfig("a", hsKey("a", "")),
+			wantErr: "secret is required for HS256",
+		},
+		{
+			name:    "unsupported algorithm",
+			cfg:     jwtConfig("a", config.JWTKey{ID: "a", Algorithm: "none"}),
+			wantErr: `unsupported algorithm "none"`,
+		},
+		{
+			name:    "RS256 without key files",
+			cfg:     jwtConfig("a", config.JWTKey{ID: "a", Algorithm: "RS256"}),
+			wantErr: "private_key_path or public_key_path is required",
+		},
+		{
+			name:    "verify-only key cannot sign",
+			cfg:     jwtConfig("a", config.JWTKey{ID: "a", Algorithm: "RS256", PublicKeyPath: rsaPublic}),
+			wantErr: `signing key "a" has no private key`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := auth.NewKeys(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewKeys: error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	rsaPrivate, _ := rsaKeyFiles(t)
+	edPrivate, _ := edKeyFiles(t)
+
+	tests := []struct {
+		name string
+		key  config.JWTKey
+	}{
+		{"HS256", hsKey("hs", "secret")},
+		{"RS256", config.JWTKey{ID: "rs", Algorithm: "RS256", PrivateKeyPath: rsaPrivate}},
+		{"EdDSA", config.JWTKey{ID: "ed", Algorithm: "EdDSA", PrivateKeyPath: edPrivate}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := newKeys(t, tt.key.ID, tt.key)
+
+			token, err := keys.Issue(42, "session-1", []string{"moderator"}, time.Now().Add(time.Minute))
+			if err != nil {
+				t.Fatalf("Issue: %v", err)
+			}
+			header, _, err := jwt.NewParser().ParseUnverified(token, &auth.Claims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header.Header["kid"] != tt.key.ID || header.Method.Alg() != tt.name {
+				t.Errorf("header: kid %v, alg %s; want %s, %s", header.Header["kid"], header.Method.Alg(), tt.key.ID, tt.name)
+			}
+
+			claims, err := keys.Verify(token)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.UserID() != 42 || claims.ID != "session-1" || len(claims.Roles) != 1 || claims.Roles[0] != "moderator" {
+				t.Errorf("Verify: got %+v", claims)
+			}
+		})
+	}
+}
+
+// Проверка публичным ключом другого сервиса: JWKS содержит только
+// асимметричные ключи, а ключ с одним public_key_path принимает токены.
+func TestVerifyOnlyKey(t *testing.T) {
+	rsaPrivate, rsaPublic := rsaKeyFiles(t)
+	edPrivate, edPublic := edKeyFiles(t)
+
+	issuer := newKeys(t, "rs",
+		config.JWTKey{ID: "rs", Algorithm: "RS256", PrivateKeyPath: rsaPrivate},
+		config.JWTKey{ID: "ed", Algorithm: "EdDSA", PrivateKeyPath: edPrivate},
+		hsKey("hs", "secret"),
+	)
+	verifier := newKeys(t, "hs",
+		hsKey("hs", "other-secret"),
+		config.JWTKey{ID: "rs", Algorithm: "RS256", PublicKeyPath: rsaPublic},
+		config.JWTKey{ID: "ed", Algorithm: "EdDSA", PublicKeyPath: edPublic},
+	)
+
+	token, err := issuer.Issue(7, "s", nil, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(token); err != nil {
+		t.Errorf("Verify with public key: %v", err)
+	}
+
+	jwks := issuer.PublicJWKs()
+	kinds := map[string]string{}
+	for _, jwk := range jwks {
+		kinds[jwk.KeyID] = jwk.KeyType
+	}
+	if len(jwks) != 2 || kinds["rs"] != "RSA" || kinds["ed"] != "OKP" {
+		t.Errorf("PublicJWKs: got %+v, want the RSA and Ed25519 keys only", jwks)
+	}
+}
+
+// После ротации старый ключ остаётся для проверки выданных им токенов,
+// а новые токены подписываются новым ключом.
+func TestKeyRotation(t *testing.T) {
+	oldKey, newKey := hsKey("2024-01", "old-secret"), hsKey("2024-06", "new-secret")
+
+	before := newKeys(t, oldKey.ID, oldKey)
+	oldToken, err := before.Issue(1, "s", nil, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := newKeys(t, newKey.ID, newKey, oldKey)
+	if _, err := rotated.Verify(oldToken); err != nil {
+		t.Errorf("Verify token of the rotated-out key: %v", err)
+	}
+
+	newToken, err := rotated.Issue(1, "s", nil, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _, err := jwt.NewParser().ParseUnverified(newToken, &auth.Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Header["kid"] != newKey.ID {
+		t.Errorf("new token signed with kid %v, want %s", header.Header["kid"], newKey.ID)
+	}
+	// сервис, ещё не знающий о новом ключе, его токены не принимает
+	if _, err := before.Verify(newToken); err == nil {
+		t.Error("Verify with keys that predate the rotation: want an error")
+	}
+
+	// после удаления старого ключа его токены больше не принимаются
+	retired := newKeys(t, newKey.ID, newKey)
+	if _, err := retired.Verify(oldToken); err == nil {
+		t.Error("Verify token of a removed key: want an error")
+	}
+}
@@ -11,6 +11,10 @@ type Config struct {
 	Env        string      `yaml:"env" env:"ENV" env-default:"local"`
 	Postgres   PostgresCfg `yaml:"postgres" env-required:"true"`
 	HTTPServer HTTPServer  `yaml:"http_server" env-required:"true"`
+	JWT        JWT         `yaml:"jwt" env-required:"true"`
+	Media      Media       `yaml:"media"`
+	Timeline   Timeline    `yaml:"timeline"`
+	Ranking    Ranking     `yaml:"ranking"`
 }
 
 type PostgresCfg struct {
@@ -20,12 +24,139 @@ type PostgresCfg struct {
 	Password string `yaml:"password" env:"PG_PASSWORD" env-default:"postgres"`
 	DBName   string `yaml:"dbname" env:"PG_DBNAME" env-default:"postgres"`
 	SSLMode  string `yaml:"sslmode" env:"PG_SSLMODE" env-default:"disable"`
+	// MigrateOnStartup — применять встроенные миграции при запуске сервера.
+	MigrateOnStartup bool `yaml:"migrate_on_startup" env:"PG_MIGRATE_ON_STARTUP" env-default:"false"`
 }
 
 type HTTPServer struct {
 	Address     string        `yaml:"address" env:"HTTP_ADDRESS" env-default:"0.0.0.0:8082"`
 	Timeout     time.Duration `yaml:"timeout" env:"HTTP_TIMEOUT" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" env-default:"60s"`
+	// DrainDelay — сколько /readyz отвечает 503 перед остановкой сервера,
+	// чтобы балансировщик успел убрать экземпляр. ShutdownTimeout — сколько
+	// ждать завершения текущих запросов.
+	DrainDelay      time.Duration `yaml:"drain_delay" env:"HTTP_DRAIN_DELAY" env-default:"0s"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT" env-default:"15s"`
+}
+
+// JWT описывает ключи подписи токенов. Токены подписываются ключом
+// SigningKeyID, а проверяются любым ключом из Keys — так ключи можно
+// ротировать, не разлогинивая пользователей.
+type JWT struct {
+	SigningKeyID string   `yaml:"signing_key_id" env:"JWT_SIGNING_KEY_ID" env-required:"true"`
+	Keys         []JWTKey `yaml:"keys" env-required:"true"`
+	// Issuer и Audience записываются в iss/aud и обязательно проверяются.
+	Issuer   string        `yaml:"issuer" env:"JWT_ISSUER" env-default:"kursach"`
+	Audience string        `yaml:"audience" env:"JWT_AUDIENCE" env-default:"kursach-api"`
+	Leeway   time.Duration `yaml:"leeway" env:"JWT_LEEWAY" env-default:"30s"`
+	// AccessTTL — время жизни access-токена, RefreshTTL — сессии (refresh-токена).
+	AccessTTL  time.Duration `yaml:"access_ttl" env:"JWT_ACCESS_TTL" env-default:"15m"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl" env:"JWT_REFRESH_TTL" env-default:"720h"`
+}
+
+// Media — где хранить загруженные файлы. Driver: local или s3.
+type Media struct {
+	Driver string     `yaml:"driver" env:"MEDIA_DRIVER" env-default:"local"`
+	Local  LocalMedia `yaml:"local"`
+	S3     S3Media    `yaml:"s3"`
+	// PublicBaseURL — внешний адрес сервера, по которому клиенты получают
+	// файлы через /static/. CDNBaseURL, если задан, используется вместо него.
+	PublicBaseURL string `yaml:"public_base_url" env:"MEDIA_PUBLIC_BASE_URL" env-default:"http://localhost:8082"`
+	CDNBaseURL    string `yaml:"cdn_base_url" env:"MEDIA_CDN_BASE_URL"`
+	// Ограничения на загружаемые картинки постов и аватары.
+	PostImages  PostImages   `yaml:"post_images"`
+	Avatars     AvatarImages `yaml:"avatars"`
+	Videos      Videos       `yaml:"videos"`
+	GIFs        GIFs         `yaml:"gifs"`
+	JPEGQuality int          `yaml:"jpeg_quality" env:"MEDIA_JPEG_QUALITY" env-default:"85"`
+	// MaxPostBytes — предел всего тела запроса при создании и правке поста
+	// со всеми вложениями вместе.
+	MaxPostBytes int64   `yaml:"max_post_bytes" env:"MEDIA_MAX_POST_BYTES" env-default:"104857600"`
+	GC           MediaGC `yaml:"gc"`
+}
+
+// MediaGC — удаление файлов, на которые не ссылается база. Interval —
+// период фоновой уборки (0 отключает её, команда kursach gc-media
+// работает и так). GracePeriod — сколько файл без ссылок не трогается:
+// файл загружается раньше, чем появляется запись о нём.
+type MediaGC struct {
+	Interval    time.Duration `yaml:"interval" env:"MEDIA_GC_INTERVAL" env-default:"1h"`
+	GracePeriod time.Duration `yaml:"grace_period" env:"MEDIA_GC_GRACE_PERIOD" env-default:"24h"`
+}
+
+// Timeline — раскладка постов по домашним лентам. Посты авторов, у которых
+// подписчиков больше FanoutLimit, не раскладываются, а читаются при
+// запросе ленты. QueueSize — сколько новых постов ждёт раскладки.
+type Timeline struct {
+	FanoutLimit int `yaml:"fanout_limit" env:"TIMELINE_FANOUT_LIMIT" env-default:"10000"`
+	QueueSize   int `yaml:"queue_size" env:"TIMELINE_QUEUE_SIZE" env-default:"1024"`
+}
+
+// Ranking — лента «Для вас». Ranker — ранжировщик по умолчанию (weighted
+// или recent), Experiment — ранжировщик для ExperimentPercent процентов
+// пользователей. Кандидаты — до MaxCandidates самых новых постов за Window.
+type Ranking struct {
+	Ranker            string        `yaml:"ranker" env:"RANKING_RANKER" env-default:"weighted"`
+	Experiment        string        `yaml:"experiment" env:"RANKING_EXPERIMENT"`
+	ExperimentPercent int           `yaml:"experiment_percent" env:"RANKING_EXPERIMENT_PERCENT" env-default:"0"`
+	Window            time.Duration `yaml:"window" env:"RANKING_WINDOW" env-default:"168h"`
+	MaxCandidates     int           `yaml:"max_candidates" env:"RANKING_MAX_CANDIDATES" env-default:"500"`
+	HalfLife          time.Duration `yaml:"half_life" env:"RANKING_HALF_LIFE" env-default:"24h"`
+}
+
+// Videos — ограничения на видео во вложениях постов (mp4, webm).
+// MaxDuration 0 не ограничивает длительность.
+type Videos struct {
+	MaxBytes    int64         `yaml:"max_bytes" env:"MEDIA_VIDEO_MAX_BYTES" env-default:"52428800"`
+	MaxDuration time.Duration `yaml:"max_duration" env:"MEDIA_VIDEO_MAX_DURATION" env-default:"10m"`
+}
+
+// GIFs — ограничения на анимированные GIF, проверяемые до распаковки:
+// число кадров и сумма площадей всех кадров (столько байт занимают
+// распакованные кадры). 0 — без ограничения.
+type GIFs struct {
+	MaxFrames int   `yaml:"max_frames" env:"MEDIA_GIF_MAX_FRAMES" env-default:"500"`
+	MaxPixels int64 `yaml:"max_pixels" env:"MEDIA_GIF_MAX_PIXELS" env-default:"100000000"`
+}
+
+type PostImages struct {
+	MaxBytes  int64 `yaml:"max_bytes" env:"MEDIA_POST_MAX_BYTES" env-default:"20971520"`
+	MaxWidth  int   `yaml:"max_width" env:"MEDIA_POST_MAX_WIDTH" env-default:"8000"`
+	MaxHeight int   `yaml:"max_height" env:"MEDIA_POST_MAX_HEIGHT" env-default:"8000"`
+}
+
+type AvatarImages struct {
+	MaxBytes  int64 `yaml:"max_bytes" env:"MEDIA_AVATAR_MAX_BYTES" env-default:"10485760"`
+	MaxWidth  int   `yaml:"max_width" env:"MEDIA_AVATAR_MAX_WIDTH" env-default:"4096"`
+	MaxHeight int   `yaml:"max_height" env:"MEDIA_AVATAR_MAX_HEIGHT" env-default:"4096"`
+}
+
+type LocalMedia struct {
+	Dir string `yaml:"dir" env:"MEDIA_LOCAL_DIR" env-default:"./uploads"`
+}
+
+type S3Media struct {
+	Endpoint        string `yaml:"endpoint" env:"MEDIA_S3_ENDPOINT"` // host:port без схемы
+	Region          string `yaml:"region" env:"MEDIA_S3_REGION"`
+	Bucket          string `yaml:"bucket" env:"MEDIA_S3_BUCKET"`
+	AccessKeyID     string `yaml:"access_key_id" env:"MEDIA_S3_ACCESS_KEY_ID"`
+	SecretAccessKey string `yaml:"secret_access_key" env:"MEDIA_S3_SECRET_ACCESS_KEY"`
+	UseSSL          bool   `yaml:"use_ssl" env:"MEDIA_S3_USE_SSL" env-default:"true"`
+	// PathStyle — адресация host/bucket/key (нужна для MinIO).
+	PathStyle bool `yaml:"path_style" env:"MEDIA_S3_PATH_STYLE" env-default:"false"`
+	// CreateBucket — создать бакет при запуске, если его нет.
+	CreateBucket bool `yaml:"create_bucket" env:"MEDIA_S3_CREATE_BUCKET" env-default:"false"`
+}
+
+type JWTKey struct {
+	ID        string `yaml:"id" env-required:"true"`
+	Algorithm string `yaml:"algorithm" env-default:"HS256"` // HS256, RS256 или EdDSA
+	// Secret — общий секрет для HS256.
+	Secret string `yaml:"secret"`
+	// PrivateKeyPath и PublicKeyPath — PEM-файлы для RS256/EdDSA.
+	// Для ключа, используемого только для проверки, достаточно публичного.
+	PrivateKeyPath string `yaml:"private_key_path"`
+	PublicKeyPath  string `yaml:"public_key_path"`
 }
 
 func MustLoad() *Config {
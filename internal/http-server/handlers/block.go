@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+
+	"kursach/internal/storage/postgres"
 )
 
 func (h *PostHandler) AddBlockHandler(w http.ResponseWriter, r *http.Request) {
@@ -16,7 +19,20 @@ func (h *PostHandler) AddBlockHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.PostStorage.AddUserBlock(r.Context(), req.BlockerID, req.BlockedID)
+	blockerID, ok := actorID(w, r, req.BlockerID)
+	if !ok {
+		return
+	}
+	if req.BlockedID <= 0 || req.BlockedID == blockerID {
+		http.Error(w, "Invalid blocked_id", http.StatusBadRequest)
+		return
+	}
+
+	err := h.PostStorage.AddUserBlock(r.Context(), blockerID, req.BlockedID)
+	if errors.Is(err, postgres.ErrUserNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Could not add block", http.StatusInternalServerError)
 		return
@@ -24,20 +40,40 @@ func (h *PostHandler) AddBlockHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
+// GetBlocksHandler — GET /blocks: список заблокированных пользователем.
+// С параметром blocked_id — проверка одной блокировки (CheckBlockHandler).
+func (h *PostHandler) GetBlocksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("blocked_id") {
+		h.CheckBlockHandler(w, r)
+		return
+	}
+
+	blockerID, ok := actorIDFromParam(w, r, r.URL.Query().Get("blocker_id"))
+	if !ok {
+		return
+	}
+
+	users, err := h.PostStorage.GetBlockedUsers(r.Context(), blockerID)
+	if err != nil {
+		http.Error(w, "Failed to get blocks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
 func (h *PostHandler) CheckBlockHandler(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
-	blockerIDs, ok1 := query["blocker_id"]
-	blockedIDs, ok2 := query["blocked_id"]
-
-	if !ok1 || !ok2 || len(blockerIDs) == 0 || len(blockedIDs) == 0 {
+	blockedIDs, ok := query["blocked_id"]
+	if !ok || len(blockedIDs) == 0 {
 		http.Error(w, "Missing query parameters", http.StatusBadRequest)
 		return
 	}
 
-	blockerID, err := strconv.Atoi(blockerIDs[0])
-	if err != nil {
-		http.Error(w, "Invalid blocker_id", http.StatusBadRequest)
+	blockerID, ok := actorIDFromParam(w, r, query.Get("blocker_id"))
+	if !ok {
 		return
 	}
 
@@ -60,17 +96,14 @@ func (h *PostHandler) CheckBlockHandler(w http.ResponseWriter, r *http.Request)
 func (h *PostHandler) RemoveBlockHandler(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
-	blockerIDs, ok1 := query["blocker_id"]
-	blockedIDs, ok2 := query["blocked_id"]
-
-	if !ok1 || !ok2 || len(blockerIDs) == 0 || len(blockedIDs) == 0 {
+	blockedIDs, ok := query["blocked_id"]
+	if !ok || len(blockedIDs) == 0 {
 		http.Error(w, "Missing query parameters", http.StatusBadRequest)
 		return
 	}
 
-	blockerID, err := strconv.Atoi(blockerIDs[0])
-	if err != nil {
-		http.Error(w, "Invalid blocker_id", http.StatusBadRequest)
+	blockerID, ok := actorIDFromParam(w, r, query.Get("blocker_id"))
+	if !ok {
 		return
 	}
 
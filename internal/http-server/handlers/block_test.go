@@ -0,0 +1,82 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func (a *testAPI) blocks(u testUser) []int {
+	a.t.Helper()
+
+	var users []struct {
+		UserID  int    `json:"user_id"`
+		UserTag string `json:"user_tag"`
+	}
+	decode(a.t, a.expect(http.StatusOK, http.MethodGet, "/blocks", u.Token, nil), &users)
+	if users == nil {
+		a.t.Fatal("GET /blocks: list is null")
+	}
+	ids := make([]int, len(users))
+	for i, user := range users {
+		ids[i] = user.UserID
+	}
+	return ids
+}
+
+func TestBlocks(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+
+	if got := api.blocks(alice); len(got) != 0 {
+		t.Errorf("GET /blocks: got %v, want none", got)
+	}
+
+	api.expect(http.StatusCreated, http.MethodPost, "/blocks", alice.Token, map[string]int{"blocked_id": bob.ID})
+	if got := api.blocks(alice); len(got) != 1 || got[0] != bob.ID {
+		t.Errorf("GET /blocks: got %v, want [%d]", got, bob.ID)
+	}
+
+	api.expect(http.StatusOK, http.MethodDelete, fmt.Sprintf("/blocks?blocked_id=%d", bob.ID), alice.Token, nil)
+	if got := api.blocks(alice); len(got) != 0 {
+		t.Errorf("GET /blocks after unblock: got %v, want none", got)
+	}
+}
+
+func TestAddBlockErrors(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+
+	requireError(t, api.do(http.MethodPost, "/blocks", alice.Token, map[string]int{"blocked_id": alice.ID}), http.StatusBadRequest, "Invalid blocked_id")
+	requireError(t, api.do(http.MethodPost, "/blocks", alice.Token, map[string]int{"blocked_id": 0}), http.StatusBadRequest, "Invalid blocked_id")
+	requireError(t, api.do(http.MethodPost, "/blocks", alice.Token, map[string]int{"blocked_id": -1}), http.StatusBadRequest, "Invalid blocked_id")
+	requireError(t, api.do(http.MethodPost, "/blocks", alice.Token, map[string]int{"blocked_id": 999}), http.StatusNotFound, "User not found")
+	requireError(t, api.do(http.MethodDelete, "/blocks", alice.Token, nil), http.StatusBadRequest, "Missing query parameters")
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/blocks", "", map[string]int{"blocked_id": 1})
+}
+
+// Блокировка в любую сторону запрещает лайки, комментарии и подписки и
+// скрывает комментарии к постам заблокировавшего.
+func TestBlockedInteractions(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	postID := api.createPost(alice, "hello")
+	bobPostID := api.createPost(bob, "bob's post")
+
+	api.expect(http.StatusCreated, http.MethodPost, "/blocks", alice.Token, map[string]int{"blocked_id": bob.ID})
+
+	requireError(t, api.do(http.MethodPost, "/likes", bob.Token, map[string]int{"post_id": postID}), http.StatusForbidden, "User is blocked")
+	requireError(t, api.do(http.MethodPost, "/comments", bob.Token, map[string]any{"post_id": postID, "comment": "hi"}), http.StatusForbidden, "User is blocked")
+	requireError(t, api.do(http.MethodPost, "/follows", bob.Token, map[string]int{"following_id": alice.ID}), http.StatusForbidden, "User is blocked")
+	requireError(t, api.do(http.MethodPost, "/likes", alice.Token, map[string]int{"post_id": bobPostID}), http.StatusForbidden, "User is blocked")
+
+	path := fmt.Sprintf("/posts/%d/comments", postID)
+	requireError(t, api.do(http.MethodGet, path, bob.Token, nil), http.StatusNotFound, "Post not found")
+	api.comments(path, "")
+
+	api.expect(http.StatusOK, http.MethodDelete, fmt.Sprintf("/blocks?blocked_id=%d", bob.ID), alice.Token, nil)
+	api.expect(http.StatusCreated, http.MethodPost, "/likes", bob.Token, map[string]int{"post_id": postID})
+	api.comments(path, bob.Token)
+}
@@ -2,14 +2,22 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"kursach/internal/logger/sl"
 	"kursach/internal/storage/postgres"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 type CommentRequest struct {
 	AuthorID int    `json:"author_id"`
 	PostID   int    `json:"post_id"`
+	ParentID *int   `json:"parent_id,omitempty"` // ответ на комментарий
 	Comment  string `json:"comment"`
 }
 
@@ -20,16 +28,43 @@ func (h *PostHandler) AddCommentHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	authorID, ok := actorID(w, r, req.AuthorID)
+	if !ok {
+		return
+	}
+
 	comment := &postgres.Comment{
-		AuthorID: req.AuthorID,
+		AuthorID: authorID,
 		PostID:   req.PostID,
+		ParentID: req.ParentID,
 		Text:     req.Comment,
 	}
 
-	if err := h.PostStorage.AddComment(r.Context(), comment); err != nil {
+	err := h.PostStorage.AddComment(r.Context(), comment)
+	if errors.Is(err, postgres.ErrPostNotFound) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, postgres.ErrParentComment) {
+		http.Error(w, "Parent comment not found", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, postgres.ErrCommentTooDeep) {
+		http.Error(w, "Reply depth limit reached", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, postgres.ErrBlocked) {
+		blocked(w)
+		return
+	}
+	if err != nil {
+		h.Log.Error("failed to add comment",
+			sl.Err(err),
+			slog.Int("post_id", comment.PostID),
+			slog.Int("author_id", comment.AuthorID),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
 		http.Error(w, "Could not add comment", http.StatusInternalServerError)
-		log.Println(err)
-		log.Println(comment)
 		return
 	}
 
@@ -49,10 +84,240 @@ func (h *PostHandler) DeleteCommentHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := h.PostStorage.DeleteComment(r.Context(), req.CommentID, req.UserID); err != nil {
+	actor, ok := currentActor(w, r, req.UserID)
+	if !ok {
+		return
+	}
+
+	authorID, err := h.PostStorage.GetCommentAuthorID(r.Context(), req.CommentID)
+	if errors.Is(err, postgres.ErrCommentNotFound) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
 		http.Error(w, "Could not delete comment", http.StatusInternalServerError)
 		return
 	}
+	if !actor.CanDeleteComment(authorID) {
+		forbidden(w)
+		return
+	}
+
+	if err := h.PostStorage.DeleteComment(r.Context(), req.CommentID); err != nil {
+		http.Error(w, "Could not delete comment", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type EditCommentRequest struct {
+	Comment string `json:"comment"`
+}
+
+// EditCommentHandler — PATCH /comments/{id}, правка текста автором.
+func (h *PostHandler) EditCommentHandler(w http.ResponseWriter, r *http.Request) {
+	commentID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid comment id", http.StatusBadRequest)
+		return
+	}
+
+	var req EditCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Comment) == "" {
+		http.Error(w, "Comment is empty", http.StatusBadRequest)
+		return
+	}
+
+	actor, ok := currentActor(w, r, 0)
+	if !ok {
+		return
+	}
+	authorID, err := h.PostStorage.GetCommentAuthorID(r.Context(), commentID)
+	if errors.Is(err, postgres.ErrCommentNotFound) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Could not update comment", http.StatusInternalServerError)
+		return
+	}
+	if !actor.CanEditComment(authorID) {
+		forbidden(w)
+		return
+	}
+
+	comment, err := h.PostStorage.UpdateComment(r.Context(), commentID, req.Comment)
+	if errors.Is(err, postgres.ErrCommentNotFound) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Could not update comment", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// CommentsResult — страница комментариев. NextCursor передаётся в
+// параметре cursor, чтобы получить следующую страницу.
+type CommentsResult struct {
+	Comments   []postgres.CommentBrief `json:"comments"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+	HasMore    bool                    `json:"hasMore"`
+}
+
+var commentOrders = map[string]bool{
+	postgres.CommentsOldest: true,
+	postgres.CommentsNewest: true,
+	postgres.CommentsTop:    true,
+}
+
+// GetPostCommentsHandler — GET /posts/{id}/comments. view=tree — всё
+// дерево по времени; по умолчанию — плоский список одного уровня
+// (parent_id — ответы на комментарий) с числом ответов и лайков.
+// Плоский список сортируется по order (oldest, newest, top) и
+// листается курсором: limit и cursor из next_cursor предыдущей страницы.
+func (h *PostHandler) GetPostCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid post id", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	q := postgres.CommentsQuery{Order: postgres.CommentsOldest, Limit: 20}
+	switch query.Get("view") {
+	case "tree":
+		q.Tree = true
+	case "", "flat":
+	default:
+		http.Error(w, "Invalid view", http.StatusBadRequest)
+		return
+	}
+	if v := query.Get("order"); v != "" {
+		if !commentOrders[v] {
+			http.Error(w, "Invalid order", http.StatusBadRequest)
+			return
+		}
+		q.Order = v
+	}
+	if v := query.Get("parent_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "Invalid parent_id", http.StatusBadRequest)
+			return
+		}
+		q.ParentID = &n
+	}
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 100 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		q.Limit = n
+	}
+	if v := query.Get("cursor"); v != "" {
+		var after postgres.CommentCursor
+		if err := postgres.DecodeCursor(v, &after); err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		q.After = &after
+	}
+
+	q.ViewerID = viewerID(r)
+	// комментарии скрытого модератором поста не показываются
+	authorID, err := h.PostStorage.GetVisiblePostAuthorID(r.Context(), postID)
+	if errors.Is(err, postgres.ErrPostNotFound) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get comments", http.StatusInternalServerError)
+		return
+	}
+	// пост автора, с которым есть блокировка, для читателя не существует
+	if q.ViewerID != 0 {
+		isBlocked, err := h.PostStorage.IsBlockedBetween(r.Context(), q.ViewerID, authorID)
+		if err != nil {
+			http.Error(w, "Failed to get comments", http.StatusInternalServerError)
+			return
+		}
+		if isBlocked {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	comments, next, err := h.PostStorage.GetCommentsByPostID(r.Context(), postID, q)
+	if err != nil {
+		http.Error(w, "Failed to get comments", http.StatusInternalServerError)
+		return
+	}
+
+	resp := CommentsResult{Comments: comments, HasMore: next != nil}
+	if resp.Comments == nil {
+		resp.Comments = []postgres.CommentBrief{}
+	}
+	if next != nil {
+		resp.NextCursor = postgres.EncodeCursor(next)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *PostHandler) AddCommentLikeHandler(w http.ResponseWriter, r *http.Request) {
+	commentID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid comment id", http.StatusBadRequest)
+		return
+	}
+	userID, ok := actorID(w, r, 0)
+	if !ok {
+		return
+	}
+
+	err = h.PostStorage.AddCommentLike(r.Context(), userID, commentID)
+	if errors.Is(err, postgres.ErrCommentNotFound) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, postgres.ErrBlocked) {
+		blocked(w)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Could not add like", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *PostHandler) RemoveCommentLikeHandler(w http.ResponseWriter, r *http.Request) {
+	commentID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid comment id", http.StatusBadRequest)
+		return
+	}
+	userID, ok := actorID(w, r, 0)
+	if !ok {
+		return
+	}
+
+	if err := h.PostStorage.RemoveCommentLike(r.Context(), userID, commentID); err != nil {
+		http.Error(w, "Could not remove like", http.StatusInternalServerError)
+		return
+	}
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,243 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"kursach/internal/storage/postgres"
+)
+
+type commentsPage struct {
+	Comments []struct {
+		CommentID  int    `json:"comment_id"`
+		ParentID   *int   `json:"parent_id"`
+		Comment    string `json:"comment"`
+		AuthorID   int    `json:"author_id"`
+		ReplyCount int    `json:"reply_count"`
+	} `json:"comments"`
+	NextCursor string `json:"next_cursor"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+func (a *testAPI) comments(path, token string) commentsPage {
+	a.t.Helper()
+
+	var page commentsPage
+	decode(a.t, a.expect(http.StatusOK, http.MethodGet, path, token, nil), &page)
+	if page.Comments == nil {
+		a.t.Fatalf("GET %s: comments is null", path)
+	}
+	return page
+}
+
+func (a *testAPI) addComment(u testUser, postID int, text string, parentID *int) int {
+	a.t.Helper()
+
+	var comment struct {
+		CommentID int `json:"comment_id"`
+		AuthorID  int `json:"author_id"`
+	}
+	resp := a.expect(http.StatusOK, http.MethodPost, "/comments", u.Token, map[string]any{
+		"post_id": postID, "comment": text, "parent_id": parentID,
+	})
+	decode(a.t, resp, &comment)
+	if comment.CommentID == 0 || comment.AuthorID != u.ID {
+		a.t.Fatalf("POST /comments: got %+v", comment)
+	}
+	return comment.CommentID
+}
+
+func TestComments(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	postID := api.createPost(alice, "hello")
+
+	first := api.addComment(bob, postID, "first", nil)
+	api.addComment(alice, postID, "second", nil)
+	api.addComment(alice, postID, "reply", &first)
+
+	path := fmt.Sprintf("/posts/%d/comments", postID)
+	page := api.comments(path, "")
+	if len(page.Comments) != 2 || page.HasMore {
+		t.Fatalf("GET %s: got %+v, want 2 top-level comments", path, page)
+	}
+	if c := page.Comments[0]; c.CommentID != first || c.AuthorID != bob.ID || c.Comment != "first" || c.ReplyCount != 1 {
+		t.Errorf("GET %s: first comment %+v", path, c)
+	}
+
+	replies := api.comments(fmt.Sprintf("%s?parent_id=%d", path, first), "")
+	if len(replies.Comments) != 1 || replies.Comments[0].Comment != "reply" {
+		t.Errorf("GET %s?parent_id=%d: got %+v", path, first, replies)
+	}
+
+	// курсор первой страницы ведёт ко второй
+	page = api.comments(path+"?limit=1", "")
+	if len(page.Comments) != 1 || !page.HasMore || page.NextCursor == "" {
+		t.Fatalf("GET %s?limit=1: got %+v", path, page)
+	}
+	page = api.comments(path+"?limit=1&cursor="+url.QueryEscape(page.NextCursor), "")
+	if len(page.Comments) != 1 || page.HasMore || page.Comments[0].Comment != "second" {
+		t.Errorf("GET %s?limit=1 second page: got %+v", path, page)
+	}
+
+	if posts := api.posts("/posts", ""); posts.Posts[0].CommentCount != 3 {
+		t.Errorf("GET /posts: comment_count %d, want 3", posts.Posts[0].CommentCount)
+	}
+}
+
+func TestCommentErrors(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	postID := api.createPost(alice, "hello")
+
+	missing := 999
+	resp := api.do(http.MethodPost, "/comments", alice.Token, map[string]any{"post_id": postID, "comment": "x", "parent_id": missing})
+	requireError(t, resp, http.StatusBadRequest, "Parent comment not found")
+
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/comments", "", map[string]any{"post_id": postID, "comment": "x"})
+
+	requireError(t, api.do(http.MethodGet, "/posts/999/comments", "", nil), http.StatusNotFound, "Post not found")
+	requireError(t, api.do(http.MethodGet, "/posts/x/comments", "", nil), http.StatusBadRequest, "Invalid post id")
+	requireError(t, api.do(http.MethodGet, fmt.Sprintf("/posts/%d/comments?cursor=garbage", postID), "", nil), http.StatusBadRequest, "Invalid cursor")
+}
+
+func TestCommentDepthLimit(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	postID := api.createPost(alice, "hello")
+
+	// комментарий к посту — глубина 0, ответы — до MaxCommentDepth
+	parent := api.addComment(alice, postID, "depth 0", nil)
+	for depth := 1; depth <= postgres.MaxCommentDepth; depth++ {
+		parent = api.addComment(alice, postID, fmt.Sprintf("depth %d", depth), &parent)
+	}
+	resp := api.do(http.MethodPost, "/comments", alice.Token, map[string]any{"post_id": postID, "comment": "too deep", "parent_id": parent})
+	requireError(t, resp, http.StatusBadRequest, "Reply depth limit reached")
+
+	// ответ на комментарий другого поста не принимается
+	other := api.createPost(alice, "other")
+	resp = api.do(http.MethodPost, "/comments", alice.Token, map[string]any{"post_id": other, "comment": "x", "parent_id": parent})
+	requireError(t, resp, http.StatusBadRequest, "Parent comment not found")
+}
+
+func TestCommentTree(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	postID := api.createPost(alice, "hello")
+
+	first := api.addComment(bob, postID, "first", nil)
+	second := api.addComment(alice, postID, "second", nil)
+	reply1 := api.addComment(alice, postID, "reply 1", &first)
+	nested := api.addComment(bob, postID, "nested", &reply1)
+	reply2 := api.addComment(bob, postID, "reply 2", &first)
+
+	var tree struct {
+		Comments []postgres.CommentBrief `json:"comments"`
+		HasMore  bool                    `json:"hasMore"`
+	}
+	path := fmt.Sprintf("/posts/%d/comments?view=tree", postID)
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path, "", nil), &tree)
+
+	ids := func(list []postgres.CommentBrief) []int {
+		out := make([]int, len(list))
+		for i, c := range list {
+			out[i] = c.CommentID
+		}
+		return out
+	}
+	// на каждом уровне — по времени создания
+	if got := ids(tree.Comments); !slices.Equal(got, []int{first, second}) || tree.HasMore {
+		t.Fatalf("GET %s: roots %v, want [%d %d]", path, got, first, second)
+	}
+	replies := tree.Comments[0].Replies
+	if got := ids(replies); !slices.Equal(got, []int{reply1, reply2}) {
+		t.Fatalf("GET %s: replies %v, want [%d %d]", path, got, reply1, reply2)
+	}
+	if got := ids(replies[0].Replies); !slices.Equal(got, []int{nested}) {
+		t.Errorf("GET %s: nested replies %v, want [%d]", path, got, nested)
+	}
+	if len(tree.Comments[1].Replies) != 0 || len(replies[1].Replies) != 0 {
+		t.Errorf("GET %s: unexpected replies in %+v", path, tree.Comments)
+	}
+
+	requireError(t, api.do(http.MethodGet, fmt.Sprintf("/posts/%d/comments?view=graph", postID), "", nil), http.StatusBadRequest, "Invalid view")
+}
+
+func TestCommentsTopOrder(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	carol := api.register("carol")
+	postID := api.createPost(alice, "hello")
+
+	var ids []int
+	for i := 0; i < 4; i++ {
+		ids = append(ids, api.addComment(alice, postID, fmt.Sprintf("comment %d", i), nil))
+	}
+	like := func(u testUser, commentID int) {
+		api.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/comments/%d/likes", commentID), u.Token, nil)
+	}
+	like(bob, ids[2])
+	like(carol, ids[2])
+	like(bob, ids[0])
+	like(bob, ids[0]) // повторный лайк не считается
+
+	// больше лайков — выше, при равенстве новые первыми
+	want := []int{ids[2], ids[0], ids[3], ids[1]}
+	path := fmt.Sprintf("/posts/%d/comments?order=top&limit=3", postID)
+	page := api.comments(path, "")
+	var got []int
+	for _, c := range page.Comments {
+		got = append(got, c.CommentID)
+	}
+	if !page.HasMore || page.NextCursor == "" {
+		t.Fatalf("GET %s: got %+v, want another page", path, page)
+	}
+	page = api.comments(path+"&cursor="+url.QueryEscape(page.NextCursor), "")
+	for _, c := range page.Comments {
+		got = append(got, c.CommentID)
+	}
+	if !slices.Equal(got, want) || page.HasMore {
+		t.Errorf("GET %s: pages %v (has more %v), want %v", path, got, page.HasMore, want)
+	}
+
+	requireError(t, api.do(http.MethodGet, fmt.Sprintf("/posts/%d/comments?order=best", postID), "", nil), http.StatusBadRequest, "Invalid order")
+}
+
+func TestCommentLikes(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	postID := api.createPost(alice, "hello")
+	commentID := api.addComment(alice, postID, "like me", nil)
+
+	likes := func() int {
+		var tree struct {
+			Comments []postgres.CommentBrief `json:"comments"`
+		}
+		decode(t, api.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/posts/%d/comments?view=tree", postID), "", nil), &tree)
+		return tree.Comments[0].LikeCount
+	}
+
+	path := fmt.Sprintf("/comments/%d/likes", commentID)
+	api.expect(http.StatusUnauthorized, http.MethodPost, path, "", nil)
+	api.expect(http.StatusCreated, http.MethodPost, path, bob.Token, nil)
+	api.expect(http.StatusCreated, http.MethodPost, path, alice.Token, nil)
+	if n := likes(); n != 2 {
+		t.Errorf("like_count after two likes: %d, want 2", n)
+	}
+
+	api.expect(http.StatusOK, http.MethodDelete, path, bob.Token, nil)
+	api.expect(http.StatusOK, http.MethodDelete, path, bob.Token, nil) // повторное снятие — не ошибка
+	if n := likes(); n != 1 {
+		t.Errorf("like_count after unlike: %d, want 1", n)
+	}
+
+	requireError(t, api.do(http.MethodPost, "/comments/999/likes", bob.Token, nil), http.StatusNotFound, "Comment not found")
+	requireError(t, api.do(http.MethodPost, "/comments/x/likes", bob.Token, nil), http.StatusBadRequest, "Invalid comment id")
+}
@@ -18,7 +18,12 @@ func (h *PostHandler) AddToFavoritesHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := h.PostStorage.AddToFavorites(r.Context(), req.UserID, req.PostID); err != nil {
+	userID, ok := actorID(w, r, req.UserID)
+	if !ok {
+		return
+	}
+
+	if err := h.PostStorage.AddToFavorites(r.Context(), userID, req.PostID); err != nil {
 		http.Error(w, "Failed to add to favorites", http.StatusInternalServerError)
 		return
 	}
@@ -27,13 +32,14 @@ func (h *PostHandler) AddToFavoritesHandler(w http.ResponseWriter, r *http.Reque
 }
 
 func (h *PostHandler) RemoveFromFavoritesHandler(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
-	postIDStr := r.URL.Query().Get("post_id")
+	postID, err := strconv.Atoi(r.URL.Query().Get("post_id"))
+	if err != nil {
+		http.Error(w, "Invalid post_id", http.StatusBadRequest)
+		return
+	}
 
-	userID, err1 := strconv.Atoi(userIDStr)
-	postID, err2 := strconv.Atoi(postIDStr)
-	if err1 != nil || err2 != nil {
-		http.Error(w, "Invalid user_id or post_id", http.StatusBadRequest)
+	userID, ok := actorIDFromParam(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
 		return
 	}
 
@@ -0,0 +1,39 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestFavorites(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	first := api.createPost(alice, "first")
+	second := api.createPost(alice, "second")
+
+	if page := api.posts("/favorites", bob.Token); len(page.Posts) != 0 {
+		t.Errorf("GET /favorites: got %v, want none", page.ids())
+	}
+
+	api.expect(http.StatusCreated, http.MethodPost, "/favorites", bob.Token, map[string]int{"post_id": first})
+	api.expect(http.StatusCreated, http.MethodPost, "/favorites", bob.Token, map[string]int{"post_id": second})
+	got := api.posts("/favorites", bob.Token).ids()
+	slices.Sort(got)
+	if !slices.Equal(got, []int{first, second}) {
+		t.Errorf("GET /favorites: got %v, want [%d %d]", got, first, second)
+	}
+	if page := api.posts("/favorites", alice.Token); len(page.Posts) != 0 {
+		t.Errorf("GET /favorites for alice: got %v, want none", page.ids())
+	}
+
+	api.expect(http.StatusOK, http.MethodDelete, fmt.Sprintf("/favorites?post_id=%d", first), bob.Token, nil)
+	if got := api.posts("/favorites", bob.Token).ids(); !slices.Equal(got, []int{second}) {
+		t.Errorf("GET /favorites after removal: got %v, want [%d]", got, second)
+	}
+
+	requireError(t, api.do(http.MethodDelete, "/favorites?post_id=x", bob.Token, nil), http.StatusBadRequest, "Invalid post_id")
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/favorites", "", nil)
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+
+	"kursach/internal/storage/postgres"
 )
 
 type FollowRequest struct {
@@ -18,12 +21,22 @@ func (h *PostHandler) AddFollowHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.FollowerID == 0 || req.FollowingID == 0 {
-		http.Error(w, "follower_id and following_id are required", http.StatusBadRequest)
+	if req.FollowingID == 0 {
+		http.Error(w, "following_id is required", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.PostStorage.AddFollow(r.Context(), req.FollowerID, req.FollowingID); err != nil {
+	followerID, ok := actorID(w, r, req.FollowerID)
+	if !ok {
+		return
+	}
+
+	err := h.PostStorage.AddFollow(r.Context(), followerID, req.FollowingID)
+	if errors.Is(err, postgres.ErrBlocked) {
+		blocked(w)
+		return
+	}
+	if err != nil {
 		http.Error(w, "Failed to add follow", http.StatusInternalServerError)
 		return
 	}
@@ -32,11 +45,14 @@ func (h *PostHandler) AddFollowHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *PostHandler) RemoveFollowHandler(w http.ResponseWriter, r *http.Request) {
-	followerID, err1 := strconv.Atoi(r.URL.Query().Get("follower_id"))
-	followingID, err2 := strconv.Atoi(r.URL.Query().Get("following_id"))
+	followingID, err := strconv.Atoi(r.URL.Query().Get("following_id"))
+	if err != nil {
+		http.Error(w, "Invalid following_id", http.StatusBadRequest)
+		return
+	}
 
-	if err1 != nil || err2 != nil {
-		http.Error(w, "Invalid follower_id or following_id", http.StatusBadRequest)
+	followerID, ok := actorIDFromParam(w, r, r.URL.Query().Get("follower_id"))
+	if !ok {
 		return
 	}
 
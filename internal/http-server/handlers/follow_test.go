@@ -0,0 +1,42 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func (a *testAPI) followings(u testUser) []int {
+	a.t.Helper()
+
+	var ids []int
+	decode(a.t, a.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/follows?follower_id=%d", u.ID), "", nil), &ids)
+	slices.Sort(ids)
+	return ids
+}
+
+func TestFollows(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	carol := api.register("carol")
+
+	api.expect(http.StatusCreated, http.MethodPost, "/follows", alice.Token, map[string]int{"following_id": bob.ID})
+	api.expect(http.StatusCreated, http.MethodPost, "/follows", alice.Token, map[string]int{"following_id": carol.ID})
+	if got := api.followings(alice); !slices.Equal(got, []int{bob.ID, carol.ID}) {
+		t.Errorf("GET /follows: got %v, want [%d %d]", got, bob.ID, carol.ID)
+	}
+	if got := api.followings(bob); len(got) != 0 {
+		t.Errorf("GET /follows for bob: got %v, want none", got)
+	}
+
+	api.expect(http.StatusOK, http.MethodDelete, fmt.Sprintf("/follows?following_id=%d", bob.ID), alice.Token, nil)
+	if got := api.followings(alice); !slices.Equal(got, []int{carol.ID}) {
+		t.Errorf("GET /follows after unfollow: got %v, want [%d]", got, carol.ID)
+	}
+
+	requireError(t, api.do(http.MethodPost, "/follows", alice.Token, map[string]int{}), http.StatusBadRequest, "following_id is required")
+	requireError(t, api.do(http.MethodGet, "/follows?follower_id=x", "", nil), http.StatusBadRequest, "Invalid follower_id")
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/follows", "", map[string]int{"following_id": bob.ID})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"kursach/internal/ranking"
+	"kursach/internal/storage/postgres"
+)
+
+// ForYouResult — страница ленты «Для вас». Ranker — ранжировщик, которым
+// она построена; Scores (только с debug=1) объясняет оценку каждого поста
+// в том же порядке, что и Posts.
+type ForYouResult struct {
+	Posts      []postgres.PostResponse `json:"posts"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+	HasMore    bool                    `json:"hasMore"`
+	Ranker     string                  `json:"ranker"`
+	Scores     []ranking.Score         `json:"scores,omitempty"`
+}
+
+// forYouCursor фиксирует момент построения ленты и ранжировщик, чтобы
+// следующие страницы продолжали тот же порядок, и последний показанный
+// пост с его оценкой: оценки пересчитываются при каждом запросе, и
+// смещение сдвигалось бы, когда у постов меняются лайки и комментарии.
+type forYouCursor struct {
+	Now    time.Time `json:"t"`
+	Ranker string    `json:"r"`
+	Score  float64   `json:"s"`
+	PostID int       `json:"id"`
+}
+
+// GetForYouHandler — GET /feed/for-you: недавние посты, упорядоченные
+// ранжировщиком. Параметры: amount, cursor из next_cursor, ranker — другой
+// ранжировщик вместо назначенного пользователю, debug=1 — оценки постов.
+func (h *PostHandler) GetForYouHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, ok := parsePageSize(w, r)
+	if !ok {
+		return
+	}
+
+	userID, ok := actorID(w, r, 0)
+	if !ok {
+		return
+	}
+
+	// Без монотонных часов: иначе возраст постов на первой странице
+	// считался бы иначе, чем после разбора момента из курсора
+	c := forYouCursor{Now: time.Now().Truncate(time.Microsecond), Ranker: h.Ranking.Pick(userID)}
+	after := false
+	if v := query.Get("cursor"); v != "" {
+		after = true
+		if err := postgres.DecodeCursor(v, &c); err != nil || c.PostID <= 0 {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+	} else if v := query.Get("ranker"); v != "" {
+		c.Ranker = v
+	}
+	scorer, ok := h.Ranking.Scorers[c.Ranker]
+	if !ok {
+		http.Error(w, "Unknown ranker", http.StatusBadRequest)
+		return
+	}
+
+	candidates, err := h.PostStorage.GetPostSignals(r.Context(), userID, c.Now.Add(-h.Ranking.Window), c.Now, h.Ranking.MaxCandidates)
+	if err != nil {
+		http.Error(w, "Failed to get feed", http.StatusInternalServerError)
+		return
+	}
+	scores := ranking.Rank(scorer, candidates, c.Now)
+	if after {
+		scores = ranking.After(scores, c.Score, c.PostID)
+	}
+
+	page := scores[:min(limit, len(scores))]
+	ids := make([]int, len(page))
+	for i, s := range page {
+		ids[i] = s.PostID
+	}
+	posts, err := h.PostStorage.GetPostsByIDs(r.Context(), userID, ids)
+	if err != nil {
+		http.Error(w, "Failed to get feed", http.StatusInternalServerError)
+		return
+	}
+
+	resp := ForYouResult{
+		Posts:   withImageURLs(h.URLs, posts),
+		HasMore: limit < len(scores),
+		Ranker:  c.Ranker,
+	}
+	if resp.HasMore {
+		last := page[len(page)-1]
+		resp.NextCursor = postgres.EncodeCursor(forYouCursor{Now: c.Now, Ranker: c.Ranker, Score: last.Value, PostID: last.PostID})
+	}
+	if query.Get("debug") == "1" {
+		// пост могли скрыть между выборкой кандидатов и загрузкой страницы
+		shown := make(map[int]bool, len(posts))
+		for _, p := range posts {
+			shown[p.PostID] = true
+		}
+		for _, s := range page {
+			if shown[s.PostID] {
+				resp.Scores = append(resp.Scores, s)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,118 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+// forYouPage — ответ GET /feed/for-you.
+type forYouPage struct {
+	postsPage
+	Ranker string `json:"ranker"`
+	Scores []struct {
+		PostID  int                `json:"post_id"`
+		Value   float64            `json:"score"`
+		Factors map[string]float64 `json:"factors"`
+	} `json:"scores"`
+}
+
+func (a *testAPI) forYou(path, token string) forYouPage {
+	a.t.Helper()
+
+	var page forYouPage
+	decode(a.t, a.expect(http.StatusOK, http.MethodGet, path, token, nil), &page)
+	if page.Posts == nil {
+		a.t.Fatalf("GET %s: posts is null", path)
+	}
+	return page
+}
+
+func TestForYou(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	carol := api.register("carol")
+
+	plain := api.createPost(bob, "plain")
+	liked := api.createPost(carol, "liked")
+	api.createPost(alice, "own")
+	latest := api.createPost(bob, "latest")
+	api.expect(http.StatusCreated, http.MethodPost, "/likes", bob.Token, map[string]int{"post_id": liked})
+	api.expect(http.StatusCreated, http.MethodPost, "/likes", carol.Token, map[string]int{"post_id": liked})
+
+	// Лайкнутый пост выше новых, собственные посты в ленту не попадают
+	page := api.forYou("/feed/for-you?debug=1", alice.Token)
+	if page.Ranker != "weighted" {
+		t.Errorf("ranker %q, want weighted", page.Ranker)
+	}
+	if got, want := page.ids(), []int{liked, latest, plain}; !slices.Equal(got, want) {
+		t.Fatalf("GET /feed/for-you: got %v, want %v", got, want)
+	}
+
+	// Оценки идут в порядке постов и объясняют их
+	if len(page.Scores) != len(page.Posts) {
+		t.Fatalf("debug: %d scores for %d posts", len(page.Scores), len(page.Posts))
+	}
+	for i, s := range page.Scores {
+		if s.PostID != page.Posts[i].PostID {
+			t.Errorf("scores[%d] is for post %d, want %d", i, s.PostID, page.Posts[i].PostID)
+		}
+		if i > 0 && s.Value > page.Scores[i-1].Value {
+			t.Errorf("scores[%d] = %v is above scores[%d] = %v", i, s.Value, i-1, page.Scores[i-1].Value)
+		}
+		if _, ok := s.Factors["decay"]; !ok {
+			t.Errorf("scores[%d]: factors %v without decay", i, s.Factors)
+		}
+	}
+	if page.Scores[0].Factors["likes"] <= 0 || page.Scores[1].Factors["likes"] != 0 {
+		t.Errorf("likes factors %v and %v, want only the liked post to have one",
+			page.Scores[0].Factors["likes"], page.Scores[1].Factors["likes"])
+	}
+
+	if page := api.forYou("/feed/for-you", alice.Token); page.Scores != nil {
+		t.Errorf("scores without debug=1: %v", page.Scores)
+	}
+
+	// Контрольный ранжировщик — по времени, как обычная лента
+	recent := api.forYou("/feed/for-you?ranker=recent", alice.Token)
+	if recent.Ranker != "recent" || !slices.Equal(recent.ids(), []int{latest, liked, plain}) {
+		t.Errorf("ranker=recent: got %q %v, want recent [%d %d %d]", recent.Ranker, recent.ids(), latest, liked, plain)
+	}
+
+	requireError(t, api.do(http.MethodGet, "/feed/for-you?ranker=popular", alice.Token, nil), http.StatusBadRequest, "Unknown ranker")
+	requireError(t, api.do(http.MethodGet, "/feed/for-you?cursor=x", alice.Token, nil), http.StatusBadRequest, "Invalid cursor")
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/feed/for-you", "", nil)
+}
+
+func TestForYouPages(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+
+	var want []int
+	for _, title := range []string{"one", "two", "three", "four", "five"} {
+		want = append(want, api.createPost(bob, title))
+	}
+	slices.Reverse(want)
+
+	// Лайк между страницами меняет оценку, но не порядок уже начатой ленты:
+	// страницы продолжаются с последнего показанного поста
+	var got []int
+	path := "/feed/for-you?amount=2"
+	for i := 0; ; i++ {
+		page := api.forYou(path, alice.Token)
+		got = append(got, page.ids()...)
+		if !page.HasMore {
+			break
+		}
+		if i == 0 {
+			api.expect(http.StatusCreated, http.MethodPost, "/likes", bob.Token, map[string]int{"post_id": page.ids()[1]})
+		}
+		path = "/feed/for-you?amount=2&cursor=" + url.QueryEscape(page.NextCursor)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("GET /feed/for-you pages: got %v, want %v", got, want)
+	}
+}
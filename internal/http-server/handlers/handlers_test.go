@@ -0,0 +1,368 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"kursach/internal/auth"
+	"kursach/internal/config"
+	"kursach/internal/http-server/handlers"
+	"kursach/internal/http-server/router"
+	"kursach/internal/media"
+	"kursach/internal/ranking"
+	"kursach/internal/storage/memory"
+)
+
+var (
+	_ handlers.UserRepository         = (*memory.Store)(nil)
+	_ handlers.PostRepository         = (*memory.Store)(nil)
+	_ handlers.NotificationRepository = (*memory.Store)(nil)
+)
+
+// testAPI — HTTP-сервер с маршрутами router.New, как в cmd/kursach, поверх
+// хранилища в памяти и локального медиахранилища во временном каталоге.
+type testAPI struct {
+	t     *testing.T
+	srv   *httptest.Server
+	store *memory.Store
+	media media.Store
+}
+
+// testFanoutLimit — лимит подписчиков для раскладки в тестах: посты авторов
+// с тремя подписчиками уже читаются из posts.
+const testFanoutLimit = 2
+
+// syncFanout раскладывает пост сразу при создании, а не через очередь
+// timeline.Fanout, чтобы лента была готова к следующему запросу.
+type syncFanout struct {
+	store *memory.Store
+}
+
+func (f syncFanout) PostCreated(postID string) {
+	f.store.FanOutPost(context.Background(), postID, testFanoutLimit)
+}
+
+// testUser — зарегистрированный пользователь и его токены.
+type testUser struct {
+	ID           int
+	Tag          string
+	Token        string
+	RefreshToken string
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	store := memory.New()
+	keys, err := auth.NewKeys(config.JWT{
+		SigningKeyID: "test",
+		Keys:         []config.JWTKey{{ID: "test", Algorithm: "HS256", Secret: "test-secret"}},
+		Issuer:       "kursach",
+		Audience:     "kursach-api",
+		Leeway:       time.Second,
+	})
+	if err != nil {
+		t.Fatalf("auth.NewKeys: %v", err)
+	}
+
+	mediaCfg := config.Media{
+		PublicBaseURL: "http://media.test",
+		JPEGQuality:   85,
+		MaxPostBytes:  8 << 20,
+	}
+	mediaCfg.PostImages.MaxBytes, mediaCfg.PostImages.MaxWidth, mediaCfg.PostImages.MaxHeight = 4<<20, 4000, 4000
+	mediaCfg.Avatars.MaxBytes, mediaCfg.Avatars.MaxWidth, mediaCfg.Avatars.MaxHeight = 1<<20, 1000, 1000
+	mediaCfg.Videos.MaxBytes = 4 << 20
+	mediaStore, err := media.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("media.NewLocal: %v", err)
+	}
+	urls, err := media.NewURLs(mediaCfg)
+	if err != nil {
+		t.Fatalf("media.NewURLs: %v", err)
+	}
+	feed, err := ranking.NewFeed(config.Ranking{Ranker: "weighted", Window: 24 * time.Hour, MaxCandidates: 100, HalfLife: time.Hour})
+	if err != nil {
+		t.Fatalf("ranking.NewFeed: %v", err)
+	}
+
+	srv := httptest.NewServer(router.New(router.Deps{
+		Log:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Keys:          keys,
+		Users:         store,
+		Posts:         store,
+		Notifications: store,
+		Health:        &handlers.HealthHandler{DB: store, Media: mediaStore},
+		Media:         mediaStore,
+		Images:        media.NewImageProcessor(mediaCfg),
+		URLs:          urls,
+		Ranking:       feed,
+		Timeline:      syncFanout{store},
+		AccessTTL:     time.Minute,
+		RefreshTTL:    time.Hour,
+	}))
+	t.Cleanup(srv.Close)
+	return &testAPI{t: t, srv: srv, store: store, media: mediaStore}
+}
+
+// do выполняет запрос с JSON-телом (body == nil — без тела) и токеном
+// (пустой — без заголовка Authorization).
+func (a *testAPI) do(method, path, token string, body any) *http.Response {
+	a.t.Helper()
+
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			a.t.Fatalf("marshal request: %v", err)
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, a.srv.URL+path, r)
+	if err != nil {
+		a.t.Fatalf("new request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return a.send(req, token)
+}
+
+func (a *testAPI) send(req *http.Request, token string) *http.Response {
+	a.t.Helper()
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := a.srv.Client().Do(req)
+	if err != nil {
+		a.t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	a.t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// expect выполняет запрос и проверяет код ответа.
+func (a *testAPI) expect(status int, method, path, token string, body any) *http.Response {
+	a.t.Helper()
+
+	resp := a.do(method, path, token, body)
+	requireStatus(a.t, resp, status)
+	return resp
+}
+
+// register регистрирует пользователя с тегом tag через POST /register.
+func (a *testAPI) register(tag string) testUser {
+	a.t.Helper()
+
+	resp := a.expect(http.StatusCreated, http.MethodPost, "/register", "", map[string]string{
+		"email":     tag + "@example.com",
+		"password":  "password-" + tag,
+		"user_name": strings.ToUpper(tag),
+		"user_tag":  tag,
+	})
+	var body struct {
+		UserInfo     map[string]any `json:"user_info"`
+		Token        string         `json:"token"`
+		RefreshToken string         `json:"refresh_token"`
+	}
+	decode(a.t, resp, &body)
+	id, _ := body.UserInfo["user_id"].(float64)
+	if id == 0 || body.Token == "" || body.RefreshToken == "" {
+		a.t.Fatalf("register %q: unexpected response %+v", tag, body)
+	}
+	return testUser{ID: int(id), Tag: tag, Token: body.Token, RefreshToken: body.RefreshToken}
+}
+
+// login входит заново и возвращает пользователя с новыми токенами: роли
+// попадают в токен только при входе.
+func (a *testAPI) login(u testUser) testUser {
+	a.t.Helper()
+
+	var tokens struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	decode(a.t, a.expect(http.StatusOK, http.MethodPost, "/auth", "", map[string]string{
+		"email": u.Tag + "@example.com", "password": "password-" + u.Tag,
+	}), &tokens)
+	u.Token, u.RefreshToken = tokens.Token, tokens.RefreshToken
+	return u
+}
+
+// withRole назначает роль в хранилище и входит заново.
+func (a *testAPI) withRole(u testUser, role string) testUser {
+	a.t.Helper()
+
+	if err := a.store.SetUserRole(context.Background(), u.ID, role); err != nil {
+		a.t.Fatalf("SetUserRole: %v", err)
+	}
+	return a.login(u)
+}
+
+// createPost создаёт пост без вложений и возвращает его id.
+func (a *testAPI) createPost(u testUser, title string, tags ...string) int {
+	a.t.Helper()
+
+	fields := map[string][]string{"title": {title}, "description": {title + " description"}, "tags": tags}
+	resp := a.send(multipartRequest(a.t, a.srv.URL+"/posts", fields, nil), u.Token)
+	requireStatus(a.t, resp, http.StatusOK)
+
+	var post struct {
+		ID string `json:"id"`
+	}
+	decode(a.t, resp, &post)
+	var id int
+	if _, err := fmt.Sscan(post.ID, &id); err != nil {
+		a.t.Fatalf("create post: unexpected id %q", post.ID)
+	}
+	return id
+}
+
+// mediaKeys возвращает ключи всех объектов медиахранилища.
+func (a *testAPI) mediaKeys() []string {
+	a.t.Helper()
+
+	var keys []string
+	err := a.media.List(context.Background(), func(key string, _ media.ObjectInfo) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		a.t.Fatalf("list media: %v", err)
+	}
+	return keys
+}
+
+// pngImage возвращает однотонную картинку PNG размером w×h.
+func pngImage(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// multipartFile — файл multipart-формы.
+type multipartFile struct {
+	Field, Name string
+	Data        []byte
+}
+
+func multipartRequest(t *testing.T, url string, fields map[string][]string, files []multipartFile) *http.Request {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for name, values := range fields {
+		for _, v := range values {
+			mw.WriteField(name, v)
+		}
+	}
+	for _, f := range files {
+		part, err := mw.CreateFormFile(f.Field, f.Name)
+		if err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		part.Write(f.Data)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatalf("close multipart writer: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, &buf)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func requireStatus(t *testing.T, resp *http.Response, status int) {
+	t.Helper()
+
+	if resp.StatusCode != status {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("%s %s: status %d, want %d; body: %s",
+			resp.Request.Method, resp.Request.URL.RequestURI(), resp.StatusCode, status, strings.TrimSpace(string(body)))
+	}
+}
+
+// requireError проверяет код ответа и текст ошибки http.Error.
+func requireError(t *testing.T, resp *http.Response, status int, message string) {
+	t.Helper()
+
+	requireStatus(t, resp, status)
+	body, _ := io.ReadAll(resp.Body)
+	if got := strings.TrimSpace(string(body)); got != message {
+		t.Errorf("%s %s: error %q, want %q", resp.Request.Method, resp.Request.URL.RequestURI(), got, message)
+	}
+}
+
+func decode(t *testing.T, resp *http.Response, v any) {
+	t.Helper()
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("%s %s: Content-Type %q, want application/json", resp.Request.Method, resp.Request.URL.RequestURI(), ct)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("%s %s: decode response: %v", resp.Request.Method, resp.Request.URL.RequestURI(), err)
+	}
+}
+
+// postsPage — ответ лент постов.
+type postsPage struct {
+	Posts []struct {
+		PostID   int    `json:"post_id"`
+		Title    string `json:"title"`
+		AuthorID int    `json:"author_id"`
+		Tags     []struct {
+			Name string `json:"name"`
+		} `json:"tags"`
+		Comments []struct {
+			AuthorID int `json:"author_id"`
+		} `json:"comments"`
+		LikeCount    int        `json:"like_count"`
+		CommentCount int        `json:"comment_count"`
+		EditedAt     *time.Time `json:"edited_at"`
+	} `json:"posts"`
+	NextCursor string `json:"next_cursor"`
+	HasMore    bool   `json:"hasMore"`
+}
+
+func (p postsPage) ids() []int {
+	ids := make([]int, len(p.Posts))
+	for i, post := range p.Posts {
+		ids[i] = post.PostID
+	}
+	return ids
+}
+
+func (a *testAPI) posts(path, token string) postsPage {
+	a.t.Helper()
+
+	var page postsPage
+	decode(a.t, a.expect(http.StatusOK, http.MethodGet, path, token, nil), &page)
+	if page.Posts == nil {
+		a.t.Fatalf("GET %s: posts is null", path)
+	}
+	return page
+}
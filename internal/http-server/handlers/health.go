@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// Pinger — проверка доступности зависимости (postgres.Storage, media.Store).
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// HealthHandler отвечает на пробы оркестратора: /healthz — процесс жив,
+// /readyz — сервис готов принимать запросы.
+type HealthHandler struct {
+	DB    Pinger
+	Media Pinger
+
+	draining atomic.Bool
+}
+
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// SetDraining переводит /readyz в состояние «не готов» на время остановки сервера.
+func (h *HealthHandler) SetDraining() {
+	h.draining.Store(true)
+}
+
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HealthResponse{Status: "ok"})
+}
+
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := map[string]string{
+		"database": "ok",
+		"media":    "ok",
+	}
+	ready := true
+
+	if h.draining.Load() {
+		checks["server"] = "draining"
+		ready = false
+	}
+	if err := h.DB.Ping(ctx); err != nil {
+		checks["database"] = err.Error()
+		ready = false
+	}
+	if err := h.Media.Ping(ctx); err != nil {
+		checks["media"] = err.Error()
+		ready = false
+	}
+
+	resp := HealthResponse{Status: "ok", Checks: checks}
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		resp.Status = "unavailable"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kursach/internal/http-server/middleware/authenticate"
+	"kursach/internal/policy"
+)
+
+// actorID возвращает пользователя, от имени которого выполняется запрос.
+// claimed — идентификатор, переданный клиентом в теле или query (0 — не передан);
+// если он не совпадает с пользователем из токена, запрос отклоняется с 403.
+func actorID(w http.ResponseWriter, r *http.Request, claimed int) (int, bool) {
+	userID, ok := authenticate.UserID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, false
+	}
+	if claimed != 0 && claimed != userID {
+		forbidden(w)
+		return 0, false
+	}
+	return userID, true
+}
+
+// currentActor возвращает пользователя вместе с ролями для проверки политик доступа.
+func currentActor(w http.ResponseWriter, r *http.Request, claimed int) (policy.Actor, bool) {
+	userID, ok := actorID(w, r, claimed)
+	if !ok {
+		return policy.Actor{}, false
+	}
+	return policy.Actor{UserID: userID, Roles: authenticate.Roles(r.Context())}, true
+}
+
+// viewerID возвращает пользователя, который смотрит публичную ленту, или 0,
+// если запрос без токена.
+func viewerID(r *http.Request) int {
+	userID, _ := authenticate.UserID(r.Context())
+	return userID
+}
+
+// blocked — ответ на действие между пользователями, один из которых
+// заблокировал другого.
+func blocked(w http.ResponseWriter) {
+	http.Error(w, "User is blocked", http.StatusForbidden)
+}
+
+// forbidden — единый ответ на запрещённое политикой действие.
+func forbidden(w http.ResponseWriter) {
+	http.Error(w, "Forbidden", http.StatusForbidden)
+}
+
+// actorIDFromParam — то же, что actorID, но для идентификатора из строкового параметра.
+func actorIDFromParam(w http.ResponseWriter, r *http.Request, param string) (int, bool) {
+	if param == "" {
+		return actorID(w, r, 0)
+	}
+	claimed, err := strconv.Atoi(param)
+	if err != nil {
+		http.Error(w, "Invalid user id", http.StatusBadRequest)
+		return 0, false
+	}
+	return actorID(w, r, claimed)
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+
+	"kursach/internal/storage/postgres"
 )
 
 type LikeRequest struct {
@@ -18,7 +21,21 @@ func (h *PostHandler) AddLikeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.PostStorage.AddLike(r.Context(), req.UserID, req.PostID); err != nil {
+	userID, ok := actorID(w, r, req.UserID)
+	if !ok {
+		return
+	}
+
+	err := h.PostStorage.AddLike(r.Context(), userID, req.PostID)
+	if errors.Is(err, postgres.ErrPostNotFound) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, postgres.ErrBlocked) {
+		blocked(w)
+		return
+	}
+	if err != nil {
 		http.Error(w, "Could not add like", http.StatusInternalServerError)
 		return
 	}
@@ -27,13 +44,14 @@ func (h *PostHandler) AddLikeHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *PostHandler) RemoveLikeHandler(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("user_id")
-	postIDStr := r.URL.Query().Get("post_id")
+	postID, err := strconv.Atoi(r.URL.Query().Get("post_id"))
+	if err != nil {
+		http.Error(w, "Invalid post_id", http.StatusBadRequest)
+		return
+	}
 
-	userID, err1 := strconv.Atoi(userIDStr)
-	postID, err2 := strconv.Atoi(postIDStr)
-	if err1 != nil || err2 != nil {
-		http.Error(w, "Invalid user_id or post_id", http.StatusBadRequest)
+	userID, ok := actorIDFromParam(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
 		return
 	}
 
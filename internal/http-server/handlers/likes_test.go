@@ -0,0 +1,32 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestLikes(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	postID := api.createPost(alice, "hello")
+
+	api.expect(http.StatusCreated, http.MethodPost, "/likes", bob.Token, map[string]int{"post_id": postID})
+	api.expect(http.StatusCreated, http.MethodPost, "/likes", alice.Token, map[string]int{"post_id": postID})
+	if got := api.posts("/posts", "").Posts[0].LikeCount; got != 2 {
+		t.Errorf("GET /posts: like_count %d, want 2", got)
+	}
+
+	api.expect(http.StatusOK, http.MethodDelete, fmt.Sprintf("/likes?post_id=%d", postID), bob.Token, nil)
+	if got := api.posts("/posts", "").Posts[0].LikeCount; got != 1 {
+		t.Errorf("GET /posts: like_count %d after unlike, want 1", got)
+	}
+
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/likes", "", map[string]int{"post_id": postID})
+	requireError(t, api.do(http.MethodDelete, "/likes?post_id=x", bob.Token, nil), http.StatusBadRequest, "Invalid post_id")
+
+	// нельзя действовать от имени другого пользователя
+	resp := api.do(http.MethodPost, "/likes", bob.Token, map[string]int{"post_id": postID, "user_id": alice.ID})
+	requireStatus(t, resp, http.StatusForbidden)
+}
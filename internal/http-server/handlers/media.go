@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kursach/internal/media"
+	"kursach/internal/storage/postgres"
+)
+
+// MediaHandler раздаёт загруженные файлы из хранилища. Ключ — путь запроса,
+// поэтому обработчик подключается через http.StripPrefix.
+func MediaHandler(store media.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// r.URL.Path, а не chi.URLParam: URLFormat отрезает расширение файла
+		body, info, err := store.Get(r.Context(), strings.TrimPrefix(r.URL.Path, "/"))
+		if errors.Is(err, media.ErrNotFound) || errors.Is(err, media.ErrInvalidKey) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to read file", http.StatusInternalServerError)
+			return
+		}
+		defer body.Close()
+
+		if info.ContentType != "" {
+			w.Header().Set("Content-Type", info.ContentType)
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+
+		// Оба драйвера отдают seekable-объект: ServeContent сам обрабатывает
+		// Range и условные запросы, без этого видео нельзя перематывать
+		if rs, ok := body.(io.ReadSeeker); ok {
+			http.ServeContent(w, r, "", info.ModTime, rs)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+		w.Header().Set("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+		io.Copy(w, body)
+	}
+}
+
+// Ключи файлов хранятся в базе, ссылки строятся при ответе.
+
+func withAvatarURL(urls *media.URLs, userInfo map[string]interface{}) map[string]interface{} {
+	if key, ok := userInfo["avatar_url"].(string); ok {
+		userInfo["avatar_url"] = urls.URL(key)
+	}
+	if images, ok := userInfo["images"].(postgres.Images); ok {
+		userInfo["images"] = imageURLs(urls, images)
+	}
+	return userInfo
+}
+
+func withImageURLs(urls *media.URLs, posts []postgres.PostResponse) []postgres.PostResponse {
+	for i := range posts {
+		posts[i].ImageURL = urls.URL(posts[i].ImageURL)
+		posts[i].Images = imageURLs(urls, posts[i].Images)
+		posts[i].Attachments = attachmentURLs(urls, posts[i].Attachments)
+	}
+	return posts
+}
+
+func attachmentURLs(urls *media.URLs, attachments []postgres.Attachment) []postgres.Attachment {
+	resolved := make([]postgres.Attachment, len(attachments))
+	for i, a := range attachments {
+		a.URL = urls.URL(a.URL)
+		a.Images = imageURLs(urls, a.Images)
+		resolved[i] = a
+	}
+	return resolved
+}
+
+func imageURLs(urls *media.URLs, images postgres.Images) postgres.Images {
+	resolved := make(postgres.Images, len(images))
+	for name, v := range images {
+		v.URL = urls.URL(v.URL)
+		resolved[name] = v
+	}
+	return resolved
+}
+
+func withReportURL(urls *media.URLs, report *postgres.Report) {
+	if report.Content != nil {
+		report.Content.ImageURL = urls.URL(report.Content.ImageURL)
+	}
+}
+
+// formOverhead — запас на текстовые поля формы сверх размера файла.
+const formOverhead = 1 << 20
+
+// parseUploadForm разбирает multipart-форму, ограничивая размер тела
+// запроса размером картинки. При ошибке отвечает клиенту и возвращает false.
+func parseUploadForm(w http.ResponseWriter, r *http.Request, maxBytes int64) bool {
+	// Заведомо слишком большое тело отклоняем, не читая его
+	if r.ContentLength > maxBytes+formOverhead {
+		http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+		return false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+formOverhead)
+	err := r.ParseMultipartForm(10 << 20)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+		return false
+	}
+	if err != nil {
+		http.Error(w, "Could not parse form", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// saveImage проверяет и перекодирует картинку и сохраняет её через storeImage.
+func saveImage(ctx context.Context, store media.Store, images *media.ImageProcessor, r io.Reader, kind media.ImageKind, prefix string) (string, postgres.Images, error) {
+	processed, err := images.Process(r, kind)
+	if err != nil {
+		return "", nil, err
+	}
+	return storeImage(ctx, store, processed, prefix)
+}
+
+// saveAttachment проверяет вложение поста и сохраняет его под ключом с префиксом prefix.
+func saveAttachment(ctx context.Context, store media.Store, images *media.ImageProcessor, r io.Reader, prefix string) (postgres.Attachment, error) {
+	processed, err := images.ProcessAttachment(r)
+	if err != nil {
+		return postgres.Attachment{}, err
+	}
+
+	if processed.Kind == media.AttachmentVideo {
+		video := processed.Video
+		key := prefix + video.Ext
+		if err := store.Put(ctx, key, bytes.NewReader(video.Data), int64(len(video.Data)), video.ContentType); err != nil {
+			return postgres.Attachment{}, err
+		}
+		return postgres.Attachment{
+			Kind:        media.AttachmentVideo,
+			URL:         key,
+			ContentType: video.ContentType,
+			Images:      postgres.Images{},
+		}, nil
+	}
+
+	key, variants, err := storeImage(ctx, store, processed.Image, prefix)
+	if err != nil {
+		return postgres.Attachment{}, err
+	}
+	original := processed.Image.Original
+	return postgres.Attachment{
+		Kind:        media.AttachmentImage,
+		URL:         key,
+		ContentType: original.ContentType,
+		Width:       original.Width,
+		Height:      original.Height,
+		Images:      variants,
+	}, nil
+}
+
+// storeImage сохраняет оригинал под ключом prefix + расширение, а
+// уменьшенные копии — prefix_<вариант>. Возвращает ключ оригинала и варианты.
+func storeImage(ctx context.Context, store media.Store, processed *media.ProcessedImage, prefix string) (string, postgres.Images, error) {
+	put := func(img *media.Image) (string, error) {
+		key := prefix + img.Ext
+		if img.Variant != "" {
+			key = prefix + "_" + img.Variant + img.Ext
+		}
+		return key, store.Put(ctx, key, bytes.NewReader(img.Data), int64(len(img.Data)), img.ContentType)
+	}
+
+	originalKey, err := put(processed.Original)
+	if err != nil {
+		return "", nil, err
+	}
+	variants := make(postgres.Images, len(processed.Variants))
+	for name, img := range processed.Variants {
+		key := originalKey
+		if img != processed.Original {
+			if key, err = put(img); err != nil {
+				return "", nil, err
+			}
+		}
+		variants[name] = postgres.ImageVariant{URL: key, Width: img.Width, Height: img.Height}
+	}
+	return originalKey, variants, nil
+}
+
+// deleteMedia удаляет файлы из хранилища; ошибки только логируются —
+// оставшиеся файлы подберёт сборщик мусора.
+func deleteMedia(ctx context.Context, store media.Store, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete media %q: %v", key, err)
+		}
+	}
+}
+
+// writeImageError отвечает клиенту на ошибку загрузки картинки или вложения.
+func writeImageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, media.ErrFileTooLarge):
+		http.Error(w, "File is too large", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, media.ErrUnsupportedMedia):
+		http.Error(w, "Unsupported media type", http.StatusUnsupportedMediaType)
+	case errors.Is(err, media.ErrImageDimensions):
+		http.Error(w, "Image dimensions exceed the limit", http.StatusBadRequest)
+	case errors.Is(err, media.ErrInvalidImage):
+		http.Error(w, "Invalid image", http.StatusBadRequest)
+	case errors.Is(err, media.ErrTooManyFrames):
+		http.Error(w, "Animation has too many frames", http.StatusBadRequest)
+	case errors.Is(err, media.ErrVideoTooLong):
+		http.Error(w, "Video is too long", http.StatusBadRequest)
+	case errors.Is(err, media.ErrInvalidVideo):
+		http.Error(w, "Invalid video", http.StatusBadRequest)
+	default:
+		http.Error(w, "Error saving file", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,154 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"kursach/internal/media"
+	"kursach/internal/storage/postgres"
+)
+
+const staticURL = "http://media.test/static/"
+
+// mediaKey возвращает ключ медиахранилища по ссылке из ответа API.
+func mediaKey(t *testing.T, url string) string {
+	t.Helper()
+
+	key, ok := strings.CutPrefix(url, staticURL)
+	if !ok {
+		t.Fatalf("media URL %q does not start with %q", url, staticURL)
+	}
+	return key
+}
+
+// requireVariants проверяет, что у картинки есть все варианты нужных
+// размеров, и возвращает ключи их файлов.
+func requireVariants(t *testing.T, images postgres.Images, want map[string][2]int) []string {
+	t.Helper()
+
+	var keys []string
+	for name, size := range want {
+		v, ok := images[name]
+		if !ok {
+			t.Fatalf("variant %s is missing in %+v", name, images)
+		}
+		if v.Width != size[0] || v.Height != size[1] {
+			t.Errorf("variant %s: %dx%d, want %dx%d", name, v.Width, v.Height, size[0], size[1])
+		}
+		keys = append(keys, mediaKey(t, v.URL))
+	}
+	return keys
+}
+
+func TestPostImageVariants(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+
+	files := []multipartFile{{Field: "files", Name: "photo.png", Data: pngImage(t, 3000, 1500)}}
+	fields := map[string][]string{"title": {"photo"}, "description": {"big"}}
+	resp := api.send(multipartRequest(t, api.srv.URL+"/posts", fields, files), alice.Token)
+	requireStatus(t, resp, http.StatusOK)
+	var post postgres.Post
+	decode(t, resp, &post)
+
+	if len(post.Attachments) != 1 {
+		t.Fatalf("POST /posts: got %d attachments, want 1", len(post.Attachments))
+	}
+	a := post.Attachments[0]
+	if a.Kind != media.AttachmentImage || a.Width != 3000 || a.Height != 1500 {
+		t.Errorf("POST /posts: attachment %+v", a)
+	}
+	keys := requireVariants(t, a.Images, map[string][2]int{
+		"thumbnail": {320, 160}, "feed": {1080, 540}, "full": {2048, 1024},
+	})
+	keys = append(keys, mediaKey(t, a.URL))
+	// обложка поста — та же первая картинка
+	if post.ImageURL != a.URL || post.Images["feed"] != a.Images["feed"] {
+		t.Errorf("POST /posts: cover %q %+v does not match the attachment", post.ImageURL, post.Images)
+	}
+
+	slices.Sort(keys)
+	stored := api.mediaKeys()
+	slices.Sort(stored)
+	if !slices.Equal(keys, stored) {
+		t.Errorf("stored media %v, want %v", stored, keys)
+	}
+	resp = api.expect(http.StatusOK, http.MethodGet, "/static/"+mediaKey(t, a.Images["thumbnail"].URL), "", nil)
+	if ct := resp.Header.Get("Content-Type"); ct != "image/png" {
+		t.Errorf("GET thumbnail: Content-Type %q, want image/png", ct)
+	}
+
+	var id int
+	fmt.Sscan(post.ID, &id)
+	api.expect(http.StatusOK, http.MethodDelete, fmt.Sprintf("/posts?post_id=%d", id), alice.Token, nil)
+	if stored := api.mediaKeys(); len(stored) != 0 {
+		t.Errorf("media after DELETE /posts: %v, want none", stored)
+	}
+}
+
+// uploadAvatar загружает аватар и возвращает ответ PATCH /users.
+func (a *testAPI) uploadAvatar(u testUser, data []byte) (avatarURL string, images postgres.Images) {
+	a.t.Helper()
+
+	req := multipartRequest(a.t, a.srv.URL+"/users", nil, []multipartFile{{Field: "avatar_url", Name: "me.png", Data: data}})
+	req.Method = http.MethodPatch
+	resp := a.send(req, u.Token)
+	requireStatus(a.t, resp, http.StatusOK)
+
+	var info struct {
+		AvatarURL string          `json:"avatar_url"`
+		Images    postgres.Images `json:"images"`
+	}
+	decode(a.t, resp, &info)
+	return info.AvatarURL, info.Images
+}
+
+func TestAvatarVariantsGC(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+
+	oldURL, oldImages := api.uploadAvatar(alice, pngImage(t, 800, 400))
+	oldKeys := requireVariants(t, oldImages, map[string][2]int{
+		"thumbnail": {64, 32}, "feed": {256, 128}, "full": {800, 400},
+	})
+	// вариант не больше оригинала — это сам оригинал
+	if oldImages["full"].URL != oldURL {
+		t.Errorf("full variant %q, want the original %q", oldImages["full"].URL, oldURL)
+	}
+
+	newURL, newImages := api.uploadAvatar(alice, pngImage(t, 100, 100))
+	newKeys := requireVariants(t, newImages, map[string][2]int{
+		"thumbnail": {64, 64}, "feed": {100, 100}, "full": {100, 100},
+	})
+	if newURL == oldURL {
+		t.Fatalf("new avatar reuses the old key %q", oldURL)
+	}
+
+	// прежний аватар остаётся в хранилище, пока его не уберёт сборщик
+	result, err := media.CollectGarbage(context.Background(), api.media, api.store.ReferencedMediaKeys, 0, false, nil)
+	if err != nil {
+		t.Fatalf("CollectGarbage: %v", err)
+	}
+	slices.Sort(oldKeys)
+	oldKeys = slices.Compact(oldKeys)
+	if result.Deleted != len(oldKeys) {
+		t.Errorf("CollectGarbage: deleted %d objects, want %d", result.Deleted, len(oldKeys))
+	}
+	stored := api.mediaKeys()
+	for _, key := range oldKeys {
+		if slices.Contains(stored, key) {
+			t.Errorf("old avatar %s survived GC", key)
+		}
+	}
+	for _, key := range newKeys {
+		if !slices.Contains(stored, key) {
+			t.Errorf("current avatar %s was collected", key)
+		}
+	}
+	api.expect(http.StatusNotFound, http.MethodGet, "/static/"+mediaKey(t, oldURL), "", nil)
+	api.expect(http.StatusOK, http.MethodGet, "/static/"+mediaKey(t, newURL), "", nil)
+}
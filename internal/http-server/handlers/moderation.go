@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"kursach/internal/storage/postgres"
+)
+
+type AssignReportRequest struct {
+	AssigneeID int `json:"assignee_id"` // по умолчанию — сам модератор
+}
+
+type ResolveReportRequest struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+}
+
+var resolveActions = map[string]bool{
+	postgres.ModerationDismiss:       true,
+	postgres.ModerationHideContent:   true,
+	postgres.ModerationDeleteContent: true,
+	postgres.ModerationSuspendAuthor: true,
+}
+
+// GetReportsHandler — очередь жалоб для модераторов.
+func (h *PostHandler) GetReportsHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentActor(w, r, 0)
+	if !ok {
+		return
+	}
+	if !actor.CanModerate() {
+		forbidden(w)
+		return
+	}
+
+	query := r.URL.Query()
+	status := query.Get("status")
+	if status == "" {
+		status = postgres.ReportStatusOpen
+	}
+	limit, offset := 50, 0
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 100 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		offset = n
+	}
+
+	reports, err := h.PostStorage.GetReports(r.Context(), status, limit, offset)
+	if err != nil {
+		http.Error(w, "Failed to get reports", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range reports {
+		withReportURL(h.URLs, &reports[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+func (h *PostHandler) AssignReportHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentActor(w, r, 0)
+	if !ok {
+		return
+	}
+	if !actor.CanModerate() {
+		forbidden(w)
+		return
+	}
+
+	reportID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid report id", http.StatusBadRequest)
+		return
+	}
+
+	var req AssignReportRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.AssigneeID == 0 {
+		req.AssigneeID = actor.UserID
+	}
+
+	err = h.PostStorage.AssignReport(r.Context(), reportID, actor.UserID, req.AssigneeID)
+	if writeReportError(w, err) {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *PostHandler) ResolveReportHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentActor(w, r, 0)
+	if !ok {
+		return
+	}
+	if !actor.CanModerate() {
+		forbidden(w)
+		return
+	}
+
+	reportID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid report id", http.StatusBadRequest)
+		return
+	}
+
+	var req ResolveReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !resolveActions[req.Action] {
+		http.Error(w, "Unknown action", http.StatusBadRequest)
+		return
+	}
+
+	keys, err := h.PostStorage.ResolveReport(r.Context(), reportID, actor.UserID, req.Action, req.Note)
+	if writeReportError(w, err) {
+		return
+	}
+	deleteMedia(r.Context(), h.Media, keys)
+
+	report, err := h.PostStorage.GetReport(r.Context(), reportID)
+	if err != nil {
+		http.Error(w, "Failed to get report", http.StatusInternalServerError)
+		return
+	}
+
+	withReportURL(h.URLs, report)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetReportActionsHandler — журнал действий модераторов по жалобе.
+func (h *PostHandler) GetReportActionsHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentActor(w, r, 0)
+	if !ok {
+		return
+	}
+	if !actor.CanModerate() {
+		forbidden(w)
+		return
+	}
+
+	reportID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid report id", http.StatusBadRequest)
+		return
+	}
+
+	actions, err := h.PostStorage.GetModerationActions(r.Context(), reportID)
+	if err != nil {
+		http.Error(w, "Failed to get moderation actions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(actions)
+}
+
+// writeReportError отвечает клиенту на ошибку работы с жалобой и сообщает, была ли ошибка.
+func writeReportError(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, postgres.ErrReportNotFound):
+		http.Error(w, "Report not found", http.StatusNotFound)
+	case errors.Is(err, postgres.ErrReportClosed):
+		http.Error(w, "Report already closed", http.StatusConflict)
+	case errors.Is(err, postgres.ErrReportTarget):
+		http.Error(w, "Reported content no longer exists", http.StatusConflict)
+	case errors.Is(err, postgres.ErrActionTarget):
+		http.Error(w, "Action is not applicable to the reported object", http.StatusBadRequest)
+	case errors.Is(err, postgres.ErrAssignee):
+		http.Error(w, "Assignee must be a moderator", http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, "Failed to update report", http.StatusInternalServerError)
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"kursach/internal/storage/postgres"
+)
+
+// report отправляет жалобу и возвращает её id.
+func (a *testAPI) report(u testUser, body map[string]any) int {
+	a.t.Helper()
+
+	var report reportStatus
+	decode(a.t, a.expect(http.StatusCreated, http.MethodPost, "/reports", u.Token, body), &report)
+	return report.ReportID
+}
+
+func TestAssignReport(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	mod := api.withRole(api.register("mod"), "moderator")
+	other := api.withRole(api.register("other"), "moderator")
+	reportID := api.report(bob, map[string]any{"reason": "spam", "user_id": alice.ID})
+
+	path := fmt.Sprintf("/moderation/reports/%d/assign", reportID)
+	requireError(t, api.do(http.MethodPost, path, bob.Token, nil), http.StatusForbidden, "Forbidden")
+	// назначить можно только модератора
+	requireError(t, api.do(http.MethodPost, path, mod.Token, map[string]int{"assignee_id": bob.ID}),
+		http.StatusUnprocessableEntity, "Assignee must be a moderator")
+	requireError(t, api.do(http.MethodPost, path, mod.Token, map[string]int{"assignee_id": 999}),
+		http.StatusUnprocessableEntity, "Assignee must be a moderator")
+
+	api.expect(http.StatusOK, http.MethodPost, path, mod.Token, nil)
+	api.expect(http.StatusOK, http.MethodPost, path, mod.Token, map[string]int{"assignee_id": other.ID})
+
+	var reports []postgres.Report
+	decode(t, api.expect(http.StatusOK, http.MethodGet, "/moderation/reports", mod.Token, nil), &reports)
+	if len(reports) != 1 || reports[0].AssigneeID == nil || *reports[0].AssigneeID != other.ID {
+		t.Errorf("GET /moderation/reports: got %+v, want report assigned to %d", reports, other.ID)
+	}
+
+	requireError(t, api.do(http.MethodPost, "/moderation/reports/999/assign", mod.Token, nil), http.StatusNotFound, "Report not found")
+}
+
+func TestReportActions(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	mod := api.withRole(api.register("mod"), "moderator")
+	reportID := api.report(bob, map[string]any{"reason": "spam", "user_id": alice.ID})
+
+	api.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/moderation/reports/%d/assign", reportID), mod.Token, nil)
+	api.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/moderation/reports/%d/resolve", reportID), mod.Token,
+		map[string]string{"action": postgres.ModerationDismiss, "note": "not spam"})
+
+	path := fmt.Sprintf("/moderation/reports/%d/actions", reportID)
+	requireError(t, api.do(http.MethodGet, path, bob.Token, nil), http.StatusForbidden, "Forbidden")
+
+	var actions []postgres.ModerationAction
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path, mod.Token, nil), &actions)
+	if len(actions) != 2 {
+		t.Fatalf("GET %s: got %+v, want 2 actions", path, actions)
+	}
+	for i, want := range []string{postgres.ModerationAssign, postgres.ModerationDismiss} {
+		if a := actions[i]; a.Action != want || a.ReportID != reportID || a.ModeratorID != mod.ID {
+			t.Errorf("GET %s: action %d is %+v, want %q by %d", path, i, a, want, mod.ID)
+		}
+	}
+	if actions[1].Note != "not spam" {
+		t.Errorf("GET %s: note %q, want %q", path, actions[1].Note, "not spam")
+	}
+}
+
+func TestSuspendAuthor(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	mod := api.withRole(api.register("mod"), "moderator")
+	postID := api.createPost(alice, "hello")
+	reportID := api.report(bob, map[string]any{"reason": "spam", "post_id": postID})
+
+	api.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/moderation/reports/%d/resolve", reportID), mod.Token,
+		map[string]string{"action": postgres.ModerationSuspendAuthor})
+
+	// блокировка автора отзывает все его сессии
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/auth/sessions", alice.Token, nil)
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": alice.RefreshToken})
+	api.expect(http.StatusOK, http.MethodGet, "/auth/sessions", bob.Token, nil)
+}
+
+func TestDeleteReportedContent(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	mod := api.withRole(api.register("mod"), "moderator")
+
+	files := []multipartFile{{Field: "files", Name: "a.png", Data: pngImage(t, 64, 48)}}
+	fields := map[string][]string{"title": {"hello"}, "description": {"with image"}}
+	resp := api.send(multipartRequest(t, api.srv.URL+"/posts", fields, files), alice.Token)
+	requireStatus(t, resp, http.StatusOK)
+	var post struct {
+		ID string `json:"id"`
+	}
+	decode(t, resp, &post)
+	var postID int
+	fmt.Sscan(post.ID, &postID)
+	if len(api.mediaKeys()) == 0 {
+		t.Fatal("POST /posts: no media stored for the attachment")
+	}
+
+	commentID := api.addComment(bob, api.createPost(bob, "other"), "rude", nil)
+	postReport := api.report(bob, map[string]any{"reason": "spam", "post_id": postID})
+	commentReport := api.report(alice, map[string]any{"reason": "harassment", "comment_id": commentID})
+
+	deleteContent := map[string]string{"action": postgres.ModerationDeleteContent}
+	api.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/moderation/reports/%d/resolve", postReport), mod.Token, deleteContent)
+	api.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/moderation/reports/%d/resolve", commentReport), mod.Token, deleteContent)
+
+	// пост удаляется вместе с файлами вложений
+	requireError(t, api.do(http.MethodGet, fmt.Sprintf("/posts/%d/comments", postID), bob.Token, nil), http.StatusNotFound, "Post not found")
+	if keys := api.mediaKeys(); len(keys) != 0 {
+		t.Errorf("media after delete_content: %v, want none", keys)
+	}
+	requireError(t, api.do(http.MethodDelete, "/comments", bob.Token, map[string]int{"comment_id": commentID}),
+		http.StatusNotFound, "Comment not found")
+}
@@ -2,25 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
-	"kursach/internal/storage/postgres"
 	"net/http"
-	"strconv"
 )
 
 type NotificationHandler struct {
-	NotificationStorage *postgres.NotificationStorage
+	NotificationStorage NotificationRepository
 }
 
 func (h *NotificationHandler) GetUnreadNotificationsHandler(w http.ResponseWriter, r *http.Request) {
-	userIDStr := r.URL.Query().Get("userId")
-	if userIDStr == "" {
-		http.Error(w, "Missing userId", http.StatusBadRequest)
-		return
-	}
-
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		http.Error(w, "Invalid userId", http.StatusBadRequest)
+	userID, ok := actorIDFromParam(w, r, r.URL.Query().Get("userId"))
+	if !ok {
 		return
 	}
 
@@ -35,8 +26,6 @@ func (h *NotificationHandler) GetUnreadNotificationsHandler(w http.ResponseWrite
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(notifications)
 }
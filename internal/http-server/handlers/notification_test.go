@@ -0,0 +1,60 @@
+package handlers_test
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+type notification struct {
+	UserID   int  `json:"user_id"`
+	TypeID   int  `json:"type_id"`
+	EntityID int  `json:"entity_id"`
+	IsRead   bool `json:"is_read"`
+}
+
+func (a *testAPI) notifications(u testUser) []notification {
+	a.t.Helper()
+
+	var list []notification
+	decode(a.t, a.expect(http.StatusOK, http.MethodGet, "/notifications", u.Token, nil), &list)
+	return list
+}
+
+func TestNotifications(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	postID := api.createPost(alice, "hello")
+
+	api.expect(http.StatusCreated, http.MethodPost, "/likes", bob.Token, map[string]int{"post_id": postID})
+	api.addComment(bob, postID, "hi", nil)
+	api.expect(http.StatusCreated, http.MethodPost, "/follows", bob.Token, map[string]int{"following_id": alice.ID})
+	// свои действия уведомлений не создают
+	api.expect(http.StatusCreated, http.MethodPost, "/likes", alice.Token, map[string]int{"post_id": postID})
+
+	list := api.notifications(alice)
+	var types []int
+	for _, n := range list {
+		if n.UserID != alice.ID || n.IsRead {
+			t.Errorf("GET /notifications: unexpected %+v", n)
+		}
+		types = append(types, n.TypeID)
+		if n.TypeID == 3 && n.EntityID != bob.ID {
+			t.Errorf("GET /notifications: follow notification entity %d, want %d", n.EntityID, bob.ID)
+		}
+	}
+	slices.Sort(types)
+	if !slices.Equal(types, []int{1, 2, 3}) {
+		t.Errorf("GET /notifications: types %v, want [1 2 3]", types)
+	}
+
+	// выданные уведомления помечаются прочитанными
+	if list := api.notifications(alice); len(list) != 0 {
+		t.Errorf("GET /notifications again: got %+v, want none", list)
+	}
+	if list := api.notifications(bob); len(list) != 0 {
+		t.Errorf("GET /notifications for bob: got %+v, want none", list)
+	}
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/notifications", "", nil)
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"kursach/internal/storage/postgres"
+)
+
+// AttachmentEdit — элемент списка вложений при правке поста: либо
+// существующее вложение (AttachmentID), либо новый файл (File — индекс
+// в поле files формы).
+type AttachmentEdit struct {
+	AttachmentID int    `json:"attachment_id,omitempty"`
+	File         *int   `json:"file,omitempty"`
+	AltText      string `json:"alt_text"`
+}
+
+// UpdatePostHandler — PATCH /posts/{id}. Multipart-форма: title, description,
+// tags (заменяют набор тегов; пустое значение — убрать все) и attachments —
+// JSON-список []AttachmentEdit в нужном порядке вместе с новыми файлами files.
+// Не переданные поля не меняются.
+func (h *PostHandler) UpdatePostHandler(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid post id", http.StatusBadRequest)
+		return
+	}
+	if !parseUploadForm(w, r, h.Images.MaxPostBytes()) {
+		return
+	}
+
+	actor, ok := currentActor(w, r, 0)
+	if !ok {
+		return
+	}
+	authorID, err := h.PostStorage.GetPostAuthorID(r.Context(), postID)
+	if errors.Is(err, postgres.ErrPostNotFound) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update post", http.StatusInternalServerError)
+		return
+	}
+	if !actor.CanEditPost(authorID) {
+		forbidden(w)
+		return
+	}
+
+	var update postgres.PostUpdate
+	form := r.MultipartForm.Value
+	if v, ok := form["title"]; ok {
+		update.Title = &v[0]
+	}
+	if v, ok := form["description"]; ok {
+		update.Description = &v[0]
+	}
+	if v, ok := form["tags"]; ok {
+		update.Tags = []string{}
+		for _, tag := range v {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				update.Tags = append(update.Tags, tag)
+			}
+		}
+	}
+
+	files := r.MultipartForm.File["files"]
+	var edits []AttachmentEdit
+	if v, ok := form["attachments"]; ok {
+		if err := json.Unmarshal([]byte(v[0]), &edits); err != nil {
+			http.Error(w, "Invalid attachments", http.StatusBadRequest)
+			return
+		}
+		if edits == nil {
+			edits = []AttachmentEdit{} // пустой список — убрать все вложения
+		}
+		if msg := validateAttachmentEdits(edits, len(files)); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+	} else if len(files) > 0 {
+		http.Error(w, "Files require an attachments list", http.StatusBadRequest)
+		return
+	}
+
+	if update.Title == nil && update.Description == nil && update.Tags == nil && edits == nil {
+		http.Error(w, "Nothing to update", http.StatusBadRequest)
+		return
+	}
+
+	// Новые файлы сохраняем до записи в базу; при ошибке удаляем их
+	var added []postgres.Attachment
+	if edits != nil {
+		update.Attachments = make([]postgres.Attachment, len(edits))
+		for i, edit := range edits {
+			if edit.File == nil {
+				update.Attachments[i] = postgres.Attachment{ID: edit.AttachmentID, AltText: edit.AltText}
+				continue
+			}
+			attachment, err := h.saveAttachment(r, files[*edit.File])
+			if err != nil {
+				deleteMedia(r.Context(), h.Media, postgres.MediaKeys(added))
+				writeImageError(w, err)
+				return
+			}
+			attachment.AltText = edit.AltText
+			added = append(added, attachment)
+			update.Attachments[i] = attachment
+		}
+	}
+
+	err = h.PostStorage.UpdatePost(r.Context(), postID, actor.UserID, update)
+	if err != nil {
+		deleteMedia(r.Context(), h.Media, postgres.MediaKeys(added))
+	}
+	switch {
+	case errors.Is(err, postgres.ErrPostNotFound):
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	case errors.Is(err, postgres.ErrAttachmentNotFound):
+		http.Error(w, "Attachment not found", http.StatusBadRequest)
+		return
+	case err != nil:
+		http.Error(w, "Failed to update post", http.StatusInternalServerError)
+		return
+	}
+
+	post, err := h.PostStorage.GetPost(r.Context(), postID)
+	if err != nil {
+		http.Error(w, "Failed to get post", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(withImageURLs(h.URLs, []postgres.PostResponse{*post})[0])
+}
+
+// validateAttachmentEdits проверяет список вложений и возвращает текст ошибки.
+// Каждый файл формы должен встречаться в списке ровно один раз.
+func validateAttachmentEdits(edits []AttachmentEdit, files int) string {
+	if len(edits) > maxPostAttachments {
+		return fmt.Sprintf("Too many attachments (max %d)", maxPostAttachments)
+	}
+	used := make(map[int]bool)
+	for _, edit := range edits {
+		if len([]rune(edit.AltText)) > maxAltTextLength {
+			return "Alt text is too long"
+		}
+		switch {
+		case edit.File != nil && edit.AttachmentID != 0, edit.File == nil && edit.AttachmentID == 0:
+			return "Attachment must have either attachment_id or file"
+		case edit.File != nil && (*edit.File < 0 || *edit.File >= files || used[*edit.File]):
+			return "Invalid file index"
+		case edit.File != nil:
+			used[*edit.File] = true
+		}
+	}
+	if len(used) != files {
+		return "Every file must be listed in attachments"
+	}
+	return ""
+}
+
+// GetPostRevisionsHandler — история правок поста для модераторов.
+func (h *PostHandler) GetPostRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentActor(w, r, 0)
+	if !ok {
+		return
+	}
+	if !actor.CanModerate() {
+		forbidden(w)
+		return
+	}
+
+	postID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid post id", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.PostStorage.GetPostAuthorID(r.Context(), postID); errors.Is(err, postgres.ErrPostNotFound) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to get post revisions", http.StatusInternalServerError)
+		return
+	}
+
+	revisions, err := h.PostStorage.GetPostRevisions(r.Context(), postID)
+	if err != nil {
+		http.Error(w, "Failed to get post revisions", http.StatusInternalServerError)
+		return
+	}
+	for i := range revisions {
+		revisions[i].Attachments = attachmentURLs(h.URLs, revisions[i].Attachments)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
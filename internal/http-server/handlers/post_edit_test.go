@@ -0,0 +1,131 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"kursach/internal/storage/postgres"
+)
+
+// editPost отправляет PATCH /posts/{id} с полями формы и файлами.
+func (a *testAPI) editPost(u testUser, postID int, fields map[string][]string, files []multipartFile) *http.Response {
+	a.t.Helper()
+
+	req := multipartRequest(a.t, fmt.Sprintf("%s/posts/%d", a.srv.URL, postID), fields, files)
+	req.Method = http.MethodPatch
+	return a.send(req, u.Token)
+}
+
+func tagNames(tags []postgres.TagBrief) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestUpdatePost(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	postID := api.createPost(alice, "helo", "go", "db")
+
+	if page := api.posts("/posts", alice.Token); page.Posts[0].EditedAt != nil {
+		t.Errorf("GET /posts: new post has edited_at %v", page.Posts[0].EditedAt)
+	}
+
+	fix := map[string][]string{"title": {"hello"}, "tags": {"go"}}
+	requireError(t, api.editPost(bob, postID, fix, nil), http.StatusForbidden, "Forbidden")
+	// модератор может смотреть историю, но не править чужие посты
+	requireError(t, api.editPost(api.withRole(bob, "moderator"), postID, fix, nil), http.StatusForbidden, "Forbidden")
+	requireError(t, api.editPost(alice, 999, fix, nil), http.StatusNotFound, "Post not found")
+	requireError(t, api.editPost(alice, postID, map[string][]string{}, nil), http.StatusBadRequest, "Nothing to update")
+	requireStatus(t, api.editPost(testUser{}, postID, fix, nil), http.StatusUnauthorized)
+
+	resp := api.editPost(alice, postID, fix, nil)
+	requireStatus(t, resp, http.StatusOK)
+	var post postgres.PostResponse
+	decode(t, resp, &post)
+	if post.Title != "hello" || post.Description != "helo description" || post.EditedAt == nil {
+		t.Errorf("PATCH /posts/%d: got %+v", postID, post)
+	}
+	if tags := tagNames(post.Tags); !slices.Equal(tags, []string{"go"}) {
+		t.Errorf("PATCH /posts/%d: tags %v, want [go]", postID, tags)
+	}
+
+	page := api.posts("/posts", alice.Token)
+	if p := page.Posts[0]; p.Title != "hello" || p.EditedAt == nil || !p.EditedAt.Equal(*post.EditedAt) {
+		t.Errorf("GET /posts after edit: got %+v", p)
+	}
+}
+
+func TestUpdatePostAttachments(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	postID := api.createPost(alice, "hello")
+
+	files := []multipartFile{{Field: "files", Name: "cat.png", Data: pngImage(t, 40, 30)}}
+	attach := map[string][]string{"attachments": {`[{"file": 0, "alt_text": "a cat"}]`}}
+	resp := api.editPost(alice, postID, attach, files)
+	requireStatus(t, resp, http.StatusOK)
+	var post postgres.PostResponse
+	decode(t, resp, &post)
+	if len(post.Attachments) != 1 || post.Attachments[0].AltText != "a cat" || post.ImageURL != post.Attachments[0].URL {
+		t.Fatalf("PATCH /posts/%d: attachments %+v, cover %q", postID, post.Attachments, post.ImageURL)
+	}
+
+	requireError(t, api.editPost(alice, postID, nil, files), http.StatusBadRequest, "Files require an attachments list")
+	requireError(t, api.editPost(alice, postID, map[string][]string{"attachments": {`[{"file": 1}]`}}, files),
+		http.StatusBadRequest, "Invalid file index")
+	requireError(t, api.editPost(alice, postID, map[string][]string{"attachments": {`[{"attachment_id": 999}]`}}, nil),
+		http.StatusBadRequest, "Attachment not found")
+
+	// пустой список убирает все вложения вместе с обложкой
+	resp = api.editPost(alice, postID, map[string][]string{"attachments": {`[]`}}, nil)
+	requireStatus(t, resp, http.StatusOK)
+	post = postgres.PostResponse{}
+	decode(t, resp, &post)
+	if len(post.Attachments) != 0 || post.ImageURL != "" {
+		t.Errorf("PATCH /posts/%d with no attachments: got %+v, cover %q", postID, post.Attachments, post.ImageURL)
+	}
+}
+
+func TestPostRevisions(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	mod := api.withRole(api.register("mod"), "moderator")
+	postID := api.createPost(alice, "first", "go", "db")
+
+	requireStatus(t, api.editPost(alice, postID, map[string][]string{"title": {"second"}, "tags": {""}}, nil), http.StatusOK)
+	requireStatus(t, api.editPost(alice, postID, map[string][]string{"description": {"third"}}, nil), http.StatusOK)
+
+	path := fmt.Sprintf("/posts/%d/revisions", postID)
+	requireError(t, api.do(http.MethodGet, path, alice.Token, nil), http.StatusForbidden, "Forbidden")
+	requireError(t, api.do(http.MethodGet, "/posts/999/revisions", mod.Token, nil), http.StatusNotFound, "Post not found")
+
+	// ревизия — состояние поста до правки, новые первыми
+	var revisions []postgres.PostRevision
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path, mod.Token, nil), &revisions)
+	if len(revisions) != 2 {
+		t.Fatalf("GET %s: got %d revisions, want 2", path, len(revisions))
+	}
+	if r := revisions[0]; r.Title != "second" || r.Description != "first description" || len(r.Tags) != 0 {
+		t.Errorf("GET %s: latest revision %+v", path, r)
+	}
+	r := revisions[1]
+	slices.Sort(r.Tags)
+	if r.Title != "first" || !slices.Equal(r.Tags, []string{"db", "go"}) {
+		t.Errorf("GET %s: oldest revision %+v", path, r)
+	}
+	for _, r := range revisions {
+		if r.PostID != postID || r.EditorID != alice.ID {
+			t.Errorf("GET %s: revision %+v, want post %d edited by %d", path, r, postID, alice.ID)
+		}
+	}
+	if revisions[0].CreatedAt.Before(revisions[1].CreatedAt) {
+		t.Errorf("GET %s: revisions are not newest first", path)
+	}
+}
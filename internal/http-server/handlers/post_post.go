@@ -2,26 +2,51 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
+	"kursach/internal/logger/sl"
+	"kursach/internal/media"
+	"kursach/internal/ranking"
 	"kursach/internal/storage/postgres"
 )
 
 type PostHandler struct {
-	UserStorage *postgres.UserStorage
-	PostStorage *postgres.PostStorage
+	Log         *slog.Logger
+	UserStorage UserRepository
+	PostStorage PostRepository
+	Media       media.Store
+	Images      *media.ImageProcessor
+	URLs        *media.URLs
+	Timeline    TimelineFanout // nil — посты не раскладываются по лентам
+	Ranking     *ranking.Feed
 }
+
+// Ограничения на вложения поста
+const (
+	maxPostAttachments = 10
+	maxAltTextLength   = 1000
+)
+
+// Размер страницы ленты: amount по умолчанию и наибольший допустимый
+const (
+	defaultPostsPageSize = 20
+	maxPostsPageSize     = 100
+)
+
+// PostsResult — страница ленты. NextCursor передаётся в параметре
+// cursor, чтобы получить следующую страницу.
 type PostsResult struct {
-	Posts   []postgres.PostResponse `json:"posts"`
-	HasMore bool                    `json:"hasMore"`
+	Posts      []postgres.PostResponse `json:"posts"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+	HasMore    bool                    `json:"hasMore"`
 }
 type GetPostsRequest struct {
 	StartIndex int  `json:"start_index"`
@@ -30,20 +55,17 @@ type GetPostsRequest struct {
 }
 
 func (h *PostHandler) AddPost(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(20 << 20); err != nil { // 20 MB max
-		http.Error(w, "Could not parse form", http.StatusBadRequest)
+	if !parseUploadForm(w, r, h.Images.MaxPostBytes()) {
 		return
 	}
 
-	userIDStr := r.FormValue("userId")
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		http.Error(w, "Invalid userId", http.StatusBadRequest)
+	userID, ok := actorIDFromParam(w, r, r.FormValue("userId"))
+	if !ok {
 		return
 	}
 
 	// Проверяем, есть ли пользователь
-	_, err = h.UserStorage.GetUserInfo(r.Context(), userID)
+	_, err := h.UserStorage.GetUserInfo(r.Context(), userID)
 	if err != nil {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
@@ -52,47 +74,49 @@ func (h *PostHandler) AddPost(w http.ResponseWriter, r *http.Request) {
 	title := r.FormValue("title")
 	description := r.FormValue("description")
 
-	var imageURL string
-	// Обработка файла (если есть)
-	fileHeaders := r.MultipartForm.File["file"]
-	if len(fileHeaders) > 0 {
-		file, err := fileHeaders[0].Open()
-		if err != nil {
-			http.Error(w, "Error opening file", http.StatusInternalServerError)
-			return
-		}
-		defer file.Close()
-
-		uploadDir := "./uploads/posts"
-		if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-			http.Error(w, "Error creating upload directory", http.StatusInternalServerError)
+	// Вложения: поле files (в порядке следования) и alt — подписи к ним
+	// по тому же индексу. Старое поле file — единственное вложение.
+	fileHeaders := r.MultipartForm.File["files"]
+	if len(fileHeaders) == 0 {
+		fileHeaders = r.MultipartForm.File["file"]
+	}
+	if len(fileHeaders) > maxPostAttachments {
+		http.Error(w, fmt.Sprintf("Too many attachments (max %d)", maxPostAttachments), http.StatusBadRequest)
+		return
+	}
+	altTexts := r.MultipartForm.Value["alt"]
+	for _, alt := range altTexts {
+		if len([]rune(alt)) > maxAltTextLength {
+			http.Error(w, "Alt text is too long", http.StatusBadRequest)
 			return
 		}
+	}
 
-		filename := fmt.Sprintf("post_%s_%s", uuid.NewString(), filepath.Base(fileHeaders[0].Filename))
-		filepath := filepath.Join(uploadDir, filename)
-
-		dst, err := os.Create(filepath)
+	var attachments []postgres.Attachment
+	for i, fileHeader := range fileHeaders {
+		attachment, err := h.saveAttachment(r, fileHeader)
 		if err != nil {
-			http.Error(w, "Error saving file", http.StatusInternalServerError)
+			deleteMedia(r.Context(), h.Media, postgres.MediaKeys(attachments))
+			writeImageError(w, err)
 			return
 		}
-		defer dst.Close()
-
-		if _, err := io.Copy(dst, file); err != nil {
-			http.Error(w, "Error writing file", http.StatusInternalServerError)
-			return
+		if i < len(altTexts) {
+			attachment.AltText = altTexts[i]
 		}
-
-		imageURL = "localhost:8082/static/posts/" + filename
+		attachments = append(attachments, attachment)
 	}
 
+	// Обложка — первая картинка среди вложений
+	imageURL, images := postgres.Cover(attachments)
+
 	// Создаем пост
 	newPost := postgres.Post{
 		AuthorID:    userID,
 		Title:       title,
 		Description: description,
 		ImageURL:    imageURL,
+		Images:      images,
+		Attachments: attachments,
 		CreatedAt:   time.Now(),
 	}
 
@@ -101,9 +125,13 @@ func (h *PostHandler) AddPost(w http.ResponseWriter, r *http.Request) {
 	// Сохраняем пост (без тегов)
 	err = h.PostStorage.CreatePost(r.Context(), &newPost)
 	if err != nil {
+		deleteMedia(r.Context(), h.Media, postgres.MediaKeys(attachments))
 		http.Error(w, "Could not create post", http.StatusInternalServerError)
 		return
 	}
+	if h.Timeline != nil {
+		h.Timeline.PostCreated(newPost.ID)
+	}
 
 	// Сохраняем теги и связи
 	for _, tagName := range tags {
@@ -121,58 +149,106 @@ func (h *PostHandler) AddPost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Отдаем ответ с новым постом
+	newPost.ImageURL = h.URLs.URL(newPost.ImageURL)
+	newPost.Images = imageURLs(h.URLs, newPost.Images)
+	newPost.Attachments = attachmentURLs(h.URLs, newPost.Attachments)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(newPost)
 }
-func (h *PostHandler) GetPostsHandler(w http.ResponseWriter, r *http.Request) {
-	// Чтение query-параметров
-	query := r.URL.Query()
-
-	startIndexStr := query.Get("startIndex")
-	amountStr := query.Get("amount")
-	userIDStr := query.Get("userId") // может быть пустым
 
-	// Парсим startIndex
-	startIndex, err := strconv.Atoi(startIndexStr)
+// saveAttachment сохраняет одно вложение; имя файла от клиента не используется.
+func (h *PostHandler) saveAttachment(r *http.Request, fileHeader *multipart.FileHeader) (postgres.Attachment, error) {
+	file, err := fileHeader.Open()
 	if err != nil {
-		http.Error(w, "Invalid startIndex", http.StatusBadRequest)
-		return
+		return postgres.Attachment{}, err
 	}
+	defer file.Close()
+	return saveAttachment(r.Context(), h.Media, h.Images, file, "posts/post_"+uuid.NewString())
+}
 
-	// Парсим amount
-	amount, err := strconv.Atoi(amountStr)
-	if err != nil {
+// parsePostsQuery читает параметры страницы ленты: amount (не больше
+// maxPostsPageSize) и cursor из next_cursor предыдущей страницы. Старые
+// клиенты могут вместо курсора передавать startIndex.
+func parsePostsQuery(w http.ResponseWriter, r *http.Request) (postgres.PostsQuery, bool) {
+	query := r.URL.Query()
+
+	limit, ok := parsePageSize(w, r)
+	if !ok {
+		return postgres.PostsQuery{}, false
+	}
+	q := postgres.PostsQuery{Limit: limit}
+	if v := query.Get("cursor"); v != "" {
+		var after postgres.PostCursor
+		if err := postgres.DecodeCursor(v, &after); err != nil {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return q, false
+		}
+		q.After = &after
+	} else if v := query.Get("startIndex"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid startIndex", http.StatusBadRequest)
+			return q, false
+		}
+		q.Offset = n
+	}
+	return q, true
+}
+
+// parsePageSize читает amount: по умолчанию defaultPostsPageSize, больше
+// maxPostsPageSize не отдаётся.
+func parsePageSize(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("amount")
+	if v == "" {
+		return defaultPostsPageSize, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
 		http.Error(w, "Invalid amount", http.StatusBadRequest)
+		return 0, false
+	}
+	return min(n, maxPostsPageSize), true
+}
+
+func (h *PostHandler) writePosts(w http.ResponseWriter, posts []postgres.PostResponse, next *postgres.PostCursor) {
+	resp := PostsResult{
+		Posts:   withImageURLs(h.URLs, posts),
+		HasMore: next != nil,
+	}
+	if next != nil {
+		resp.NextCursor = postgres.EncodeCursor(next)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *PostHandler) GetPostsHandler(w http.ResponseWriter, r *http.Request) {
+	q, ok := parsePostsQuery(w, r)
+	if !ok {
 		return
 	}
 
 	// userId — опционален
-	var userID *int
-	if userIDStr != "" {
+	if userIDStr := r.URL.Query().Get("userId"); userIDStr != "" {
 		uid, err := strconv.Atoi(userIDStr)
 		if err != nil {
 			http.Error(w, "Invalid userId", http.StatusBadRequest)
 			return
 		}
-		userID = &uid
+		q.AuthorID = &uid
 	}
+	q.ViewerID = viewerID(r)
 
 	// Получаем посты
-	posts, hasMore, err := h.PostStorage.GetPosts(r.Context(), userID, startIndex, amount)
-	log.Println(err)
+	posts, next, err := h.PostStorage.GetPosts(r.Context(), q)
 	if err != nil {
+		h.Log.Error("failed to get posts", sl.Err(err), slog.String("request_id", middleware.GetReqID(r.Context())))
 		http.Error(w, "Failed to get posts", http.StatusInternalServerError)
 		return
 	}
 
-	// Формируем ответ
-	resp := PostsResult{
-		Posts:   posts,
-		HasMore: hasMore,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	h.writePosts(w, posts, next)
 }
 
 func (h *PostHandler) DeletePostHandler(w http.ResponseWriter, r *http.Request) {
@@ -183,51 +259,57 @@ func (h *PostHandler) DeletePostHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.PostStorage.DeletePost(r.Context(), postID); err != nil {
-		http.Error(w, "Failed to delete post", http.StatusInternalServerError)
+	actor, ok := currentActor(w, r, 0)
+	if !ok {
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-}
-func (h *PostHandler) GetFavoritePostsHandler(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
-
-	startIndexStr := query.Get("startIndex")
-	amountStr := query.Get("amount")
-	userIDStr := query.Get("userId")
-
-	startIndex, err := strconv.Atoi(startIndexStr)
+	authorID, err := h.PostStorage.GetPostAuthorID(r.Context(), postID)
+	if errors.Is(err, postgres.ErrPostNotFound) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Invalid startIndex", http.StatusBadRequest)
+		http.Error(w, "Failed to delete post", http.StatusInternalServerError)
+		return
+	}
+	if !actor.CanDeletePost(authorID) {
+		forbidden(w)
 		return
 	}
 
-	amount, err := strconv.Atoi(amountStr)
+	keys, err := h.PostStorage.DeletePost(r.Context(), postID)
+	if errors.Is(err, postgres.ErrPostNotFound) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Invalid amount", http.StatusBadRequest)
+		http.Error(w, "Failed to delete post", http.StatusInternalServerError)
 		return
 	}
+	deleteMedia(r.Context(), h.Media, keys)
 
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		http.Error(w, "Invalid userId", http.StatusBadRequest)
+	w.WriteHeader(http.StatusOK)
+}
+func (h *PostHandler) GetFavoritePostsHandler(w http.ResponseWriter, r *http.Request) {
+	q, ok := parsePostsQuery(w, r)
+	if !ok {
 		return
 	}
 
-	posts, hasMore, err := h.PostStorage.GetFavoritePosts(r.Context(), userID, startIndex, amount)
-	if err != nil {
-		http.Error(w, "Failed to get favorite posts", http.StatusInternalServerError)
+	userID, ok := actorIDFromParam(w, r, r.URL.Query().Get("userId"))
+	if !ok {
 		return
 	}
 
-	resp := PostsResult{
-		Posts:   posts,
-		HasMore: hasMore,
+	q.ViewerID = userID
+	posts, next, err := h.PostStorage.GetFavoritePosts(r.Context(), userID, q)
+	if err != nil {
+		http.Error(w, "Failed to get favorite posts", http.StatusInternalServerError)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	h.writePosts(w, posts, next)
 }
 
 func (h *PostHandler) GetAllTagsHandler(w http.ResponseWriter, r *http.Request) {
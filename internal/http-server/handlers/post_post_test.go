@@ -0,0 +1,91 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestCreatePost(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+
+	id := api.createPost(alice, "hello", "go", "news")
+
+	page := api.posts("/posts", "")
+	if len(page.Posts) != 1 {
+		t.Fatalf("GET /posts: got %d posts, want 1", len(page.Posts))
+	}
+	post := page.Posts[0]
+	if post.PostID != id || post.Title != "hello" || post.AuthorID != alice.ID {
+		t.Errorf("GET /posts: got %+v", post)
+	}
+	var tags []string
+	for _, tag := range post.Tags {
+		tags = append(tags, tag.Name)
+	}
+	slices.Sort(tags)
+	if !slices.Equal(tags, []string{"go", "news"}) {
+		t.Errorf("GET /posts: tags %v, want [go news]", tags)
+	}
+
+	req := multipartRequest(t, api.srv.URL+"/posts", map[string][]string{"title": {"anonymous"}}, nil)
+	requireStatus(t, api.send(req, ""), http.StatusUnauthorized)
+}
+
+func TestGetPostsCursor(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+
+	var ids []int
+	for i := range 5 {
+		ids = append(ids, api.createPost(alice, fmt.Sprintf("post %d", i)))
+	}
+	bobPost := api.createPost(bob, "bob's post")
+
+	// новые посты первыми, страницы не пересекаются
+	var got []int
+	path := "/posts?amount=2&userId=" + fmt.Sprint(alice.ID)
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("GET /posts: too many pages")
+		}
+		page := api.posts(path, "")
+		got = append(got, page.ids()...)
+		if !page.HasMore {
+			if page.NextCursor != "" {
+				t.Errorf("GET %s: next_cursor %q on the last page", path, page.NextCursor)
+			}
+			break
+		}
+		if len(page.Posts) != 2 || page.NextCursor == "" {
+			t.Fatalf("GET %s: got %d posts, next_cursor %q", path, len(page.Posts), page.NextCursor)
+		}
+		path = "/posts?amount=2&userId=" + fmt.Sprint(alice.ID) + "&cursor=" + url.QueryEscape(page.NextCursor)
+	}
+	want := slices.Clone(ids)
+	slices.Reverse(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("GET /posts: got %v, want %v", got, want)
+	}
+
+	if all := api.posts("/posts", "").ids(); len(all) != 6 || all[0] != bobPost {
+		t.Errorf("GET /posts: got %v, want 6 posts starting with %d", all, bobPost)
+	}
+
+	requireError(t, api.do(http.MethodGet, "/posts?cursor=garbage", "", nil), http.StatusBadRequest, "Invalid cursor")
+	requireError(t, api.do(http.MethodGet, "/posts?amount=0", "", nil), http.StatusBadRequest, "Invalid amount")
+	requireError(t, api.do(http.MethodGet, "/posts?userId=x", "", nil), http.StatusBadRequest, "Invalid userId")
+}
+
+func TestGetPostsEmpty(t *testing.T) {
+	api := newTestAPI(t)
+
+	page := api.posts("/posts", "")
+	if len(page.Posts) != 0 || page.HasMore || page.NextCursor != "" {
+		t.Errorf("GET /posts: got %+v, want an empty page", page)
+	}
+}
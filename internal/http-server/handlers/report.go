@@ -2,14 +2,54 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"kursach/internal/storage/postgres"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
 )
 
+const maxReportEvidence = 10
+
 type CreateReportRequest struct {
-	ReporterID  int    `json:"reporter_id"`
-	Description string `json:"description"`
-	PostID      *int   `json:"post_id,omitempty"`
-	CommentID   *int   `json:"comment_id,omitempty"`
+	ReporterID  int               `json:"reporter_id"`
+	Reason      string            `json:"reason"`
+	Description string            `json:"description"`
+	PostID      *int              `json:"post_id,omitempty"`
+	CommentID   *int              `json:"comment_id,omitempty"`
+	UserID      *int              `json:"user_id,omitempty"`
+	Evidence    []EvidenceRequest `json:"evidence,omitempty"`
+}
+
+// EvidenceRequest — ссылка на пост или комментарий (ровно одно из полей).
+type EvidenceRequest struct {
+	PostID    *int `json:"post_id,omitempty"`
+	CommentID *int `json:"comment_id,omitempty"`
+}
+
+// ReportStatusResponse — то, что видит автор жалобы.
+type ReportStatusResponse struct {
+	ReportID     int        `json:"report_id"`
+	ReportTypeID int        `json:"report_type_id"`
+	TargetID     int        `json:"target_id"`
+	Reason       string     `json:"reason"`
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ResolvedAt   *time.Time `json:"resolved_at"`
+}
+
+func newReportStatusResponse(report postgres.Report) ReportStatusResponse {
+	return ReportStatusResponse{
+		ReportID:     report.ID,
+		ReportTypeID: report.ReportTypeID,
+		TargetID:     report.TargetID,
+		Reason:       report.Reason,
+		Status:       report.Status,
+		CreatedAt:    report.CreatedAt,
+		ResolvedAt:   report.ResolvedAt,
+	}
 }
 
 func (h *PostHandler) CreateReportHandler(w http.ResponseWriter, r *http.Request) {
@@ -19,29 +59,154 @@ func (h *PostHandler) CreateReportHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if req.PostID == nil && req.CommentID == nil {
-		http.Error(w, "Either post_id or comment_id must be provided", http.StatusBadRequest)
+	reporterID, ok := actorID(w, r, req.ReporterID)
+	if !ok {
+		return
+	}
+
+	var targets int
+	for _, id := range []*int{req.PostID, req.CommentID, req.UserID} {
+		if id != nil {
+			targets++
+		}
+	}
+	if targets != 1 {
+		http.Error(w, "Exactly one of post_id, comment_id or user_id must be provided", http.StatusBadRequest)
+		return
+	}
+	if req.Reason == "" {
+		http.Error(w, "reason is required", http.StatusBadRequest)
 		return
 	}
-	if req.PostID != nil && req.CommentID != nil {
-		http.Error(w, "Only one of post_id or comment_id should be provided", http.StatusBadRequest)
+	if len(req.Evidence) > maxReportEvidence {
+		http.Error(w, "Too many evidence items", http.StatusBadRequest)
 		return
 	}
 
-	var targetID, reportTypeID int
-	if req.PostID != nil {
-		targetID = *req.PostID
-		reportTypeID = 1 // post
-	} else {
-		targetID = *req.CommentID
-		reportTypeID = 2 // comment
+	report := postgres.Report{
+		ReporterID:  reporterID,
+		Reason:      req.Reason,
+		Description: req.Description,
+	}
+	switch {
+	case req.PostID != nil:
+		report.TargetID = *req.PostID
+		report.ReportTypeID = postgres.ReportTypePost
+	case req.CommentID != nil:
+		report.TargetID = *req.CommentID
+		report.ReportTypeID = postgres.ReportTypeComment
+	default:
+		if *req.UserID == reporterID {
+			http.Error(w, "You cannot report yourself", http.StatusBadRequest)
+			return
+		}
+		report.TargetID = *req.UserID
+		report.ReportTypeID = postgres.ReportTypeUser
 	}
 
-	err := h.PostStorage.CreateReport(r.Context(), req.ReporterID, targetID, reportTypeID, req.Description)
+	for _, e := range req.Evidence {
+		switch {
+		case e.PostID != nil && e.CommentID == nil:
+			report.Evidence = append(report.Evidence, postgres.ReportEvidence{ReportTypeID: postgres.ReportTypePost, TargetID: *e.PostID})
+		case e.CommentID != nil && e.PostID == nil:
+			report.Evidence = append(report.Evidence, postgres.ReportEvidence{ReportTypeID: postgres.ReportTypeComment, TargetID: *e.CommentID})
+		default:
+			http.Error(w, "Each evidence item must have exactly one of post_id or comment_id", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := h.PostStorage.CreateReport(r.Context(), &report)
+	if errors.Is(err, postgres.ErrReportExists) {
+		http.Error(w, "Report already exists", http.StatusConflict)
+		return
+	}
+	if errors.Is(err, postgres.ErrUnknownReason) {
+		http.Error(w, "Unknown reason", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, postgres.ErrPostNotFound) {
+		http.Error(w, "Post not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, postgres.ErrCommentNotFound) {
+		http.Error(w, "Comment not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, postgres.ErrUserNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Failed to create report", http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newReportStatusResponse(report))
+}
+
+func (h *PostHandler) GetReportReasonsHandler(w http.ResponseWriter, r *http.Request) {
+	reasons, err := h.PostStorage.GetReportReasons(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to fetch report reasons", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reasons)
+}
+
+// GetMyReportsHandler — жалобы, поданные текущим пользователем.
+func (h *PostHandler) GetMyReportsHandler(w http.ResponseWriter, r *http.Request) {
+	reporterID, ok := actorID(w, r, 0)
+	if !ok {
+		return
+	}
+
+	reports, err := h.PostStorage.GetReportsByReporter(r.Context(), reporterID)
+	if err != nil {
+		http.Error(w, "Failed to get reports", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]ReportStatusResponse, 0, len(reports))
+	for _, report := range reports {
+		resp = append(resp, newReportStatusResponse(report))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetReportStatusHandler — статус жалобы для её автора.
+func (h *PostHandler) GetReportStatusHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentActor(w, r, 0)
+	if !ok {
+		return
+	}
+
+	reportID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid report id", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.PostStorage.GetReport(r.Context(), reportID)
+	if errors.Is(err, postgres.ErrReportNotFound) {
+		http.Error(w, "Report not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to get report", http.StatusInternalServerError)
+		return
+	}
+	if report.ReporterID != actor.UserID && !actor.CanModerate() {
+		// не раскрываем существование чужих жалоб
+		http.Error(w, "Report not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newReportStatusResponse(*report))
 }
@@ -0,0 +1,118 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"kursach/internal/storage/postgres"
+)
+
+type reportStatus struct {
+	ReportID int    `json:"report_id"`
+	TargetID int    `json:"target_id"`
+	Reason   string `json:"reason"`
+	Status   string `json:"status"`
+}
+
+func TestReportReasons(t *testing.T) {
+	api := newTestAPI(t)
+
+	var reasons []struct {
+		Code  string `json:"code"`
+		Title string `json:"title"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodGet, "/reports/reasons", "", nil), &reasons)
+	if len(reasons) == 0 || reasons[0].Code != "spam" || reasons[0].Title == "" {
+		t.Errorf("GET /reports/reasons: got %+v", reasons)
+	}
+}
+
+func TestReports(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	postID := api.createPost(alice, "hello")
+
+	var report reportStatus
+	resp := api.expect(http.StatusCreated, http.MethodPost, "/reports", bob.Token, map[string]any{"reason": "spam", "post_id": postID})
+	decode(t, resp, &report)
+	if report.ReportID == 0 || report.TargetID != postID || report.Reason != "spam" || report.Status == "" {
+		t.Fatalf("POST /reports: got %+v", report)
+	}
+
+	resp = api.do(http.MethodPost, "/reports", bob.Token, map[string]any{"reason": "spam", "post_id": postID})
+	requireError(t, resp, http.StatusConflict, "Report already exists")
+	resp = api.do(http.MethodPost, "/reports", bob.Token, map[string]any{"reason": "nonsense", "user_id": alice.ID})
+	requireError(t, resp, http.StatusBadRequest, "Unknown reason")
+	resp = api.do(http.MethodPost, "/reports", bob.Token, map[string]any{"reason": "spam", "user_id": bob.ID})
+	requireError(t, resp, http.StatusBadRequest, "You cannot report yourself")
+	resp = api.do(http.MethodPost, "/reports", bob.Token, map[string]any{"reason": "spam", "post_id": postID, "user_id": alice.ID})
+	requireError(t, resp, http.StatusBadRequest, "Exactly one of post_id, comment_id or user_id must be provided")
+
+	// объект жалобы и доказательства должны существовать
+	resp = api.do(http.MethodPost, "/reports", bob.Token, map[string]any{"reason": "spam", "post_id": 999})
+	requireError(t, resp, http.StatusNotFound, "Post not found")
+	resp = api.do(http.MethodPost, "/reports", bob.Token, map[string]any{"reason": "spam", "comment_id": 999})
+	requireError(t, resp, http.StatusNotFound, "Comment not found")
+	resp = api.do(http.MethodPost, "/reports", bob.Token, map[string]any{"reason": "spam", "user_id": 999})
+	requireError(t, resp, http.StatusNotFound, "User not found")
+	resp = api.do(http.MethodPost, "/reports", bob.Token, map[string]any{
+		"reason": "harassment", "user_id": alice.ID, "evidence": []map[string]int{{"post_id": postID}, {"comment_id": 999}},
+	})
+	requireError(t, resp, http.StatusNotFound, "Comment not found")
+
+	var mine []reportStatus
+	decode(t, api.expect(http.StatusOK, http.MethodGet, "/reports", bob.Token, nil), &mine)
+	if len(mine) != 1 || mine[0].ReportID != report.ReportID {
+		t.Errorf("GET /reports: got %+v", mine)
+	}
+
+	path := fmt.Sprintf("/reports/%d", report.ReportID)
+	var status reportStatus
+	decode(t, api.expect(http.StatusOK, http.MethodGet, path, bob.Token, nil), &status)
+	if status != report {
+		t.Errorf("GET %s: got %+v, want %+v", path, status, report)
+	}
+	// чужая жалоба не видна
+	requireError(t, api.do(http.MethodGet, path, alice.Token, nil), http.StatusNotFound, "Report not found")
+}
+
+func TestResolveReport(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	mod := api.register("mod")
+	postID := api.createPost(alice, "hello")
+	api.addComment(bob, postID, "comment", nil)
+
+	var report reportStatus
+	decode(t, api.expect(http.StatusCreated, http.MethodPost, "/reports", bob.Token, map[string]any{"reason": "spam", "post_id": postID}), &report)
+
+	path := fmt.Sprintf("/moderation/reports/%d/resolve", report.ReportID)
+	hide := map[string]string{"action": postgres.ModerationHideContent, "note": "spam"}
+	requireError(t, api.do(http.MethodPost, path, mod.Token, hide), http.StatusForbidden, "Forbidden")
+
+	mod = api.withRole(mod, "moderator")
+
+	requireError(t, api.do(http.MethodPost, path, mod.Token, map[string]string{"action": "nonsense"}), http.StatusBadRequest, "Unknown action")
+	api.expect(http.StatusOK, http.MethodPost, path, mod.Token, hide)
+	requireError(t, api.do(http.MethodPost, path, mod.Token, hide), http.StatusConflict, "Report already closed")
+
+	var status reportStatus
+	decode(t, api.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/reports/%d", report.ReportID), bob.Token, nil), &status)
+	if status.Status == report.Status {
+		t.Errorf("GET /reports/%d: status is still %q after resolve", report.ReportID, status.Status)
+	}
+
+	// скрытый пост пропадает из ленты, его комментарии недоступны
+	if page := api.posts("/posts", bob.Token); len(page.Posts) != 0 {
+		t.Errorf("GET /posts: hidden post is still listed: %v", page.ids())
+	}
+	requireError(t, api.do(http.MethodGet, fmt.Sprintf("/posts/%d/comments", postID), bob.Token, nil), http.StatusNotFound, "Post not found")
+
+	// лайкнуть и прокомментировать его тоже нельзя
+	requireError(t, api.do(http.MethodPost, "/likes", bob.Token, map[string]int{"post_id": postID}), http.StatusNotFound, "Post not found")
+	requireError(t, api.do(http.MethodPost, "/comments", bob.Token, map[string]any{"post_id": postID, "comment": "still here?"}),
+		http.StatusNotFound, "Post not found")
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"kursach/internal/policy"
+	"kursach/internal/storage/postgres"
+)
+
+type SetRoleRequest struct {
+	UserID int    `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// SetUserRoleHandler назначает пользователю роль (только для администраторов).
+func (h *UserHandler) SetUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	actor, ok := currentActor(w, r, 0)
+	if !ok {
+		return
+	}
+	if !actor.CanManageRoles() {
+		forbidden(w)
+		return
+	}
+
+	var req SetRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.UserID == 0 || !policy.IsValidRole(req.Role) {
+		http.Error(w, "user_id and a valid role are required", http.StatusBadRequest)
+		return
+	}
+
+	err := h.UserStorage.SetUserRole(r.Context(), req.UserID, req.Role)
+	if errors.Is(err, postgres.ErrUserNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Could not update role", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
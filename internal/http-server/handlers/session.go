@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"kursach/internal/auth"
+	"kursach/internal/http-server/middleware/authenticate"
+	"kursach/internal/storage/postgres"
+)
+
+// TokenPair — короткоживущий access-токен и refresh-токен для его обновления.
+type TokenPair struct {
+	Token        string    `json:"token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type SessionResponse struct {
+	postgres.Session
+	Current bool `json:"current"`
+}
+
+// startSession создаёт запись в user_tokens и выпускает пару токенов.
+func (h *UserHandler) startSession(ctx context.Context, r *http.Request, userID int) (TokenPair, error) {
+	refreshToken, err := auth.NewRefreshToken()
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	session := postgres.Session{
+		SessionID: uuid.NewString(),
+		UserID:    userID,
+		Device:    r.UserAgent(),
+		IP:        clientIP(r),
+		ExpiresAt: time.Now().Add(h.RefreshTTL),
+	}
+	if err := h.UserStorage.CreateSession(ctx, &session, auth.HashRefreshToken(refreshToken)); err != nil {
+		return TokenPair{}, err
+	}
+
+	return h.issueTokens(ctx, session.UserID, session.SessionID, refreshToken)
+}
+
+// issueTokens выпускает access-токен с актуальными ролями пользователя:
+// смена роли вступает в силу при следующем обновлении токена.
+func (h *UserHandler) issueTokens(ctx context.Context, userID int, sessionID, refreshToken string) (TokenPair, error) {
+	role, err := h.UserStorage.GetUserRole(ctx, userID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	expiresAt := time.Now().Add(h.AccessTTL)
+	token, err := h.Keys.Issue(userID, sessionID, []string{role}, expiresAt)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return TokenPair{Token: token, ExpiresAt: expiresAt, RefreshToken: refreshToken}, nil
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов.
+// Старый refresh-токен после этого недействителен.
+func (h *UserHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	newRefreshToken, err := auth.NewRefreshToken()
+	if err != nil {
+		http.Error(w, "Token generation failed", http.StatusInternalServerError)
+		return
+	}
+
+	session, err := h.UserStorage.RotateSession(r.Context(),
+		auth.HashRefreshToken(req.RefreshToken), auth.HashRefreshToken(newRefreshToken),
+		clientIP(r), time.Now().Add(h.RefreshTTL),
+	)
+	if errors.Is(err, postgres.ErrSessionNotFound) {
+		http.Error(w, "Refresh token is invalid", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to refresh session", http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := h.issueTokens(r.Context(), session.UserID, session.SessionID, newRefreshToken)
+	if err != nil {
+		http.Error(w, "Token generation failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// Logout отзывает текущую сессию.
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actorID(w, r, 0)
+	if !ok {
+		return
+	}
+	sessionID, _ := authenticate.SessionID(r.Context())
+
+	err := h.UserStorage.RevokeSession(r.Context(), userID, sessionID)
+	if err != nil && !errors.Is(err, postgres.ErrSessionNotFound) {
+		http.Error(w, "Failed to log out", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// LogoutAll отзывает все сессии пользователя, включая текущую.
+func (h *UserHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actorID(w, r, 0)
+	if !ok {
+		return
+	}
+
+	if err := h.UserStorage.RevokeAllSessions(r.Context(), userID); err != nil {
+		http.Error(w, "Failed to log out", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *UserHandler) GetSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actorID(w, r, 0)
+	if !ok {
+		return
+	}
+	currentID, _ := authenticate.SessionID(r.Context())
+
+	sessions, err := h.UserStorage.GetActiveSessions(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to get sessions", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, SessionResponse{Session: session, Current: session.SessionID == currentID})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *UserHandler) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actorID(w, r, 0)
+	if !ok {
+		return
+	}
+
+	sessionID := r.URL.Query().Get("session_id")
+	if _, err := uuid.Parse(sessionID); err != nil {
+		http.Error(w, "Invalid session_id", http.StatusBadRequest)
+		return
+	}
+
+	err := h.UserStorage.RevokeSession(r.Context(), userID, sessionID)
+	if errors.Is(err, postgres.ErrSessionNotFound) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"kursach/internal/storage/postgres"
+)
+
+// Хранилища, с которыми работают обработчики. Реализации — postgres
+// (основная) и memory (для тестов без базы данных).
+
+type UserRepository interface {
+	IsUserTagTaken(ctx context.Context, tag string) (bool, error)
+	IsEmailTaken(ctx context.Context, email string) (bool, error)
+	CreateFullUser(ctx context.Context, email, password, name, tag string) error
+	GetUserByEmail(ctx context.Context, email string) (int, string, error)
+	GetUserInfo(ctx context.Context, userID int) (map[string]interface{}, error)
+	GetUserInfoByTag(ctx context.Context, userTag string) (map[string]interface{}, error)
+	UpdateUser(ctx context.Context, userID int, updates map[string]interface{}) error
+
+	GetUserRole(ctx context.Context, userID int) (string, error)
+	SetUserRole(ctx context.Context, userID int, role string) error
+	IsUserSuspended(ctx context.Context, userID int) (bool, error)
+
+	CreateSession(ctx context.Context, session *postgres.Session, tokenHash string) error
+	RotateSession(ctx context.Context, oldHash, newHash, ip string, expiresAt time.Time) (*postgres.Session, error)
+	TouchSession(ctx context.Context, sessionID string) (bool, error)
+	RevokeSession(ctx context.Context, userID int, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID int) error
+	GetActiveSessions(ctx context.Context, userID int) ([]postgres.Session, error)
+}
+
+type PostRepository interface {
+	CreatePost(ctx context.Context, post *postgres.Post) error
+	GetOrCreateTag(ctx context.Context, name string) (int, error)
+	AddPostTag(ctx context.Context, postID string, tagID int) error
+	GetPosts(ctx context.Context, q postgres.PostsQuery) ([]postgres.PostResponse, *postgres.PostCursor, error)
+	GetFavoritePosts(ctx context.Context, userID int, q postgres.PostsQuery) ([]postgres.PostResponse, *postgres.PostCursor, error)
+	GetTimeline(ctx context.Context, userID int, q postgres.PostsQuery) ([]postgres.PostResponse, *postgres.PostCursor, error)
+	GetPostSignals(ctx context.Context, userID int, since, until time.Time, limit int) ([]postgres.PostSignals, error)
+	GetPostsByIDs(ctx context.Context, viewerID int, ids []int) ([]postgres.PostResponse, error)
+	GetPostAuthorID(ctx context.Context, postID int) (int, error)
+	GetVisiblePostAuthorID(ctx context.Context, postID int) (int, error)
+	GetPost(ctx context.Context, postID int) (*postgres.PostResponse, error)
+	UpdatePost(ctx context.Context, postID, editorID int, update postgres.PostUpdate) error
+	GetPostRevisions(ctx context.Context, postID int) ([]postgres.PostRevision, error)
+	DeletePost(ctx context.Context, postID int) ([]string, error)
+	GetAllTags(ctx context.Context) ([]map[string]interface{}, error)
+
+	AddComment(ctx context.Context, comment *postgres.Comment) error
+	UpdateComment(ctx context.Context, commentID int, text string) (*postgres.Comment, error)
+	GetCommentsByPostID(ctx context.Context, postID int, q postgres.CommentsQuery) ([]postgres.CommentBrief, *postgres.CommentCursor, error)
+	AddCommentLike(ctx context.Context, userID, commentID int) error
+	RemoveCommentLike(ctx context.Context, userID, commentID int) error
+	GetCommentAuthorID(ctx context.Context, commentID int) (int, error)
+	DeleteComment(ctx context.Context, commentID int) error
+
+	AddLike(ctx context.Context, userID, postID int) error
+	RemoveLike(ctx context.Context, userID, postID int) error
+
+	AddFollow(ctx context.Context, followerID, followingID int) error
+	RemoveFollow(ctx context.Context, followerID, followingID int) error
+	GetUserFollowings(ctx context.Context, followerID int) ([]int, error)
+
+	AddUserBlock(ctx context.Context, blockerID, blockedID int) error
+	IsUserBlocked(ctx context.Context, blockerID, blockedID int) (bool, error)
+	IsBlockedBetween(ctx context.Context, userID, otherID int) (bool, error)
+	GetBlockedUsers(ctx context.Context, blockerID int) ([]postgres.BlockedUser, error)
+	RemoveBlock(ctx context.Context, blockerID, blockedID int) error
+
+	AddToFavorites(ctx context.Context, userID, postID int) error
+	RemoveFromFavorites(ctx context.Context, userID, postID int) error
+
+	GetReportReasons(ctx context.Context) ([]postgres.ReportReason, error)
+	CreateReport(ctx context.Context, report *postgres.Report) error
+	GetReport(ctx context.Context, reportID int) (*postgres.Report, error)
+	GetReports(ctx context.Context, status string, limit, offset int) ([]postgres.Report, error)
+	GetReportsByReporter(ctx context.Context, reporterID int) ([]postgres.Report, error)
+	AssignReport(ctx context.Context, reportID, moderatorID, assigneeID int) error
+	ResolveReport(ctx context.Context, reportID, moderatorID int, action, note string) ([]string, error)
+	GetModerationActions(ctx context.Context, reportID int) ([]postgres.ModerationAction, error)
+}
+
+type NotificationRepository interface {
+	GetUnreadNotifications(ctx context.Context, userID int) ([]postgres.Notification, error)
+	MarkAllAsRead(ctx context.Context, userID int) error
+}
+
+var (
+	_ UserRepository         = (*postgres.UserStorage)(nil)
+	_ PostRepository         = (*postgres.PostStorage)(nil)
+	_ NotificationRepository = (*postgres.NotificationStorage)(nil)
+)
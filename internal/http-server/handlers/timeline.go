@@ -0,0 +1,30 @@
+package handlers
+
+import "net/http"
+
+// TimelineFanout получает новые посты, чтобы разложить их по лентам подписчиков.
+type TimelineFanout interface {
+	PostCreated(postID string)
+}
+
+// GetTimelineHandler — GET /timeline: посты пользователя и тех, на кого он
+// подписан, страницами по курсору (см. parsePostsQuery).
+func (h *PostHandler) GetTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	q, ok := parsePostsQuery(w, r)
+	if !ok {
+		return
+	}
+
+	userID, ok := actorID(w, r, 0)
+	if !ok {
+		return
+	}
+
+	posts, next, err := h.PostStorage.GetTimeline(r.Context(), userID, q)
+	if err != nil {
+		http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
+		return
+	}
+
+	h.writePosts(w, posts, next)
+}
@@ -0,0 +1,163 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"kursach/internal/storage/postgres"
+)
+
+// timeline листает GET /timeline по курсору и возвращает id всех постов.
+func (a *testAPI) timeline(u testUser) []int {
+	a.t.Helper()
+
+	var ids []int
+	path := "/timeline?amount=50"
+	for {
+		page := a.posts(path, u.Token)
+		ids = append(ids, page.ids()...)
+		if !page.HasMore {
+			return ids
+		}
+		path = "/timeline?amount=50&cursor=" + url.QueryEscape(page.NextCursor)
+	}
+}
+
+func (a *testAPI) follow(u, following testUser) {
+	a.t.Helper()
+	a.expect(http.StatusCreated, http.MethodPost, "/follows", u.Token, map[string]int{"following_id": following.ID})
+}
+
+func (a *testAPI) unfollow(u, following testUser) {
+	a.t.Helper()
+	a.expect(http.StatusOK, http.MethodDelete, fmt.Sprintf("/follows?following_id=%d", following.ID), u.Token, nil)
+}
+
+func TestTimeline(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+	carol := api.register("carol")
+
+	api.follow(alice, bob)
+	own := api.createPost(alice, "alice post")
+	before := api.createPost(bob, "bob post")
+	api.createPost(carol, "carol post")
+	after := api.createPost(bob, "bob second post")
+
+	if got, want := api.timeline(alice), []int{after, before, own}; !slices.Equal(got, want) {
+		t.Errorf("GET /timeline: got %v, want %v", got, want)
+	}
+	if got, want := api.timeline(bob), []int{after, before}; !slices.Equal(got, want) {
+		t.Errorf("GET /timeline for bob: got %v, want %v", got, want)
+	}
+
+	// Страницы по курсору не пересекаются и не теряют постов
+	first := api.posts("/timeline?amount=2", alice.Token)
+	if !first.HasMore || !slices.Equal(first.ids(), []int{after, before}) {
+		t.Fatalf("GET /timeline?amount=2: got %v (hasMore %v), want [%d %d]", first.ids(), first.HasMore, after, before)
+	}
+	second := api.posts("/timeline?amount=2&cursor="+url.QueryEscape(first.NextCursor), alice.Token)
+	if second.HasMore || !slices.Equal(second.ids(), []int{own}) {
+		t.Errorf("GET /timeline second page: got %v (hasMore %v), want [%d]", second.ids(), second.HasMore, own)
+	}
+
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/timeline", "", nil)
+	requireError(t, api.do(http.MethodGet, "/timeline?amount=0", alice.Token, nil), http.StatusBadRequest, "Invalid amount")
+}
+
+func TestTimelineLargeAuthor(t *testing.T) {
+	api := newTestAPI(t)
+	star := api.register("star")
+	fans := []testUser{api.register("alice"), api.register("bob"), api.register("carol")}
+	for _, fan := range fans {
+		api.follow(fan, star)
+	}
+
+	// Подписчиков больше testFanoutLimit: пост не раскладывается и
+	// читается из posts
+	post := api.createPost(star, "star post")
+	for _, fan := range fans {
+		if got := api.timeline(fan); !slices.Equal(got, []int{post}) {
+			t.Errorf("GET /timeline for %s: got %v, want [%d]", fan.Tag, got, post)
+		}
+	}
+
+	// Пропущенный пост помечен, и backfill не перебирает его снова, даже
+	// когда подписчиков стало меньше лимита
+	api.unfollow(fans[2], star)
+	fannedOut, err := api.store.BackfillTimeline(context.Background(), testFanoutLimit)
+	if err != nil {
+		t.Fatalf("BackfillTimeline: %v", err)
+	}
+	if fannedOut != 0 {
+		t.Errorf("BackfillTimeline: fanned out %d posts, want 0", fannedOut)
+	}
+	if got := api.timeline(fans[0]); !slices.Equal(got, []int{post}) {
+		t.Errorf("GET /timeline after backfill: got %v, want [%d]", got, post)
+	}
+	if got := api.timeline(fans[2]); len(got) != 0 {
+		t.Errorf("GET /timeline after unfollow: got %v, want none", got)
+	}
+
+	// Автор с двумя подписчиками снова раскладывается
+	next := api.createPost(star, "star next post")
+	if got := api.timeline(fans[0]); !slices.Equal(got, []int{next, post}) {
+		t.Errorf("GET /timeline: got %v, want [%d %d]", got, next, post)
+	}
+}
+
+func TestTimelineFollow(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+
+	posts := make([]int, postgres.FollowBackfillLimit+1)
+	for i := range posts {
+		posts[i] = api.createPost(bob, fmt.Sprintf("bob post %d", i))
+	}
+	slices.Reverse(posts)
+
+	// При подписке в ленту попадают только последние FollowBackfillLimit постов
+	api.follow(alice, bob)
+	if got, want := api.timeline(alice), posts[:postgres.FollowBackfillLimit]; !slices.Equal(got, want) {
+		t.Errorf("GET /timeline after follow: got %d posts, want the latest %d", len(got), len(want))
+	}
+
+	api.unfollow(alice, bob)
+	if got := api.timeline(alice); len(got) != 0 {
+		t.Errorf("GET /timeline after unfollow: got %v, want none", got)
+	}
+}
+
+func TestTimelineBlock(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+
+	api.follow(alice, bob)
+	post := api.createPost(bob, "bob post")
+	if got := api.timeline(alice); !slices.Equal(got, []int{post}) {
+		t.Fatalf("GET /timeline: got %v, want [%d]", got, post)
+	}
+
+	// Блокировка в любую сторону убирает посты из ленты, и после снятия
+	// блокировки они не возвращаются без новой подписки
+	api.expect(http.StatusCreated, http.MethodPost, "/blocks", bob.Token, map[string]int{"blocked_id": alice.ID})
+	if got := api.timeline(alice); len(got) != 0 {
+		t.Errorf("GET /timeline after block: got %v, want none", got)
+	}
+	api.expect(http.StatusOK, http.MethodDelete, fmt.Sprintf("/blocks?blocked_id=%d", alice.ID), bob.Token, nil)
+	if got := api.timeline(alice); len(got) != 0 {
+		t.Errorf("GET /timeline after unblock: got %v, want none", got)
+	}
+
+	api.follow(alice, bob)
+	if got := api.timeline(alice); !slices.Equal(got, []int{post}) {
+		t.Errorf("GET /timeline after follow: got %v, want [%d]", got, post)
+	}
+}
@@ -2,8 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
-	"github.com/dgrijalva/jwt-go"
-	"kursach/internal/storage/postgres"
+	"kursach/internal/auth"
+	"kursach/internal/media"
 	"net/http"
 )
 
@@ -11,7 +11,7 @@ type TokenRequest struct {
 	Token string `json:"token"`
 }
 
-func ValidateTokenHandler(userStorage postgres.UserStorage) http.HandlerFunc {
+func ValidateTokenHandler(userStorage UserRepository, keys *auth.Keys, urls *media.URLs) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req TokenRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -25,36 +25,26 @@ func ValidateTokenHandler(userStorage postgres.UserStorage) http.HandlerFunc {
 			return
 		}
 
-		secretKey := []byte("secret_key")
-
 		// Парсим токен и проверяем подпись
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return secretKey, nil
-		})
-		if err != nil || !token.Valid {
+		claims, err := keys.Verify(tokenStr)
+		if err != nil {
 			http.Error(w, "Token is invalid", http.StatusUnauthorized)
 			return
 		}
 
-		// Извлекаем user_id из payload
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || !token.Valid {
-			http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+		// Проверяем, что сессия не отозвана
+		active, err := userStorage.TouchSession(r.Context(), claims.ID)
+		if err != nil {
+			http.Error(w, "Failed to check session", http.StatusInternalServerError)
 			return
 		}
-
-		userIDFloat, ok := claims["user_id"].(float64)
-		if !ok {
-			http.Error(w, "Invalid user ID in token", http.StatusUnauthorized)
+		if !active {
+			http.Error(w, "Session has been revoked", http.StatusUnauthorized)
 			return
 		}
-		userID := int(userIDFloat)
 
 		// Получаем информацию о пользователе
-		userInfo, err := userStorage.GetUserInfo(r.Context(), userID)
+		userInfo, err := userStorage.GetUserInfo(r.Context(), claims.UserID())
 		if err != nil {
 			http.Error(w, "Failed to fetch user info", http.StatusInternalServerError)
 			return
@@ -62,11 +52,19 @@ func ValidateTokenHandler(userStorage postgres.UserStorage) http.HandlerFunc {
 
 		// Формируем ответ
 		resp := LoginResponse{
-			Token: tokenStr,
-			User:  userInfo,
+			TokenPair: TokenPair{Token: tokenStr, ExpiresAt: claims.ExpiresAt.Time},
+			User:      withAvatarURL(urls, userInfo),
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(resp)
 	}
 }
+
+// JWKSHandler публикует публичные ключи проверки токенов.
+func JWKSHandler(keys *auth.Keys) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys.PublicJWKs()})
+	}
+}
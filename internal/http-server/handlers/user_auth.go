@@ -3,10 +3,8 @@ package handlers
 import (
 	"encoding/json"
 	"golang.org/x/crypto/bcrypt"
-	"kursach/internal/auth"
 	"net/http"
 	"strconv"
-	"time"
 )
 
 type LoginRequest struct {
@@ -15,8 +13,8 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token string                 `json:"token"`
-	User  map[string]interface{} `json:"user"`
+	TokenPair
+	User map[string]interface{} `json:"user"`
 }
 
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -38,17 +36,21 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Генерация токена
-	expiresAt := time.Now().Add(24 * time.Hour)
-	token, err := auth.GenerateToken(userID, expiresAt)
+	// Заблокированные модератором аккаунты не могут войти
+	suspended, err := h.UserStorage.IsUserSuspended(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "Token generation failed", http.StatusInternalServerError)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if suspended {
+		http.Error(w, "Account suspended", http.StatusForbidden)
 		return
 	}
 
-	// Сохраняем токен
-	if err := h.UserStorage.SaveUserToken(r.Context(), userID, token, expiresAt); err != nil {
-		http.Error(w, "Failed to save token", http.StatusInternalServerError)
+	// Создаём сессию и выпускаем токены
+	tokens, err := h.startSession(r.Context(), r, userID)
+	if err != nil {
+		http.Error(w, "Token generation failed", http.StatusInternalServerError)
 		return
 	}
 
@@ -61,8 +63,8 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	// Возвращаем ответ
 	resp := LoginResponse{
-		Token: token,
-		User:  userInfo,
+		TokenPair: tokens,
+		User:      withAvatarURL(h.URLs, userInfo),
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
@@ -89,5 +91,5 @@ func (h *UserHandler) GetUserInfoHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(userInfo)
+	json.NewEncoder(w).Encode(withAvatarURL(h.URLs, userInfo))
 }
@@ -5,7 +5,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	_ "golang.org/x/crypto/bcrypt"
 	"kursach/internal/auth"
-	"kursach/internal/storage/postgres"
+	"kursach/internal/media"
 	"net/http"
 	"strings"
 	"time"
@@ -19,7 +19,11 @@ type RegisterRequest struct {
 }
 
 type UserHandler struct {
-	UserStorage *postgres.UserStorage
+	UserStorage UserRepository
+	Keys        *auth.Keys
+	AccessTTL   time.Duration
+	RefreshTTL  time.Duration
+	URLs        *media.URLs
 }
 
 func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
@@ -77,24 +81,19 @@ func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Генерация JWT токена
-	token, err := auth.GenerateToken(userInfo["user_id"].(int), time.Now().Add(24*time.Hour))
+	// Создаём сессию и выпускаем токены
+	tokens, err := h.startSession(r.Context(), r, userInfo["user_id"].(int))
 	if err != nil {
 		http.Error(w, "Could not generate token", http.StatusInternalServerError)
 		return
 	}
 
-	// Сохраняем токен в базу данных
-	err = h.UserStorage.SaveUserToken(r.Context(), userInfo["user_id"].(int), token, time.Now().Add(24*time.Hour))
-	if err != nil {
-		http.Error(w, "Could not save token", http.StatusInternalServerError)
-		return
-	}
-
-	// Отправляем ответ с информацией о пользователе и токеном
+	// Отправляем ответ с информацией о пользователе и токенами
 	response := map[string]interface{}{
-		"user_info": userInfo,
-		"token":     token,
+		"user_info":     withAvatarURL(h.URLs, userInfo),
+		"token":         tokens.Token,
+		"expires_at":    tokens.ExpiresAt,
+		"refresh_token": tokens.RefreshToken,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
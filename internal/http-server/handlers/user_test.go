@@ -0,0 +1,226 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type tokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func TestRegister(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+
+	var info map[string]any
+	decode(t, api.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/users?userId=%d", alice.ID), "", nil), &info)
+	if info["user_tag"] != "alice" || info["user_name"] != "ALICE" {
+		t.Errorf("GET /users: got %v", info)
+	}
+
+	resp := api.do(http.MethodPost, "/register", "", map[string]string{
+		"email": "other@example.com", "password": "password", "user_name": "Other", "user_tag": "alice",
+	})
+	requireError(t, resp, http.StatusConflict, "User tag already taken")
+
+	resp = api.do(http.MethodPost, "/register", "", map[string]string{
+		"email": "alice@example.com", "password": "password", "user_name": "Other", "user_tag": "other",
+	})
+	requireError(t, resp, http.StatusConflict, "Email already registered")
+
+	resp = api.do(http.MethodPost, "/register", "", map[string]string{"email": "x@example.com"})
+	requireError(t, resp, http.StatusBadRequest, "Missing fields")
+}
+
+func TestLogin(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+
+	var login struct {
+		tokenPair
+		User map[string]any `json:"user"`
+	}
+	resp := api.expect(http.StatusOK, http.MethodPost, "/auth", "", map[string]string{
+		"email": "alice@example.com", "password": "password-alice",
+	})
+	decode(t, resp, &login)
+	if login.Token == "" || login.RefreshToken == "" {
+		t.Fatalf("POST /auth: empty tokens in %+v", login)
+	}
+	if id, _ := login.User["user_id"].(float64); int(id) != alice.ID {
+		t.Errorf("POST /auth: user_id %v, want %d", login.User["user_id"], alice.ID)
+	}
+	api.expect(http.StatusOK, http.MethodGet, "/auth/sessions", login.Token, nil)
+
+	resp = api.do(http.MethodPost, "/auth", "", map[string]string{
+		"email": "alice@example.com", "password": "wrong",
+	})
+	requireError(t, resp, http.StatusUnauthorized, "Invalid credentials")
+
+	resp = api.do(http.MethodPost, "/auth", "", map[string]string{
+		"email": "nobody@example.com", "password": "password-alice",
+	})
+	requireError(t, resp, http.StatusUnauthorized, "Invalid credentials")
+}
+
+func TestRefresh(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+
+	var tokens tokenPair
+	resp := api.expect(http.StatusOK, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": alice.RefreshToken})
+	decode(t, resp, &tokens)
+	if tokens.Token == "" || tokens.RefreshToken == "" || tokens.RefreshToken == alice.RefreshToken {
+		t.Fatalf("POST /auth/refresh: refresh token was not rotated: %+v", tokens)
+	}
+	api.expect(http.StatusOK, http.MethodGet, "/auth/sessions", tokens.Token, nil)
+
+	// старый refresh-токен после ротации недействителен
+	resp = api.do(http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": alice.RefreshToken})
+	requireError(t, resp, http.StatusUnauthorized, "Refresh token is invalid")
+
+	resp = api.do(http.MethodPost, "/auth/refresh", "", map[string]string{})
+	requireError(t, resp, http.StatusBadRequest, "Invalid request")
+}
+
+func TestLogout(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/auth/sessions", "", nil)
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/auth/sessions", "not-a-token", nil)
+
+	api.expect(http.StatusOK, http.MethodPost, "/auth/logout", alice.Token, nil)
+
+	// токен отозванной сессии больше не принимается
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/auth/sessions", alice.Token, nil)
+	api.expect(http.StatusUnauthorized, http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": alice.RefreshToken})
+}
+
+type session struct {
+	SessionID string `json:"session_id"`
+	Current   bool   `json:"current"`
+}
+
+func (a *testAPI) sessions(u testUser) []session {
+	a.t.Helper()
+
+	var list []session
+	decode(a.t, a.expect(http.StatusOK, http.MethodGet, "/auth/sessions", u.Token, nil), &list)
+	return list
+}
+
+func TestSessions(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	phone := api.login(alice)
+	laptop := api.login(alice)
+
+	list := api.sessions(alice)
+	if len(list) != 3 {
+		t.Fatalf("GET /auth/sessions: got %d sessions, want 3", len(list))
+	}
+	var current int
+	for _, s := range list {
+		if s.Current {
+			current++
+		}
+	}
+	if current != 1 {
+		t.Errorf("GET /auth/sessions: %d sessions marked current, want 1", current)
+	}
+
+	// отзыв одной сессии не трогает остальные
+	phoneSession := api.sessions(phone)
+	var phoneID string
+	for _, s := range phoneSession {
+		if s.Current {
+			phoneID = s.SessionID
+		}
+	}
+	api.expect(http.StatusOK, http.MethodDelete, "/auth/sessions?session_id="+phoneID, alice.Token, nil)
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/auth/sessions", phone.Token, nil)
+	if got := len(api.sessions(laptop)); got != 2 {
+		t.Errorf("GET /auth/sessions after revoke: got %d sessions, want 2", got)
+	}
+	requireError(t, api.do(http.MethodDelete, "/auth/sessions?session_id="+phoneID, alice.Token, nil), http.StatusNotFound, "Session not found")
+	requireError(t, api.do(http.MethodDelete, "/auth/sessions?session_id=x", alice.Token, nil), http.StatusBadRequest, "Invalid session_id")
+
+	api.expect(http.StatusOK, http.MethodPost, "/auth/logout-all", laptop.Token, nil)
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/auth/sessions", alice.Token, nil)
+	api.expect(http.StatusUnauthorized, http.MethodGet, "/auth/sessions", laptop.Token, nil)
+}
+
+func TestValidateToken(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+
+	var resp struct {
+		Token string         `json:"token"`
+		User  map[string]any `json:"user"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodPost, "/token", "", map[string]string{"token": alice.Token}), &resp)
+	if resp.Token != alice.Token || resp.User["user_tag"] != "alice" {
+		t.Errorf("POST /token: got %+v", resp)
+	}
+
+	requireError(t, api.do(http.MethodPost, "/token", "", map[string]string{}), http.StatusBadRequest, "Token is required")
+	requireError(t, api.do(http.MethodPost, "/token", "", map[string]string{"token": "garbage"}), http.StatusUnauthorized, "Token is invalid")
+
+	api.expect(http.StatusOK, http.MethodPost, "/auth/logout", alice.Token, nil)
+	requireError(t, api.do(http.MethodPost, "/token", "", map[string]string{"token": alice.Token}), http.StatusUnauthorized, "Session has been revoked")
+}
+
+func TestJWKS(t *testing.T) {
+	api := newTestAPI(t)
+
+	// общий секрет HS256 не публикуется
+	var jwks struct {
+		Keys []map[string]any `json:"keys"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodGet, "/.well-known/jwks.json", "", nil), &jwks)
+	if jwks.Keys == nil || len(jwks.Keys) != 0 {
+		t.Errorf("GET /.well-known/jwks.json: got %+v, want an empty key list", jwks)
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	api := newTestAPI(t)
+	alice := api.register("alice")
+	bob := api.register("bob")
+
+	setRole := map[string]any{"user_id": bob.ID, "role": "moderator"}
+	requireError(t, api.do(http.MethodPut, "/users/role", alice.Token, setRole), http.StatusForbidden, "Forbidden")
+
+	admin := api.withRole(alice, "admin")
+	api.expect(http.StatusOK, http.MethodPut, "/users/role", admin.Token, setRole)
+	if role, _ := api.store.GetUserRole(context.Background(), bob.ID); role != "moderator" {
+		t.Errorf("role after PUT /users/role: %q, want moderator", role)
+	}
+
+	requireError(t, api.do(http.MethodPut, "/users/role", admin.Token, map[string]any{"user_id": bob.ID, "role": "king"}),
+		http.StatusBadRequest, "user_id and a valid role are required")
+	requireError(t, api.do(http.MethodPut, "/users/role", admin.Token, map[string]any{"user_id": 999, "role": "user"}),
+		http.StatusNotFound, "User not found")
+}
+
+func TestHealth(t *testing.T) {
+	api := newTestAPI(t)
+
+	var health struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodGet, "/healthz", "", nil), &health)
+	if health.Status != "ok" {
+		t.Errorf("GET /healthz: got %+v", health)
+	}
+	decode(t, api.expect(http.StatusOK, http.MethodGet, "/readyz", "", nil), &health)
+	if health.Status != "ok" || health.Checks["database"] != "ok" || health.Checks["media"] != "ok" {
+		t.Errorf("GET /readyz: got %+v", health)
+	}
+}
@@ -3,45 +3,60 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"io"
-	"kursach/internal/storage/postgres"
-	"log"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strconv"
+
+	"github.com/google/uuid"
+	"kursach/internal/media"
 )
 
 type UpdateUserHandler struct {
-	UserStorage *postgres.UserStorage
+	UserStorage UserRepository
+	Media       media.Store
+	Images      *media.ImageProcessor
+	URLs        *media.URLs
 }
 
-func (h *UpdateUserHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+// editableUserFields — колонки user_info, которые можно менять текстовыми
+// полями формы. Аватар и его варианты задаются только загрузкой файла.
+var editableUserFields = map[string]bool{
+	"user_name":   true,
+	"user_tag":    true,
+	"theme":       true,
+	"language":    true,
+	"description": true,
+}
 
-	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10 MB max
-		http.Error(w, "Could not parse form", http.StatusBadRequest)
+func (h *UpdateUserHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !parseUploadForm(w, r, h.Images.MaxBytes(media.ImageAvatar)) {
 		return
 	}
-	userIDStr := r.FormValue("user_id")
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		http.Error(w, "Invalid userId", http.StatusBadRequest)
+	userID, ok := actorIDFromParam(w, r, r.FormValue("user_id"))
+	if !ok {
 		return
 	}
 
-	log.Println("user_id =", r.FormValue("user_id"))
-
 	updates := make(map[string]interface{})
 
 	// Обработка текстовых полей
 	for key, values := range r.MultipartForm.Value {
+		if key == "user_id" {
+			continue
+		}
+		if !editableUserFields[key] {
+			http.Error(w, fmt.Sprintf("Field %q cannot be updated", key), http.StatusBadRequest)
+			return
+		}
 		if len(values) > 0 {
 			updates[key] = values[0]
 		}
 	}
 
-	// Обработка файлов
+	// Обработка файлов: принимается только аватар
 	for key, files := range r.MultipartForm.File {
+		if key != "avatar_url" {
+			http.Error(w, "Unexpected file field", http.StatusBadRequest)
+			return
+		}
 		file, err := files[0].Open()
 		if err != nil {
 			http.Error(w, "Error opening file", http.StatusInternalServerError)
@@ -49,35 +64,21 @@ func (h *UpdateUserHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		defer file.Close()
 
-		// Создание директории если нет
-		uploadDir := "./uploads/avatars"
-		if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
-			http.Error(w, "Error creating upload directory", http.StatusInternalServerError)
-			return
-		}
-
-		// Сохраняем файл
-		filename := fmt.Sprintf("user_%d_%s", userID, files[0].Filename)
-		path := filepath.Join(uploadDir, filename)
-
-		dst, err := os.Create(path)
+		// Сохраняем перекодированную картинку под своим именем
+		prefix := fmt.Sprintf("avatars/user_%d_%s", userID, uuid.NewString())
+		objectKey, images, err := saveImage(r.Context(), h.Media, h.Images, file, media.ImageAvatar, prefix)
 		if err != nil {
-			http.Error(w, "Error saving file", http.StatusInternalServerError)
-			return
-		}
-		defer dst.Close()
-		if _, err := io.Copy(dst, file); err != nil {
-			http.Error(w, "Error writing file", http.StatusInternalServerError)
+			writeImageError(w, err)
 			return
 		}
 
-		// Сохраняем URL (например: /static/avatars/user_123_file.png)
-		avatarURL := fmt.Sprintf("localhost:8082/static/avatars/%s", filename)
-		updates[key] = avatarURL
+		// В базе храним ключи, ссылки строятся при ответе
+		updates[key] = objectKey
+		updates["avatar_images"] = images
 	}
 
 	// Обновляем пользователя
-	err = h.UserStorage.UpdateUser(r.Context(), userID, updates)
+	err := h.UserStorage.UpdateUser(r.Context(), userID, updates)
 	if err != nil {
 		http.Error(w, "Could not update user", http.StatusInternalServerError)
 		return
@@ -90,5 +91,5 @@ func (h *UpdateUserHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(userInfo)
+	json.NewEncoder(w).Encode(withAvatarURL(h.URLs, userInfo))
 }
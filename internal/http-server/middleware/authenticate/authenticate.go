@@ -0,0 +1,115 @@
+package authenticate
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"kursach/internal/auth"
+	"kursach/internal/logger/sl"
+	"log/slog"
+)
+
+type ctxKey struct{}
+
+type identity struct {
+	userID    int
+	sessionID string
+	roles     []string
+}
+
+// SessionChecker сообщает, активна ли сессия, к которой привязан токен
+// (не отозвана через logout и не истекла).
+type SessionChecker interface {
+	TouchSession(ctx context.Context, sessionID string) (bool, error)
+}
+
+// New проверяет заголовок Authorization: Bearer <token>, наличие
+// активной сессии в user_tokens и кладёт идентификатор пользователя
+// из токена в контекст запроса.
+func New(log *slog.Logger, keys *auth.Keys, sessions SessionChecker) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/authenticate"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			tokenStr, ok := bearerToken(r)
+			if !ok {
+				http.Error(w, "Missing bearer token", http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := keys.Verify(tokenStr)
+			if err != nil {
+				log.Debug("token rejected", sl.Err(err))
+				http.Error(w, "Token is invalid", http.StatusUnauthorized)
+				return
+			}
+
+			active, err := sessions.TouchSession(r.Context(), claims.ID)
+			if err != nil {
+				log.Error("failed to check session", sl.Err(err))
+				http.Error(w, "Internal error", http.StatusInternalServerError)
+				return
+			}
+			if !active {
+				http.Error(w, "Session has been revoked", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithIdentity(r.Context(), claims.UserID(), claims.ID, claims.Roles)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Optional — то же, что New, для публичных маршрутов: запрос без токена
+// проходит анонимно, с токеном — проверяется как обычно.
+func Optional(log *slog.Logger, keys *auth.Keys, sessions SessionChecker) func(next http.Handler) http.Handler {
+	authenticate := New(log, keys, sessions)
+	return func(next http.Handler) http.Handler {
+		authenticated := authenticate(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := bearerToken(r); !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithIdentity возвращает копию контекста с идентификаторами пользователя, сессии и ролями.
+func WithIdentity(ctx context.Context, userID int, sessionID string, roles []string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, identity{userID: userID, sessionID: sessionID, roles: roles})
+}
+
+// UserID возвращает идентификатор аутентифицированного пользователя.
+func UserID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(ctxKey{}).(identity)
+	return id.userID, ok
+}
+
+// SessionID возвращает идентификатор сессии, к которой привязан токен.
+func SessionID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(identity)
+	return id.sessionID, ok
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// Roles возвращает роли пользователя из токена.
+func Roles(ctx context.Context) []string {
+	id, _ := ctx.Value(ctxKey{}).(identity)
+	return id.roles
+}
@@ -0,0 +1,151 @@
+// Package router собирает маршруты API. Один и тот же конструктор
+// используется сервером и тестами обработчиков.
+package router
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"kursach/internal/auth"
+	"kursach/internal/http-server/handlers"
+	"kursach/internal/http-server/middleware/authenticate"
+	"kursach/internal/http-server/middleware/logger"
+	"kursach/internal/media"
+	"kursach/internal/ranking"
+)
+
+// Deps — зависимости обработчиков API.
+type Deps struct {
+	Log  *slog.Logger
+	Keys *auth.Keys
+
+	Users         handlers.UserRepository
+	Posts         handlers.PostRepository
+	Notifications handlers.NotificationRepository
+	Health        *handlers.HealthHandler
+
+	Media    media.Store
+	Images   *media.ImageProcessor
+	URLs     *media.URLs
+	Timeline handlers.TimelineFanout // nil — посты не раскладываются по лентам
+	Ranking  *ranking.Feed
+
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+func New(d Deps) chi.Router {
+	router := chi.NewRouter()
+
+	router.Use(middleware.RequestID)
+	router.Use(logger.New(d.Log))
+	router.Use(middleware.Recoverer)
+	router.Use(middleware.URLFormat)
+
+	userHandler := handlers.UserHandler{
+		UserStorage: d.Users,
+		Keys:        d.Keys,
+		AccessTTL:   d.AccessTTL,
+		RefreshTTL:  d.RefreshTTL,
+		URLs:        d.URLs,
+	}
+	updateUserHandler := handlers.UpdateUserHandler{
+		UserStorage: d.Users,
+		Media:       d.Media,
+		Images:      d.Images,
+		URLs:        d.URLs,
+	}
+	postHandler := handlers.PostHandler{
+		Log:         d.Log,
+		PostStorage: d.Posts,
+		UserStorage: d.Users,
+		Media:       d.Media,
+		Images:      d.Images,
+		URLs:        d.URLs,
+		Timeline:    d.Timeline,
+		Ranking:     d.Ranking,
+	}
+	notificationHandler := handlers.NotificationHandler{NotificationStorage: d.Notifications}
+
+	router.Get("/healthz", d.Health.Healthz)
+	router.Get("/readyz", d.Health.Readyz)
+
+	router.Post("/register", userHandler.Register)
+	router.Post("/auth", userHandler.Login)
+	router.Post("/auth/refresh", userHandler.Refresh)
+	router.Get("/users", userHandler.GetUserInfoHandler)
+	router.Get("/follows", postHandler.GetFollowingsHandler)
+	router.Get("/tags", postHandler.GetAllTagsHandler)
+	router.Get("/reports/reasons", postHandler.GetReportReasonsHandler)
+	router.Post("/token", handlers.ValidateTokenHandler(d.Users, d.Keys, d.URLs))
+	// URLFormat убирает расширение из пути маршрутизации, поэтому
+	// /.well-known/jwks.json сопоставляется с маршрутом без .json
+	router.Get("/.well-known/jwks", handlers.JWKSHandler(d.Keys))
+
+	// Публичные ленты: с токеном из них убираются заблокированные авторы
+	router.Group(func(r chi.Router) {
+		r.Use(authenticate.Optional(d.Log, d.Keys, d.Users))
+
+		r.Get("/posts", postHandler.GetPostsHandler)
+		r.Get("/posts/{id}/comments", postHandler.GetPostCommentsHandler)
+	})
+
+	// Маршруты, требующие Authorization: Bearer <token>
+	router.Group(func(r chi.Router) {
+		r.Use(authenticate.New(d.Log, d.Keys, d.Users))
+
+		r.Post("/auth/logout", userHandler.Logout)
+		r.Post("/auth/logout-all", userHandler.LogoutAll)
+		r.Get("/auth/sessions", userHandler.GetSessionsHandler)
+		r.Delete("/auth/sessions", userHandler.RevokeSessionHandler)
+
+		r.Patch("/users", updateUserHandler.ServeHTTP)
+		r.Put("/users/role", userHandler.SetUserRoleHandler)
+
+		r.Post("/posts", postHandler.AddPost)
+		r.Delete("/posts", postHandler.DeletePostHandler)
+		r.Patch("/posts/{id}", postHandler.UpdatePostHandler)
+		r.Get("/posts/{id}/revisions", postHandler.GetPostRevisionsHandler)
+		r.Get("/timeline", postHandler.GetTimelineHandler)
+		r.Get("/feed/for-you", postHandler.GetForYouHandler)
+
+		r.Post("/likes", postHandler.AddLikeHandler)
+		r.Delete("/likes", postHandler.RemoveLikeHandler)
+
+		r.Post("/comments", postHandler.AddCommentHandler)
+		r.Delete("/comments", postHandler.DeleteCommentHandler)
+		r.Patch("/comments/{id}", postHandler.EditCommentHandler)
+		r.Post("/comments/{id}/likes", postHandler.AddCommentLikeHandler)
+		r.Delete("/comments/{id}/likes", postHandler.RemoveCommentLikeHandler)
+
+		r.Get("/notifications", notificationHandler.GetUnreadNotificationsHandler)
+
+		r.Post("/blocks", postHandler.AddBlockHandler)
+		r.Get("/blocks", postHandler.GetBlocksHandler)
+		r.Delete("/blocks", postHandler.RemoveBlockHandler)
+
+		r.Post("/follows", postHandler.AddFollowHandler)
+		r.Delete("/follows", postHandler.RemoveFollowHandler)
+
+		r.Post("/favorites", postHandler.AddToFavoritesHandler)
+		r.Delete("/favorites", postHandler.RemoveFromFavoritesHandler)
+		r.Get("/favorites", postHandler.GetFavoritePostsHandler)
+
+		r.Post("/reports", postHandler.CreateReportHandler)
+		r.Get("/reports", postHandler.GetMyReportsHandler)
+		r.Get("/reports/{id}", postHandler.GetReportStatusHandler)
+
+		r.Get("/moderation/reports", postHandler.GetReportsHandler)
+		r.Post("/moderation/reports/{id}/assign", postHandler.AssignReportHandler)
+		r.Post("/moderation/reports/{id}/resolve", postHandler.ResolveReportHandler)
+		r.Get("/moderation/reports/{id}/actions", postHandler.GetReportActionsHandler)
+	})
+
+	router.Handle("/static/*", http.StripPrefix("/static/", handlers.MediaHandler(d.Media)))
+
+	return router
+}
@@ -0,0 +1,70 @@
+package media
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// Виды вложений поста
+const (
+	AttachmentImage = "image"
+	AttachmentVideo = "video"
+)
+
+var allowedVideoTypes = []string{"video/mp4", "video/webm"}
+
+// Attachment — проверенное вложение поста: картинка с вариантами или видео.
+type Attachment struct {
+	Kind  string
+	Image *ProcessedImage
+	// Video сохраняется как есть: перекодирование видео на сервере не делается.
+	Video *Image
+}
+
+// MaxAttachmentBytes — максимальный размер одного вложения поста.
+func (p *ImageProcessor) MaxAttachmentBytes() int64 {
+	return max(p.limits[ImagePost].MaxBytes, p.videos.MaxBytes)
+}
+
+// MaxPostBytes — максимальный размер всех вложений поста вместе, но не
+// меньше размера одного вложения.
+func (p *ImageProcessor) MaxPostBytes() int64 {
+	return max(p.postMaxBytes, p.MaxAttachmentBytes())
+}
+
+// ProcessAttachment определяет тип вложения по содержимому и проверяет его.
+func (p *ImageProcessor) ProcessAttachment(r io.Reader) (*Attachment, error) {
+	data, err := readLimited(r, p.MaxAttachmentBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	mtype := mimetype.Detect(data)
+	switch {
+	case mimetype.EqualsAny(mtype.String(), allowedImageTypes...):
+		if int64(len(data)) > p.limits[ImagePost].MaxBytes {
+			return nil, ErrFileTooLarge
+		}
+		img, err := p.processImage(data, ImagePost)
+		if err != nil {
+			return nil, err
+		}
+		return &Attachment{Kind: AttachmentImage, Image: img}, nil
+
+	case mimetype.EqualsAny(mtype.String(), allowedVideoTypes...):
+		if int64(len(data)) > p.videos.MaxBytes {
+			return nil, ErrFileTooLarge
+		}
+		if err := p.checkVideo(data, mtype.String()); err != nil {
+			return nil, err
+		}
+		return &Attachment{Kind: AttachmentVideo, Video: &Image{
+			Data:        data,
+			ContentType: mtype.String(),
+			Ext:         mtype.Extension(),
+		}}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedMedia, mtype.String())
+}
@@ -0,0 +1,59 @@
+package media
+
+import (
+	"context"
+	"time"
+)
+
+// GCResult — итог одного прохода сборщика мусора.
+type GCResult struct {
+	Scanned int   // объектов в хранилище
+	Orphans int   // из них без ссылок из базы и старше grace
+	Deleted int   // удалено (при dryRun — 0)
+	Bytes   int64 // размер найденных объектов без ссылок
+}
+
+// ReferencedKeys возвращает ключи всех файлов, на которые ссылается база.
+type ReferencedKeys func(ctx context.Context) (map[string]bool, error)
+
+// CollectGarbage удаляет объекты, на которые нет ссылок из базы и которые
+// изменены раньше, чем grace назад. Grace защищает только что загруженные
+// файлы: обработчик сначала пишет файл и лишь затем — запись в базу.
+// При dryRun объекты только подсчитываются; orphan вызывается для каждого
+// найденного объекта, если задан.
+func CollectGarbage(ctx context.Context, store Store, referenced ReferencedKeys, grace time.Duration, dryRun bool, orphan func(key string, info ObjectInfo)) (GCResult, error) {
+	var result GCResult
+
+	// Порог считаем до загрузки ссылок: файл, загруженный после неё, новее порога
+	cutoff := time.Now().Add(-grace)
+	refs, err := referenced(ctx)
+	if err != nil {
+		return result, err
+	}
+	keys := make(map[string]bool, len(refs))
+	for ref := range refs {
+		// в базе могли остаться старые абсолютные ссылки
+		keys[KeyFromLegacyURL(ref)] = true
+	}
+
+	err = store.List(ctx, func(key string, info ObjectInfo) error {
+		result.Scanned++
+		if keys[key] || !info.ModTime.Before(cutoff) {
+			return nil
+		}
+		result.Orphans++
+		result.Bytes += info.Size
+		if orphan != nil {
+			orphan(key, info)
+		}
+		if dryRun {
+			return nil
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			return err
+		}
+		result.Deleted++
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,461 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/gabriel-vasile/mimetype"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // регистрирует декодер WebP
+
+	"kursach/internal/config"
+)
+
+var (
+	ErrUnsupportedMedia = errors.New("unsupported media type")
+	ErrFileTooLarge     = errors.New("file is too large")
+	ErrImageDimensions  = errors.New("image dimensions exceed the limit")
+	ErrInvalidImage     = errors.New("invalid image")
+	ErrTooManyFrames    = errors.New("animation has too many frames")
+)
+
+// allowedImageTypes — типы, которые принимаются при загрузке. Тип
+// определяется по содержимому файла, а не по заголовкам клиента.
+var allowedImageTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// ImageKind — назначение картинки, от него зависят ограничения.
+type ImageKind int
+
+const (
+	ImagePost ImageKind = iota
+	ImageAvatar
+)
+
+// Variant — уменьшенная копия картинки: большая сторона не больше MaxSide.
+type Variant struct {
+	Name    string
+	MaxSide int
+}
+
+// imageVariants — копии, которые создаются при загрузке.
+var imageVariants = map[ImageKind][]Variant{
+	ImagePost:   {{"thumbnail", 320}, {"feed", 1080}, {"full", 2048}},
+	ImageAvatar: {{"thumbnail", 64}, {"feed", 256}, {"full", 1024}},
+}
+
+type ImageLimits struct {
+	MaxBytes  int64
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Image — перекодированная картинка, готовая к сохранению.
+type Image struct {
+	Variant     string // пусто у оригинала
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// ImageProcessor проверяет загруженные картинки и перекодирует их:
+// метаданные (EXIF и т.п.) при этом отбрасываются, а поворот из EXIF
+// применяется к пикселям.
+type ImageProcessor struct {
+	limits       map[ImageKind]ImageLimits
+	gifs         config.GIFs
+	videos       config.Videos
+	postMaxBytes int64
+	quality      int
+}
+
+func NewImageProcessor(cfg config.Media) *ImageProcessor {
+	return &ImageProcessor{
+		limits: map[ImageKind]ImageLimits{
+			ImagePost:   ImageLimits(cfg.PostImages),
+			ImageAvatar: ImageLimits(cfg.Avatars),
+		},
+		gifs:         cfg.GIFs,
+		videos:       cfg.Videos,
+		postMaxBytes: cfg.MaxPostBytes,
+		quality:      cfg.JPEGQuality,
+	}
+}
+
+// MaxBytes — максимальный размер файла для картинки этого вида.
+func (p *ImageProcessor) MaxBytes(kind ImageKind) int64 {
+	return p.limits[kind].MaxBytes
+}
+
+// ProcessedImage — оригинал и его уменьшенные копии. Если картинка уже
+// меньше варианта, вариант указывает на оригинал (Variant у него пустой).
+type ProcessedImage struct {
+	Original *Image
+	Variants map[string]*Image
+}
+
+func (p *ImageProcessor) Process(r io.Reader, kind ImageKind) (*ProcessedImage, error) {
+	data, err := readLimited(r, p.limits[kind].MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	mtype := mimetype.Detect(data)
+	if !mimetype.EqualsAny(mtype.String(), allowedImageTypes...) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMedia, mtype.String())
+	}
+	return p.processImage(data, kind)
+}
+
+func readLimited(r io.Reader, maxBytes int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrFileTooLarge
+	}
+	return data, nil
+}
+
+func (p *ImageProcessor) processImage(data []byte, kind ImageKind) (*ProcessedImage, error) {
+	limits := p.limits[kind]
+
+	// Размеры проверяем до декодирования, чтобы не распаковывать «бомбы»
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if cfg.Width > limits.MaxWidth || cfg.Height > limits.MaxHeight {
+		return nil, ErrImageDimensions
+	}
+	// DecodeAll распаковывает все кадры сразу, поэтому их число и площадь
+	// проверяются по заголовкам
+	if format == "gif" {
+		if err := p.checkGIF(data); err != nil {
+			return nil, err
+		}
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if format == "gif" {
+		if animated, err := p.processAnimatedGIF(data, kind); animated != nil || err != nil {
+			return animated, err
+		}
+	}
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	original, err := p.encode(img, format)
+	if err != nil {
+		return nil, err
+	}
+	processed := &ProcessedImage{Original: original, Variants: make(map[string]*Image)}
+	for _, v := range imageVariants[kind] {
+		if original.Width <= v.MaxSide && original.Height <= v.MaxSide {
+			processed.Variants[v.Name] = original
+			continue
+		}
+		variant, err := p.encode(resize(img, v.MaxSide), format)
+		if err != nil {
+			return nil, err
+		}
+		variant.Variant = v.Name
+		processed.Variants[v.Name] = variant
+	}
+	return processed, nil
+}
+
+func (p *ImageProcessor) checkGIF(data []byte) error {
+	frames, pixels, err := gifFrames(data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if p.gifs.MaxFrames > 0 && frames > p.gifs.MaxFrames {
+		return ErrTooManyFrames
+	}
+	if p.gifs.MaxPixels > 0 && pixels > p.gifs.MaxPixels {
+		return ErrImageDimensions
+	}
+	return nil
+}
+
+// gifFrames считает кадры GIF и сумму их площадей, проходя по блокам
+// файла без распаковки LZW-данных.
+func gifFrames(data []byte) (frames int, pixels int64, err error) {
+	errTruncated := errors.New("gif: truncated file")
+	if len(data) < 13 {
+		return 0, 0, errTruncated
+	}
+	i := 13
+	if data[10]&0x80 != 0 { // глобальная палитра
+		i += 3 << (data[10]&7 + 1)
+	}
+	for i < len(data) {
+		switch data[i] {
+		case 0x21: // расширение: метка и подблоки
+			if i, err = skipGIFSubBlocks(data, i+2); err != nil {
+				return 0, 0, errTruncated
+			}
+		case 0x2C: // кадр: дескриптор, палитра, LZW-подблоки
+			if i+10 > len(data) {
+				return 0, 0, errTruncated
+			}
+			w := int(data[i+5]) | int(data[i+6])<<8
+			h := int(data[i+7]) | int(data[i+8])<<8
+			packed := data[i+9]
+			i += 10
+			if packed&0x80 != 0 {
+				i += 3 << (packed&7 + 1)
+			}
+			if i, err = skipGIFSubBlocks(data, i+1); err != nil {
+				return 0, 0, errTruncated
+			}
+			frames++
+			pixels += int64(w) * int64(h)
+		case 0x3B: // конец файла
+			return frames, pixels, nil
+		default:
+			return 0, 0, fmt.Errorf("gif: unknown block 0x%02x", data[i])
+		}
+	}
+	return frames, pixels, nil
+}
+
+func skipGIFSubBlocks(data []byte, i int) (int, error) {
+	for {
+		if i >= len(data) {
+			return i, io.ErrUnexpectedEOF
+		}
+		n := int(data[i])
+		i++
+		if n == 0 {
+			return i, nil
+		}
+		i += n
+	}
+}
+
+// processAnimatedGIF перекодирует анимированный GIF целиком, чтобы не
+// потерять анимацию: он остаётся оригиналом и вариантом full. Остальные
+// варианты — статичный первый кадр, уменьшенный до размера варианта, чтобы
+// ленте и миниатюрам не приходилось скачивать всю анимацию. Для GIF из
+// одного кадра возвращает nil.
+func (p *ImageProcessor) processAnimatedGIF(data []byte, kind ImageKind) (*ProcessedImage, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if len(g.Image) < 2 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return nil, err
+	}
+	original := &Image{
+		Data:        buf.Bytes(),
+		ContentType: "image/gif",
+		Ext:         ".gif",
+		Width:       g.Config.Width,
+		Height:      g.Config.Height,
+	}
+
+	// Первый кадр может занимать только часть холста
+	frame := image.NewNRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	draw.Draw(frame, g.Image[0].Bounds(), g.Image[0], g.Image[0].Bounds().Min, draw.Over)
+
+	processed := &ProcessedImage{Original: original, Variants: make(map[string]*Image)}
+	for _, v := range imageVariants[kind] {
+		if v.Name == "full" {
+			processed.Variants[v.Name] = original
+			continue
+		}
+		var still image.Image = frame
+		if original.Width > v.MaxSide || original.Height > v.MaxSide {
+			still = resize(frame, v.MaxSide)
+		}
+		variant, err := p.encode(still, "gif")
+		if err != nil {
+			return nil, err
+		}
+		variant.Variant = v.Name
+		processed.Variants[v.Name] = variant
+	}
+	return processed, nil
+}
+
+// resize уменьшает картинку с сохранением пропорций.
+func resize(img image.Image, maxSide int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w >= h {
+		w, h = maxSide, max(1, h*maxSide/w)
+	} else {
+		w, h = max(1, w*maxSide/h), maxSide
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// encode сохраняет JPEG как JPEG, остальное — как PNG.
+func (p *ImageProcessor) encode(img image.Image, format string) (*Image, error) {
+	var (
+		buf bytes.Buffer
+		out = &Image{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+		err error
+	)
+	switch format {
+	case "jpeg":
+		out.ContentType, out.Ext = "image/jpeg", ".jpg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: p.quality})
+	default:
+		out.ContentType, out.Ext = "image/png", ".png"
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	out.Data = buf.Bytes()
+	return out, nil
+}
+
+// jpegOrientation читает тег Orientation (0x0112) из EXIF. 1 — без поворота.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // начало данных или конец файла
+			return 1
+		}
+		size := int(data[i+2])<<8 | int(data[i+3])
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var u16 func([]byte) int
+	var u32 func([]byte) int
+	switch string(tiff[:2]) {
+	case "II":
+		u16 = func(b []byte) int { return int(b[0]) | int(b[1])<<8 }
+		u32 = func(b []byte) int { return u16(b) | u16(b[2:])<<16 }
+	case "MM":
+		u16 = func(b []byte) int { return int(b[0])<<8 | int(b[1]) }
+		u32 = func(b []byte) int { return u16(b)<<16 | u16(b[2:]) }
+	default:
+		return 1
+	}
+
+	ifd := u32(tiff[4:])
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := u16(tiff[ifd:])
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if u16(tiff[entry:]) == 0x0112 {
+			if o := u16(tiff[entry+8:]); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation поворачивает и отражает картинку согласно EXIF Orientation.
+// Пиксели копируются по 4 байта между буферами RGBA или NRGBA: вызов
+// At/Set на каждую точку для фотографий в десятки мегапикселей слишком медленный.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	if src, ok := img.(*image.NRGBA); ok {
+		dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+		orientPixels(dst.Pix, dst.Stride, src.Pix[src.PixOffset(b.Min.X, b.Min.Y):], src.Stride, w, h, orientation)
+		return dst
+	}
+	// JPEG декодируется в YCbCr или Gray: переводим в RGBA целиком
+	src, ok := img.(*image.RGBA)
+	if !ok {
+		src = image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	orientPixels(dst.Pix, dst.Stride, src.Pix[src.PixOffset(src.Rect.Min.X, src.Rect.Min.Y):], src.Stride, w, h, orientation)
+	return dst
+}
+
+// orientPixels копирует 4-байтовые пиксели картинки w×h из src в dst с
+// поворотом и отражением; при orientation 5–8 dst имеет размер h×w.
+func orientPixels(dst []byte, dstStride int, src []byte, srcStride, w, h, orientation int) {
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	for y := 0; y < dh; y++ {
+		row := dst[y*dstStride : y*dstStride+dw*4]
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			o := sy*srcStride + sx*4
+			copy(row[x*4:x*4+4], src[o:o+4])
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"kursach/internal/config"
+)
+
+func testProcessor() *ImageProcessor {
+	cfg := config.Media{JPEGQuality: 90, MaxPostBytes: 8 << 20}
+	cfg.PostImages = config.PostImages{MaxBytes: 4 << 20, MaxWidth: 4000, MaxHeight: 3000}
+	cfg.Avatars = config.AvatarImages{MaxBytes: 1 << 20, MaxWidth: 1000, MaxHeight: 1000}
+	cfg.Videos = config.Videos{MaxBytes: 1 << 20, MaxDuration: time.Minute}
+	cfg.GIFs = config.GIFs{MaxFrames: 3, MaxPixels: 3 * 40 * 30}
+	return NewImageProcessor(cfg)
+}
+
+// halves — картинка w×h: левая половина красная, правая синяя.
+func halves(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessSniffing(t *testing.T) {
+	p := testProcessor()
+	pngData := encodePNG(t, halves(40, 30))
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+		want string
+	}{
+		{"png", pngData, nil, "image/png"},
+		{"text", []byte("just some text, not an image"), ErrUnsupportedMedia, ""},
+		{"html", []byte("<!DOCTYPE html><html><body>hi</body></html>"), ErrUnsupportedMedia, ""},
+		{"truncated png", pngData[:40], ErrInvalidImage, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := p.Process(bytes.NewReader(tt.data), ImagePost)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Process: err %v, want %v", err, tt.err)
+			}
+			if err == nil && img.Original.ContentType != tt.want {
+				t.Errorf("Process: content type %q, want %q", img.Original.ContentType, tt.want)
+			}
+		})
+	}
+
+	// тип вложения определяется по содержимому
+	a, err := p.ProcessAttachment(bytes.NewReader(pngData))
+	if err != nil || a.Kind != AttachmentImage {
+		t.Errorf("ProcessAttachment(png): %+v, %v", a, err)
+	}
+	if _, err := p.ProcessAttachment(strings.NewReader("%PDF-1.4 not allowed")); !errors.Is(err, ErrUnsupportedMedia) {
+		t.Errorf("ProcessAttachment(pdf): err %v, want %v", err, ErrUnsupportedMedia)
+	}
+}
+
+func TestProcessLimits(t *testing.T) {
+	p := testProcessor()
+
+	tests := []struct {
+		name string
+		w, h int
+		kind ImageKind
+		err  error
+	}{
+		{"post within limits", 4000, 10, ImagePost, nil},
+		{"post too wide", 4001, 10, ImagePost, ErrImageDimensions},
+		{"post too tall", 10, 3001, ImagePost, ErrImageDimensions},
+		{"avatar within limits", 1000, 1000, ImageAvatar, nil},
+		{"avatar too wide", 1001, 10, ImageAvatar, ErrImageDimensions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Process(bytes.NewReader(encodePNG(t, image.NewGray(image.Rect(0, 0, tt.w, tt.h)))), tt.kind)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Process(%dx%d): err %v, want %v", tt.w, tt.h, err, tt.err)
+			}
+		})
+	}
+
+	big := bytes.Repeat([]byte{0}, 1<<20+1)
+	if _, err := p.Process(bytes.NewReader(big), ImageAvatar); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("Process(avatar over MaxBytes): err %v, want %v", err, ErrFileTooLarge)
+	}
+}
+
+func TestProcessVariants(t *testing.T) {
+	p := testProcessor()
+
+	img, err := p.Process(bytes.NewReader(encodePNG(t, halves(3000, 1500))), ImagePost)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	for _, v := range imageVariants[ImagePost] {
+		got := img.Variants[v.Name]
+		if got == nil || got.Variant != v.Name || got.Width != v.MaxSide || got.Height != v.MaxSide/2 {
+			t.Errorf("variant %s: got %+v, want %dx%d", v.Name, got, v.MaxSide, v.MaxSide/2)
+		}
+	}
+
+	// картинка меньше варианта не увеличивается
+	small, err := p.Process(bytes.NewReader(encodePNG(t, halves(100, 50))), ImagePost)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if small.Variants["thumbnail"] != small.Original || small.Variants["full"] != small.Original {
+		t.Errorf("small image variants should point to the original: %+v", small.Variants)
+	}
+}
+
+// animatedGIF — GIF из frames кадров w×h.
+func animatedGIF(t *testing.T, frames, w, h int) []byte {
+	t.Helper()
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, w, h), palette)
+		frame.SetColorIndex(i%w, 0, 1)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("gif.EncodeAll: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestGIFLimits(t *testing.T) {
+	p := testProcessor()
+
+	frames, pixels, err := gifFrames(animatedGIF(t, 5, 40, 30))
+	if err != nil || frames != 5 || pixels != 5*40*30 {
+		t.Errorf("gifFrames: %d frames, %d pixels, %v; want 5, %d", frames, pixels, err, 5*40*30)
+	}
+	if _, _, err := gifFrames(animatedGIF(t, 2, 40, 30)[:30]); err == nil {
+		t.Error("gifFrames(truncated): no error")
+	}
+
+	img, err := p.Process(bytes.NewReader(animatedGIF(t, 3, 40, 30)), ImagePost)
+	if err != nil {
+		t.Fatalf("Process(3 frames): %v", err)
+	}
+	if img.Original.ContentType != "image/gif" || img.Variants["full"] != img.Original {
+		t.Errorf("Process(3 frames): animation not kept: %+v", img.Original)
+	}
+
+	if _, err := p.Process(bytes.NewReader(animatedGIF(t, 4, 10, 10)), ImagePost); !errors.Is(err, ErrTooManyFrames) {
+		t.Errorf("Process(4 frames): err %v, want %v", err, ErrTooManyFrames)
+	}
+	// три кадра, но суммарная площадь больше MaxPixels
+	if _, err := p.Process(bytes.NewReader(animatedGIF(t, 3, 41, 30)), ImagePost); !errors.Is(err, ErrImageDimensions) {
+		t.Errorf("Process(3 large frames): err %v, want %v", err, ErrImageDimensions)
+	}
+}
+
+// withOrientation вставляет после SOI сегмент APP1 с EXIF, в котором
+// записан только тег Orientation.
+func withOrientation(jpegData []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08") // big-endian, IFD по смещению 8
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0) // остаток значения и смещение следующего IFD
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, app1...)
+	return append(out, jpegData[2:]...)
+}
+
+func TestEXIFOrientation(t *testing.T) {
+	p := testProcessor()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, halves(64, 32), &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+
+	red := func(c color.Color) bool {
+		r, _, b, _ := c.RGBA()
+		return r > b
+	}
+	tests := []struct {
+		orientation   uint16
+		width, height int
+		topLeftRed    bool // левая (красная) половина оказывается в левом верхнем углу
+		bottomLeftRed bool
+	}{
+		{1, 64, 32, true, true},
+		{2, 64, 32, false, false}, // зеркально по горизонтали
+		{3, 64, 32, false, false}, // поворот на 180°
+		{6, 32, 64, true, false},  // поворот на 90° по часовой: левая половина сверху
+		{8, 32, 64, false, true},  // против часовой: левая половина снизу
+	}
+	for _, tt := range tests {
+		data := withOrientation(buf.Bytes(), tt.orientation)
+		if got := jpegOrientation(data); got != int(tt.orientation) {
+			t.Errorf("jpegOrientation: got %d, want %d", got, tt.orientation)
+		}
+		img, err := p.Process(bytes.NewReader(data), ImagePost)
+		if err != nil {
+			t.Fatalf("Process(orientation %d): %v", tt.orientation, err)
+		}
+		if img.Original.Width != tt.width || img.Original.Height != tt.height {
+			t.Errorf("orientation %d: %dx%d, want %dx%d", tt.orientation, img.Original.Width, img.Original.Height, tt.width, tt.height)
+		}
+		decoded, err := jpeg.Decode(bytes.NewReader(img.Original.Data))
+		if err != nil {
+			t.Fatalf("decode result: %v", err)
+		}
+		if got := red(decoded.At(2, 2)); got != tt.topLeftRed {
+			t.Errorf("orientation %d: top-left red = %v, want %v", tt.orientation, got, tt.topLeftRed)
+		}
+		if got := red(decoded.At(2, tt.height-3)); got != tt.bottomLeftRed {
+			t.Errorf("orientation %d: bottom-left red = %v, want %v", tt.orientation, got, tt.bottomLeftRed)
+		}
+	}
+}
+
+// TestApplyOrientationTypes сверяет копирование буферов с поточечным
+// поворотом через At для всех поддерживаемых типов картинок.
+func TestApplyOrientationTypes(t *testing.T) {
+	const w, h = 5, 3
+	nrgba := image.NewNRGBA(image.Rect(0, 0, w, h))
+	rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+	gray := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(y*w + x)
+			nrgba.SetNRGBA(x, y, color.NRGBA{v, v + 1, v + 2, 255})
+			rgba.SetRGBA(x, y, color.RGBA{v, v + 1, v + 2, 255})
+			gray.SetGray(x, y, color.Gray{v * 10})
+		}
+	}
+	// картинка с ненулевым началом — срез другой картинки
+	sub := image.NewNRGBA(image.Rect(0, 0, w+2, h+2)).SubImage(image.Rect(2, 2, w+2, h+2)).(*image.NRGBA)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sub.Set(x+2, y+2, nrgba.At(x, y))
+		}
+	}
+
+	for name, img := range map[string]image.Image{"nrgba": nrgba, "rgba": rgba, "gray": gray, "sub": sub} {
+		for orientation := 1; orientation <= 8; orientation++ {
+			got := applyOrientation(img, orientation)
+			b := img.Bounds()
+			for y := 0; y < got.Bounds().Dy(); y++ {
+				for x := 0; x < got.Bounds().Dx(); x++ {
+					sx, sy := orientedSource(x, y, w, h, orientation)
+					want := color.NRGBAModel.Convert(img.At(b.Min.X+sx, b.Min.Y+sy))
+					if c := color.NRGBAModel.Convert(got.At(got.Bounds().Min.X+x, got.Bounds().Min.Y+y)); c != want {
+						t.Fatalf("%s, orientation %d: pixel (%d,%d) = %v, want %v", name, orientation, x, y, c, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+// orientedSource — точка исходной картинки w×h, попадающая в (x, y).
+func orientedSource(x, y, w, h, orientation int) (int, int) {
+	switch orientation {
+	case 2:
+		return w - 1 - x, y
+	case 3:
+		return w - 1 - x, h - 1 - y
+	case 4:
+		return x, h - 1 - y
+	case 5:
+		return y, x
+	case 6:
+		return y, h - 1 - x
+	case 7:
+		return w - 1 - y, h - 1 - x
+	case 8:
+		return w - 1 - y, x
+	}
+	return x, y
+}
@@ -0,0 +1,81 @@
+// Package media хранит загруженные файлы (картинки постов, аватары).
+// Драйвер выбирается в конфиге: local — каталог на диске, s3 — любое
+// S3-совместимое хранилище (AWS S3, MinIO), которое можно разделить
+// между несколькими экземплярами сервера.
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"kursach/internal/config"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var (
+	ErrNotFound   = errors.New("media object not found")
+	ErrInvalidKey = errors.New("invalid media key")
+)
+
+// ObjectInfo — метаданные сохранённого объекта.
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Store — хранилище медиафайлов. Ключ — относительный путь вида
+// "posts/<имя файла>".
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get возвращает содержимое объекта; вызывающий закрывает reader.
+	// Оба драйвера возвращают reader, поддерживающий io.Seeker.
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete удаляет объект; отсутствие объекта не считается ошибкой.
+	Delete(ctx context.Context, key string) error
+	// SignedURL возвращает ссылку на объект, действующую ttl.
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// Ping проверяет, что хранилище доступно на запись (для /readyz).
+	Ping(ctx context.Context) error
+	// List вызывает fn для каждого объекта хранилища; ошибка fn прерывает обход.
+	List(ctx context.Context, fn func(key string, info ObjectInfo) error) error
+}
+
+func New(ctx context.Context, cfg config.Media) (Store, error) {
+	const op = "media.New"
+
+	var (
+		store Store
+		err   error
+	)
+	switch cfg.Driver {
+	case DriverLocal:
+		store, err = NewLocal(cfg.Local.Dir)
+	case DriverS3:
+		store, err = NewS3(ctx, cfg.S3)
+	default:
+		err = fmt.Errorf("unknown media driver %q", cfg.Driver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return store, nil
+}
+
+// cleanKey нормализует ключ и не даёт выйти за пределы хранилища.
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != strings.TrimPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
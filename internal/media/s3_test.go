@@ -0,0 +1,374 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"kursach/internal/config"
+)
+
+// fakeS3 — S3-совместимый сервер в памяти: path-style адресация, бакеты,
+// PUT/GET/HEAD/DELETE объектов и ListObjectsV2. Подписи не проверяются.
+type fakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+func newFakeS3(buckets ...string) *fakeS3 {
+	f := &fakeS3{buckets: make(map[string]map[string]fakeObject)}
+	for _, b := range buckets {
+		f.buckets[b] = make(map[string]fakeObject)
+	}
+	return f
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	objects, ok := f.buckets[bucket]
+
+	if key == "" {
+		switch {
+		case r.Method == http.MethodPut:
+			if !ok {
+				f.buckets[bucket] = make(map[string]fakeObject)
+			}
+		case !ok:
+			s3Error(w, http.StatusNotFound, "NoSuchBucket")
+		case r.Method == http.MethodHead:
+		case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+			listObjects(w, bucket, objects, r.URL.Query().Get("prefix"))
+		default:
+			s3Error(w, http.StatusNotImplemented, "NotImplemented")
+		}
+		return
+	}
+	if !ok {
+		s3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := readS3Body(r)
+		if err != nil {
+			s3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now().UTC()}
+		w.Header().Set("ETag", etag(data))
+	case http.MethodGet, http.MethodHead:
+		obj, ok := objects[key]
+		if !ok {
+			s3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", etag(obj.data))
+		w.Header().Set("Content-Type", obj.contentType)
+		http.ServeContent(w, r, "", obj.modTime, bytes.NewReader(obj.data))
+	case http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s3Error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// readS3Body читает тело PUT. Без TLS minio-go подписывает тело по
+// частям (aws-chunked): "<размер>;chunk-signature=...\r\n<данные>\r\n".
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+	var data []byte
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data, nil
+		}
+		chunk := make([]byte, size+2) // данные и \r\n
+		if _, err := io.ReadFull(br, chunk); err != nil {
+			return nil, err
+		}
+		data = append(data, chunk[:size]...)
+	}
+}
+
+func listObjects(w http.ResponseWriter, bucket string, objects map[string]fakeObject, prefix string) {
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+		StorageClass string
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		MaxKeys     int
+		IsTruncated bool
+		Contents    []content
+	}{Name: bucket, Prefix: prefix, MaxKeys: 1000}
+
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		obj := objects[key]
+		result.Contents = append(result.Contents, content{
+			Key:          key,
+			LastModified: obj.modTime.Format("2006-01-02T15:04:05.000Z"),
+			ETag:         etag(obj.data),
+			Size:         len(obj.data),
+			StorageClass: "STANDARD",
+		})
+	}
+	result.KeyCount = len(keys)
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func s3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func newTestS3(t *testing.T, fake *fakeS3, createBucket bool) (*S3, *httptest.Server, error) {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	store, err := NewS3(context.Background(), config.S3Media{
+		Endpoint:        strings.TrimPrefix(srv.URL, "http://"),
+		Region:          "us-east-1",
+		Bucket:          "media",
+		AccessKeyID:     "minioadmin",
+		SecretAccessKey: "minioadmin",
+		PathStyle:       true,
+		CreateBucket:    createBucket,
+	})
+	return store, srv, err
+}
+
+func TestS3PutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	store, _, err := newTestS3(t, newFakeS3("media"), false)
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+
+	data := []byte("not really a png")
+	if err := store.Put(ctx, "posts/a.png", bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	body, info, err := store.Get(ctx, "posts/a.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		t.Fatalf("reading object: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("Get returned %q, want %q", got, data)
+	}
+	if info.Size != int64(len(data)) || info.ContentType != "image/png" || info.ModTime.IsZero() {
+		t.Errorf("Get info = %+v", info)
+	}
+
+	// MediaHandler отдаёт объекты через http.ServeContent, которому нужен Seek
+	body, _, err = store.Get(ctx, "posts/a.png")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	rs, ok := body.(io.ReadSeeker)
+	if !ok {
+		t.Fatalf("Get returned %T, want io.ReadSeeker", body)
+	}
+	if _, err := rs.Seek(4, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	tail, _ := io.ReadAll(rs)
+	body.Close()
+	if string(tail) != "really a png" {
+		t.Errorf("after Seek read %q", tail)
+	}
+
+	if err := store.Delete(ctx, "posts/a.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, err := store.Get(ctx, "posts/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: err = %v, want ErrNotFound", err)
+	}
+	// удаление отсутствующего объекта — не ошибка
+	if err := store.Delete(ctx, "posts/a.png"); err != nil {
+		t.Errorf("Delete of missing object: %v", err)
+	}
+}
+
+func TestS3InvalidKey(t *testing.T) {
+	ctx := context.Background()
+	store, _, err := newTestS3(t, newFakeS3("media"), false)
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+
+	for _, key := range []string{"", "../etc/passwd", "posts/../../x"} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): err = %v, want ErrInvalidKey", key, err)
+		}
+		if _, _, err := store.Get(ctx, key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Get(%q): err = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+func TestS3SignedURL(t *testing.T) {
+	ctx := context.Background()
+	store, srv, err := newTestS3(t, newFakeS3("media"), false)
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	if err := store.Put(ctx, "avatars/u.jpg", strings.NewReader("avatar"), 6, "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	signed, err := store.SignedURL(ctx, "avatars/u.jpg", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("parsing signed url: %v", err)
+	}
+	if !strings.HasPrefix(signed, srv.URL+"/media/avatars/u.jpg?") {
+		t.Errorf("signed url %q does not point to the object", signed)
+	}
+	q := u.Query()
+	if q.Get("X-Amz-Expires") != "900" || q.Get("X-Amz-Signature") == "" {
+		t.Errorf("signed url query = %v", q)
+	}
+
+	resp, err := http.Get(signed)
+	if err != nil {
+		t.Fatalf("GET signed url: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "avatar" {
+		t.Errorf("GET signed url: %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestS3List(t *testing.T) {
+	ctx := context.Background()
+	store, _, err := newTestS3(t, newFakeS3("media"), false)
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	want := map[string]int64{"avatars/u.jpg": 3, "posts/a.png": 5, "posts/b.mp4": 8}
+	for key, size := range want {
+		if err := store.Put(ctx, key, bytes.NewReader(make([]byte, size)), size, "application/octet-stream"); err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+	}
+
+	got := make(map[string]int64)
+	err = store.List(ctx, func(key string, info ObjectInfo) error {
+		if info.ModTime.IsZero() {
+			t.Errorf("List: %q has no ModTime", key)
+		}
+		got[key] = info.Size
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+
+	// ошибка fn прерывает обход и возвращается из List
+	stop := errors.New("stop")
+	calls := 0
+	err = store.List(ctx, func(string, ObjectInfo) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("List with failing fn: err = %v, calls = %d", err, calls)
+	}
+}
+
+func TestS3Bucket(t *testing.T) {
+	if _, _, err := newTestS3(t, newFakeS3(), false); err == nil {
+		t.Error("NewS3 without bucket and create_bucket: want error")
+	}
+
+	fake := newFakeS3()
+	store, _, err := newTestS3(t, fake, true)
+	if err != nil {
+		t.Fatalf("NewS3 with create_bucket: %v", err)
+	}
+	if _, ok := fake.buckets["media"]; !ok {
+		t.Error("NewS3 with create_bucket did not create the bucket")
+	}
+	if err := store.Ping(context.Background()); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+
+	fake.mu.Lock()
+	delete(fake.buckets, "media")
+	fake.mu.Unlock()
+	if err := store.Ping(context.Background()); err == nil {
+		t.Error("Ping without bucket: want error")
+	}
+}
@@ -0,0 +1,81 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"kursach/internal/config"
+)
+
+// StaticPrefix — путь, по которому сервер сам раздаёт файлы хранилища.
+const StaticPrefix = "/static/"
+
+// URLs превращает ключи, сохранённые в базе, в абсолютные ссылки.
+// Если задан CDN, ссылки ведут на него, иначе — на /static/ этого сервера.
+type URLs struct {
+	base string
+	cdn  string
+}
+
+func NewURLs(cfg config.Media) (*URLs, error) {
+	base, err := baseURL(cfg.PublicBaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("media.public_base_url: %w", err)
+	}
+	var cdn string
+	if cfg.CDNBaseURL != "" {
+		if cdn, err = baseURL(cfg.CDNBaseURL); err != nil {
+			return nil, fmt.Errorf("media.cdn_base_url: %w", err)
+		}
+	}
+	return &URLs{base: base, cdn: cdn}, nil
+}
+
+func baseURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", errors.New("must be an absolute URL with scheme and host")
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// URL возвращает абсолютную ссылку на объект. Пустой ключ остаётся пустым.
+func (u *URLs) URL(key string) string {
+	if key == "" {
+		return ""
+	}
+	key = KeyFromLegacyURL(key)
+	if strings.Contains(key, "://") {
+		// внешняя ссылка, сохранённая как есть
+		return key
+	}
+	escaped := (&url.URL{Path: key}).EscapedPath()
+	if u.cdn != "" {
+		return u.cdn + "/" + escaped
+	}
+	return u.base + StaticPrefix + escaped
+}
+
+// KeyFromLegacyURL достаёт ключ из ссылок, которые раньше сохранялись
+// в базу целиком (например, "localhost:8082/static/posts/x.png").
+// Остальные значения возвращаются без изменений.
+func KeyFromLegacyURL(value string) string {
+	i := strings.Index(value, StaticPrefix)
+	if i < 0 {
+		return value
+	}
+	host := value[:i]
+	if strings.Contains(host, "://") {
+		host = host[strings.Index(host, "://")+3:]
+	}
+	// до /static/ должен стоять только адрес сервера
+	if host == "" || strings.Contains(host, "/") {
+		return value
+	}
+	return value[i+len(StaticPrefix):]
+}
@@ -0,0 +1,208 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrVideoTooLong = errors.New("video is too long")
+	ErrInvalidVideo = errors.New("invalid video")
+)
+
+// checkVideo проверяет длительность видео по заголовкам контейнера.
+// Видео, длительность которого определить не удалось, не принимается.
+func (p *ImageProcessor) checkVideo(data []byte, contentType string) error {
+	if p.videos.MaxDuration <= 0 {
+		return nil
+	}
+
+	var (
+		d   time.Duration
+		err error
+	)
+	switch contentType {
+	case "video/mp4":
+		d, err = mp4Duration(data)
+	case "video/webm":
+		d, err = webmDuration(data)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedMedia, contentType)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidVideo, err)
+	}
+	if d > p.videos.MaxDuration {
+		return ErrVideoTooLong
+	}
+	return nil
+}
+
+// mp4Duration читает длительность из moov/mvhd.
+func mp4Duration(data []byte) (time.Duration, error) {
+	moov, ok := mp4Box(data, "moov")
+	if !ok {
+		return 0, errors.New("mp4: moov box not found")
+	}
+	mvhd, ok := mp4Box(moov, "mvhd")
+	if !ok || len(mvhd) < 4 {
+		return 0, errors.New("mp4: mvhd box not found")
+	}
+
+	var timescale, duration uint64
+	switch mvhd[0] { // версия
+	case 0:
+		if len(mvhd) < 20 {
+			return 0, errors.New("mp4: short mvhd box")
+		}
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[12:]))
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:]))
+	case 1:
+		if len(mvhd) < 32 {
+			return 0, errors.New("mp4: short mvhd box")
+		}
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[20:]))
+		duration = binary.BigEndian.Uint64(mvhd[24:])
+	default:
+		return 0, fmt.Errorf("mp4: unknown mvhd version %d", mvhd[0])
+	}
+	if timescale == 0 {
+		return 0, errors.New("mp4: zero timescale")
+	}
+	return secondsDuration(float64(duration) / float64(timescale)), nil
+}
+
+// mp4Box возвращает содержимое первого бокса typ среди боксов data.
+func mp4Box(data []byte, typ string) ([]byte, bool) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data))
+		header := uint64(8)
+		switch size {
+		case 0: // до конца файла
+			size = uint64(len(data))
+		case 1: // 64-битный размер после типа
+			if len(data) < 16 {
+				return nil, false
+			}
+			size, header = binary.BigEndian.Uint64(data[8:]), 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return nil, false
+		}
+		if string(data[4:8]) == typ {
+			return data[header:size], true
+		}
+		data = data[size:]
+	}
+	return nil, false
+}
+
+// Идентификаторы элементов EBML, нужные для длительности WebM
+const (
+	ebmlSegment       = 0x18538067
+	ebmlInfo          = 0x1549A966
+	ebmlTimecodeScale = 0x2AD7B1
+	ebmlDuration      = 0x4489
+)
+
+// webmDuration читает Segment/Info/Duration; она задана в единицах
+// TimecodeScale (по умолчанию миллисекунды).
+func webmDuration(data []byte) (time.Duration, error) {
+	segment, ok := ebmlElement(data, ebmlSegment)
+	if !ok {
+		return 0, errors.New("webm: segment not found")
+	}
+	info, ok := ebmlElement(segment, ebmlInfo)
+	if !ok {
+		return 0, errors.New("webm: segment info not found")
+	}
+	durationData, ok := ebmlElement(info, ebmlDuration)
+	if !ok {
+		return 0, errors.New("webm: duration not set")
+	}
+
+	var duration float64
+	switch len(durationData) {
+	case 4:
+		duration = float64(math.Float32frombits(binary.BigEndian.Uint32(durationData)))
+	case 8:
+		duration = math.Float64frombits(binary.BigEndian.Uint64(durationData))
+	default:
+		return 0, errors.New("webm: invalid duration")
+	}
+
+	scale := uint64(1_000_000)
+	if scaleData, ok := ebmlElement(info, ebmlTimecodeScale); ok && len(scaleData) <= 8 {
+		scale = 0
+		for _, b := range scaleData {
+			scale = scale<<8 | uint64(b)
+		}
+	}
+	return secondsDuration(duration * float64(scale) / 1e9), nil
+}
+
+// ebmlElement возвращает содержимое первого элемента id среди элементов
+// data. Элемент неизвестного размера продолжается до конца data.
+func ebmlElement(data []byte, id uint64) ([]byte, bool) {
+	for len(data) > 0 {
+		elemID, n := ebmlVint(data, true)
+		if n == 0 {
+			return nil, false
+		}
+		size, m := ebmlVint(data[n:], false)
+		if m == 0 {
+			return nil, false
+		}
+		start := n + m
+		end := uint64(len(data))
+		if size != math.MaxUint64 {
+			if size > end-uint64(start) {
+				return nil, false
+			}
+			end = uint64(start) + size
+		}
+		if elemID == id {
+			return data[start:end], true
+		}
+		data = data[end:]
+	}
+	return nil, false
+}
+
+// ebmlVint читает целое переменной длины. У идентификаторов маркер длины
+// остаётся частью значения, у размеров — отбрасывается; размер из одних
+// единиц (неизвестный) возвращается как math.MaxUint64. n == 0 — ошибка.
+func ebmlVint(data []byte, keepMarker bool) (v uint64, n int) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0
+	}
+	for n = 1; data[0]&(0x80>>(n-1)) == 0; n++ {
+	}
+	if n > 8 || n > len(data) {
+		return 0, 0
+	}
+	marker := uint64(0x80 >> (n - 1))
+	v = uint64(data[0])
+	if !keepMarker {
+		v &^= marker
+	}
+	allOnes := v == marker-1
+	for _, b := range data[1:n] {
+		v = v<<8 | uint64(b)
+		allOnes = allOnes && b == 0xFF
+	}
+	if !keepMarker && allOnes {
+		return math.MaxUint64, n
+	}
+	return v, n
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	if seconds >= math.MaxInt64/float64(time.Second) {
+		return math.MaxInt64
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,98 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func mp4Box32(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	box := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(box, typ...), body...)
+}
+
+// testMP4 — ftyp и moov/mvhd версии 0 с длительностью d.
+func testMP4(d time.Duration) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000) // timescale
+	binary.BigEndian.PutUint32(mvhd[16:], uint32(d.Milliseconds()))
+	return append(
+		mp4Box32("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41")),
+		mp4Box32("moov", mp4Box32("mvhd", mvhd))...,
+	)
+}
+
+// ebml кодирует элемент с идентификатором id (вместе с маркером длины).
+func ebml(id []byte, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	// размер — 8-байтовое целое: маркер 0x01 и 7 байт значения
+	size := binary.BigEndian.AppendUint64(nil, uint64(len(body)))
+	size[0] = 0x01
+	out := append(append([]byte{}, id...), size...)
+	return append(out, body...)
+}
+
+// testWebM — заголовок EBML и Segment/Info с длительностью d в
+// миллисекундах. unknownSize — Segment неизвестного размера, как у записи
+// потока.
+func testWebM(d time.Duration, unknownSize bool) []byte {
+	header := ebml([]byte{0x1A, 0x45, 0xDF, 0xA3},
+		ebml([]byte{0x42, 0x86}, []byte{1}), // EBMLVersion
+		ebml([]byte{0x42, 0x82}, []byte("webm")),
+	)
+	duration := binary.BigEndian.AppendUint64(nil, math.Float64bits(float64(d.Milliseconds())))
+	info := ebml([]byte{0x15, 0x49, 0xA9, 0x66},
+		ebml([]byte{0x2A, 0xD7, 0xB1}, []byte{0x0F, 0x42, 0x40}), // TimecodeScale = 1 мс
+		ebml([]byte{0x44, 0x89}, duration),
+	)
+	cluster := ebml([]byte{0x1F, 0x43, 0xB6, 0x75}, make([]byte, 16))
+	if unknownSize {
+		segment := []byte{0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+		return append(append(append(header, segment...), info...), cluster...)
+	}
+	return append(header, ebml([]byte{0x18, 0x53, 0x80, 0x67}, info, cluster)...)
+}
+
+func TestVideoDuration(t *testing.T) {
+	if d, err := mp4Duration(testMP4(90 * time.Second)); err != nil || d != 90*time.Second {
+		t.Errorf("mp4Duration: %v, %v; want 1m30s", d, err)
+	}
+	for _, unknown := range []bool{false, true} {
+		if d, err := webmDuration(testWebM(42*time.Second, unknown)); err != nil || d != 42*time.Second {
+			t.Errorf("webmDuration(unknown size %v): %v, %v; want 42s", unknown, d, err)
+		}
+	}
+}
+
+func TestProcessVideo(t *testing.T) {
+	p := testProcessor() // MaxDuration — минута
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+		want string
+	}{
+		{"short mp4", testMP4(30 * time.Second), nil, "video/mp4"},
+		{"long mp4", testMP4(2 * time.Minute), ErrVideoTooLong, ""},
+		{"mp4 without moov", mp4Box32("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41")), ErrInvalidVideo, ""},
+		{"short webm", testWebM(59*time.Second, false), nil, "video/webm"},
+		{"long webm", testWebM(61*time.Second, true), ErrVideoTooLong, ""},
+		{"too large", append(testMP4(time.Second), make([]byte, 1<<20)...), ErrFileTooLarge, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := p.ProcessAttachment(bytes.NewReader(tt.data))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ProcessAttachment: err %v, want %v", err, tt.err)
+			}
+			if err == nil && (a.Kind != AttachmentVideo || a.Video.ContentType != tt.want) {
+				t.Errorf("ProcessAttachment: got %s %q, want video %q", a.Kind, a.Video.ContentType, tt.want)
+			}
+		})
+	}
+}
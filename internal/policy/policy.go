@@ -0,0 +1,64 @@
+package policy
+
+import "slices"
+
+// Роли пользователей. Каждая следующая роль включает права предыдущей.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var rank = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rank[role]
+	return ok
+}
+
+// Actor — пользователь, от имени которого выполняется запрос.
+type Actor struct {
+	UserID int
+	Roles  []string
+}
+
+// HasRole сообщает, есть ли у пользователя указанная роль или более старшая.
+func (a Actor) HasRole(role string) bool {
+	return slices.ContainsFunc(a.Roles, func(r string) bool {
+		return rank[r] >= rank[role] && rank[role] > 0
+	})
+}
+
+// CanModerate — доступ к жалобам и скрытому контенту.
+func (a Actor) CanModerate() bool {
+	return a.HasRole(RoleModerator)
+}
+
+// CanDeletePost — удалить пост может автор или модератор.
+func (a Actor) CanDeletePost(authorID int) bool {
+	return a.UserID == authorID || a.CanModerate()
+}
+
+// CanEditPost — редактировать пост может только автор.
+func (a Actor) CanEditPost(authorID int) bool {
+	return a.UserID == authorID
+}
+
+// CanEditComment — редактировать комментарий может только автор.
+func (a Actor) CanEditComment(authorID int) bool {
+	return a.UserID == authorID
+}
+
+// CanDeleteComment — удалить комментарий может автор или модератор.
+func (a Actor) CanDeleteComment(authorID int) bool {
+	return a.UserID == authorID || a.CanModerate()
+}
+
+// CanManageRoles — назначать роли может только администратор.
+func (a Actor) CanManageRoles() bool {
+	return a.HasRole(RoleAdmin)
+}
@@ -0,0 +1,65 @@
+package ranking
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"kursach/internal/config"
+)
+
+// Feed — настройки ленты «Для вас»: доступные ранжировщики и то, какой
+// из них достаётся пользователю.
+type Feed struct {
+	Scorers           map[string]Scorer
+	Default           string
+	Experiment        string
+	ExperimentPercent int
+	Window            time.Duration
+	MaxCandidates     int
+}
+
+func NewFeed(cfg config.Ranking) (*Feed, error) {
+	f := &Feed{
+		Scorers: map[string]Scorer{
+			"weighted": withHalfLife(DefaultWeighted(), cfg.HalfLife),
+			"recent":   Recency{HalfLife: cfg.HalfLife},
+		},
+		Default:           cfg.Ranker,
+		Experiment:        cfg.Experiment,
+		ExperimentPercent: cfg.ExperimentPercent,
+		Window:            cfg.Window,
+		MaxCandidates:     cfg.MaxCandidates,
+	}
+	if cfg.HalfLife <= 0 {
+		return nil, fmt.Errorf("ranking.half_life must be positive")
+	}
+	if _, ok := f.Scorers[f.Default]; !ok {
+		return nil, fmt.Errorf("ranking.ranker: unknown ranker %q", f.Default)
+	}
+	if _, ok := f.Scorers[f.Experiment]; f.Experiment != "" && !ok {
+		return nil, fmt.Errorf("ranking.experiment: unknown ranker %q", f.Experiment)
+	}
+	return f, nil
+}
+
+func withHalfLife(w Weighted, halfLife time.Duration) Weighted {
+	w.HalfLife = halfLife
+	return w
+}
+
+// Pick возвращает имя ранжировщика для пользователя. Группа эксперимента
+// определяется хешем от его имени и пользователя, поэтому пользователь
+// остаётся в своей группе, пока эксперимент не сменится.
+func (f *Feed) Pick(userID int) string {
+	if f.Experiment == "" || f.ExperimentPercent <= 0 {
+		return f.Default
+	}
+	h := fnv.New32a()
+	h.Write([]byte(f.Experiment + ":" + strconv.Itoa(userID)))
+	if int(h.Sum32()%100) < f.ExperimentPercent {
+		return f.Experiment
+	}
+	return f.Default
+}
@@ -0,0 +1,66 @@
+package ranking
+
+import (
+	"testing"
+	"time"
+
+	"kursach/internal/config"
+)
+
+func TestNewFeed(t *testing.T) {
+	valid := config.Ranking{Ranker: "weighted", Experiment: "recent", ExperimentPercent: 10, Window: time.Hour, MaxCandidates: 10, HalfLife: time.Hour}
+	f, err := NewFeed(valid)
+	if err != nil {
+		t.Fatalf("NewFeed: %v", err)
+	}
+	if w, ok := f.Scorers["weighted"].(Weighted); !ok || w.HalfLife != time.Hour {
+		t.Errorf("weighted scorer %#v, want half-life from config", f.Scorers["weighted"])
+	}
+
+	for name, cfg := range map[string]config.Ranking{
+		"unknown ranker":     {Ranker: "popular", HalfLife: time.Hour},
+		"unknown experiment": {Ranker: "weighted", Experiment: "popular", HalfLife: time.Hour},
+		"zero half-life":     {Ranker: "weighted"},
+	} {
+		if _, err := NewFeed(cfg); err == nil {
+			t.Errorf("%s: NewFeed succeeded", name)
+		}
+	}
+}
+
+func TestPick(t *testing.T) {
+	f := &Feed{Default: "weighted", Experiment: "recent", ExperimentPercent: 30}
+
+	const users = 10000
+	inExperiment := 0
+	for id := 1; id <= users; id++ {
+		got := f.Pick(id)
+		if got != f.Pick(id) {
+			t.Fatalf("Pick(%d) changed between calls", id)
+		}
+		if got == "recent" {
+			inExperiment++
+		}
+	}
+	// 30% ± 2%
+	if inExperiment < users*28/100 || inExperiment > users*32/100 {
+		t.Errorf("Pick: %d of %d users in experiment, want about 30%%", inExperiment, users)
+	}
+
+	f.ExperimentPercent = 100
+	for id := 1; id <= 100; id++ {
+		if got := f.Pick(id); got != "recent" {
+			t.Fatalf("Pick(%d) at 100%%: got %q, want recent", id, got)
+		}
+	}
+	f.ExperimentPercent = 0
+	for id := 1; id <= 100; id++ {
+		if got := f.Pick(id); got != "weighted" {
+			t.Fatalf("Pick(%d) at 0%%: got %q, want weighted", id, got)
+		}
+	}
+	f.Experiment, f.ExperimentPercent = "", 50
+	if got := f.Pick(1); got != "weighted" {
+		t.Errorf("Pick without experiment: got %q, want weighted", got)
+	}
+}
@@ -0,0 +1,58 @@
+// Package ranking упорядочивает посты ленты «Для вас». Функция оценки
+// скрыта за интерфейсом Scorer, чтобы ранжировщики можно было сравнивать
+// в A/B-экспериментах.
+package ranking
+
+import (
+	"sort"
+	"time"
+
+	"kursach/internal/storage/postgres"
+)
+
+// Scorer оценивает пост-кандидат для пользователя. Чем больше Value, тем
+// выше пост в ленте.
+type Scorer interface {
+	Score(post postgres.PostSignals, now time.Time) Score
+}
+
+// Score — оценка поста. Factors объясняет её: вклад каждого сигнала, как
+// его посчитал ранжировщик.
+type Score struct {
+	PostID  int                `json:"post_id"`
+	Value   float64            `json:"score"`
+	Factors map[string]float64 `json:"factors"`
+}
+
+// Rank оценивает кандидатов и сортирует их по убыванию оценки; при равных
+// оценках выше более новый пост.
+func Rank(scorer Scorer, candidates []postgres.PostSignals, now time.Time) []Score {
+	scores := make([]Score, len(candidates))
+	for i, c := range candidates {
+		scores[i] = scorer.Score(c, now)
+		scores[i].PostID = c.PostID
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return before(scores[i], scores[j].Value, scores[j].PostID)
+	})
+	return scores
+}
+
+// After возвращает оценки, идущие в порядке Rank строго после поста postID
+// с оценкой value. Так страницы ленты продолжаются с места, где закончилась
+// предыдущая, даже если между запросами оценки постов изменились.
+func After(scores []Score, value float64, postID int) []Score {
+	last := Score{PostID: postID, Value: value}
+	i := sort.Search(len(scores), func(i int) bool {
+		return before(last, scores[i].Value, scores[i].PostID)
+	})
+	return scores[i:]
+}
+
+// before сообщает, стоит ли s в ленте выше поста postID с оценкой value.
+func before(s Score, value float64, postID int) bool {
+	if s.Value != value {
+		return s.Value > value
+	}
+	return s.PostID > postID
+}
@@ -0,0 +1,158 @@
+package ranking
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"kursach/internal/storage/postgres"
+)
+
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestWeightedScore(t *testing.T) {
+	w := DefaultWeighted()
+	w.HalfLife = time.Hour
+
+	// Пост без сигналов ранжируется только по возрасту
+	empty := w.Score(postgres.PostSignals{CreatedAt: testNow}, testNow)
+	if !approx(empty.Value, 1) {
+		t.Errorf("empty post: score %v, want 1", empty.Value)
+	}
+
+	post := postgres.PostSignals{
+		CreatedAt:     testNow.Add(-2 * time.Hour),
+		Likes:         3,
+		Comments:      1,
+		Favorites:     7,
+		FollowsAuthor: true,
+		FolloweeLikes: 1,
+		TagAffinity:   3,
+	}
+	got := w.Score(post, testNow)
+	want := map[string]float64{
+		"likes":          w.Likes * math.Log(4),
+		"comments":       w.Comments * math.Log(2),
+		"favorites":      w.Favorites * math.Log(8),
+		"followee_likes": w.FolloweeLikes * math.Log(2),
+		"tag_affinity":   w.TagAffinity * math.Log(4),
+		"follows_author": w.FollowsAuthor,
+		"decay":          0.25,
+	}
+	for name, v := range want {
+		if !approx(got.Factors[name], v) {
+			t.Errorf("factor %s = %v, want %v", name, got.Factors[name], v)
+		}
+	}
+	if len(got.Factors) != len(want) {
+		t.Errorf("factors %v, want %d entries", got.Factors, len(want))
+	}
+	sum := 1.0
+	for name, v := range want {
+		if name != "decay" {
+			sum += v
+		}
+	}
+	if !approx(got.Value, sum*0.25) {
+		t.Errorf("score %v, want %v", got.Value, sum*0.25)
+	}
+
+	// Без подписки на автора фактора нет, а оценка меньше
+	post.FollowsAuthor = false
+	unfollowed := w.Score(post, testNow)
+	if _, ok := unfollowed.Factors["follows_author"]; ok {
+		t.Error("follows_author factor without follow")
+	}
+	if unfollowed.Value >= got.Value {
+		t.Errorf("score without follow %v, want less than %v", unfollowed.Value, got.Value)
+	}
+
+	// Оценка воспроизводима: по ней листается лента
+	for range 10 {
+		if again := w.Score(post, testNow); again.Value != unfollowed.Value {
+			t.Fatalf("score %v, then %v", unfollowed.Value, again.Value)
+		}
+	}
+}
+
+func TestRecencyScore(t *testing.T) {
+	r := Recency{HalfLife: time.Hour}
+	for _, tc := range []struct {
+		age  time.Duration
+		want float64
+	}{
+		{0, 1},
+		{time.Hour, 0.5},
+		{3 * time.Hour, 0.125},
+		{-time.Hour, 1}, // пост «из будущего» при расхождении часов
+	} {
+		got := r.Score(postgres.PostSignals{CreatedAt: testNow.Add(-tc.age), Likes: 100}, testNow)
+		if !approx(got.Value, tc.want) || !approx(got.Factors["decay"], tc.want) {
+			t.Errorf("age %v: score %v (factors %v), want %v", tc.age, got.Value, got.Factors, tc.want)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	candidates := []postgres.PostSignals{
+		{PostID: 1, CreatedAt: testNow.Add(-3 * time.Hour)},
+		{PostID: 2, CreatedAt: testNow.Add(-time.Hour)},
+		{PostID: 3, CreatedAt: testNow.Add(-time.Hour)},
+		{PostID: 4, CreatedAt: testNow},
+	}
+	scores := Rank(Recency{HalfLife: time.Hour}, candidates, testNow)
+	// Равные оценки — более новый (больший id) пост выше
+	if got, want := scoreIDs(scores), []int{4, 3, 2, 1}; !slices.Equal(got, want) {
+		t.Errorf("Rank: got %v, want %v", got, want)
+	}
+
+	// Взвешенный ранжировщик поднимает старый пост с лайками
+	candidates[0].Likes = 1000
+	scores = Rank(DefaultWeighted(), candidates, testNow)
+	if scores[0].PostID != 1 {
+		t.Errorf("Rank weighted: got %v, want post 1 first", scoreIDs(scores))
+	}
+}
+
+func TestAfter(t *testing.T) {
+	scores := []Score{
+		{PostID: 5, Value: 3},
+		{PostID: 7, Value: 2},
+		{PostID: 4, Value: 2},
+		{PostID: 9, Value: 1},
+	}
+	for _, tc := range []struct {
+		name   string
+		value  float64
+		postID int
+		want   []int
+	}{
+		{"first", 3, 5, []int{7, 4, 9}},
+		{"equal score", 2, 7, []int{4, 9}},
+		{"last", 1, 9, []int{}},
+		// Оценка последнего показанного поста выросла: страница
+		// продолжается с его места в старом порядке
+		{"score changed", 2.5, 5, []int{7, 4, 9}},
+		// Пост исчез из кандидатов
+		{"missing post", 2, 6, []int{4, 9}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := scoreIDs(After(scores, tc.value, tc.postID)); !slices.Equal(got, tc.want) {
+				t.Errorf("After(%v, %d): got %v, want %v", tc.value, tc.postID, got, tc.want)
+			}
+		})
+	}
+}
+
+func scoreIDs(scores []Score) []int {
+	ids := make([]int, len(scores))
+	for i, s := range scores {
+		ids[i] = s.PostID
+	}
+	return ids
+}
@@ -0,0 +1,74 @@
+package ranking
+
+import (
+	"math"
+	"time"
+
+	"kursach/internal/storage/postgres"
+)
+
+// Weighted складывает логарифмы сигналов с весами и умножает сумму на
+// затухание по возрасту поста: за HalfLife оценка падает вдвое.
+type Weighted struct {
+	Likes         float64
+	Comments      float64
+	Favorites     float64
+	FollowsAuthor float64
+	FolloweeLikes float64
+	TagAffinity   float64
+	HalfLife      time.Duration
+}
+
+// DefaultWeighted — веса ранжировщика по умолчанию.
+func DefaultWeighted() Weighted {
+	return Weighted{
+		Likes:         1,
+		Comments:      1.5,
+		Favorites:     2,
+		FollowsAuthor: 2,
+		FolloweeLikes: 1.5,
+		TagAffinity:   1,
+		HalfLife:      24 * time.Hour,
+	}
+}
+
+func (w Weighted) Score(post postgres.PostSignals, now time.Time) Score {
+	factors := map[string]float64{
+		"likes":          w.Likes * math.Log1p(float64(post.Likes)),
+		"comments":       w.Comments * math.Log1p(float64(post.Comments)),
+		"favorites":      w.Favorites * math.Log1p(float64(post.Favorites)),
+		"followee_likes": w.FolloweeLikes * math.Log1p(float64(post.FolloweeLikes)),
+		"tag_affinity":   w.TagAffinity * math.Log1p(float64(post.TagAffinity)),
+	}
+	if post.FollowsAuthor {
+		factors["follows_author"] = w.FollowsAuthor
+	}
+
+	// Складываем в постоянном порядке: обход map случаен, а от порядка
+	// сложения зависят младшие биты оценки, по которой листается лента
+	sum := 1.0 // чтобы пост без сигналов тоже ранжировался по возрасту
+	for _, name := range []string{"likes", "comments", "favorites", "followee_likes", "tag_affinity", "follows_author"} {
+		sum += factors[name]
+	}
+	decay := decay(now.Sub(post.CreatedAt), w.HalfLife)
+	factors["decay"] = decay
+	return Score{Value: sum * decay, Factors: factors}
+}
+
+// Recency ранжирует только по возрасту — контрольная группа для
+// экспериментов: порядок совпадает с обычной лентой.
+type Recency struct {
+	HalfLife time.Duration
+}
+
+func (r Recency) Score(post postgres.PostSignals, now time.Time) Score {
+	decay := decay(now.Sub(post.CreatedAt), r.HalfLife)
+	return Score{Value: decay, Factors: map[string]float64{"decay": decay}}
+}
+
+func decay(age, halfLife time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, age.Hours()/halfLife.Hours())
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"kursach/internal/storage/postgres"
+)
+
+// AddUserBlock блокирует пользователя и, как postgres, удаляет подписки
+// между двумя пользователями в обе стороны вместе с их лентами.
+func (s *Store) AddUserBlock(ctx context.Context, blockerID, blockedID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if blockerID == blockedID {
+		return errCheck
+	}
+	if _, ok := s.users[blockerID]; !ok {
+		return postgres.ErrUserNotFound
+	}
+	if _, ok := s.users[blockedID]; !ok {
+		return postgres.ErrUserNotFound
+	}
+
+	key := pair{blockerID, blockedID}
+	if _, exists := s.blocks[key]; !exists {
+		s.blocks[key] = s.now()
+		delete(s.follows, pair{blockerID, blockedID})
+		delete(s.follows, pair{blockedID, blockerID})
+		s.pruneTimeline(blockerID, blockedID)
+		s.pruneTimeline(blockedID, blockerID)
+	}
+	return nil
+}
+
+func (s *Store) IsUserBlocked(ctx context.Context, blockerID, blockedID int) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, blocked := s.blocks[pair{blockerID, blockedID}]
+	return blocked, nil
+}
+
+func (s *Store) IsBlockedBetween(ctx context.Context, userID, otherID int) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.blockedBetween(userID, otherID), nil
+}
+
+func (s *Store) GetBlockedUsers(ctx context.Context, blockerID int) ([]postgres.BlockedUser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []postgres.BlockedUser{}
+	for key, blockedAt := range s.blocks {
+		if key.a != blockerID {
+			continue
+		}
+		u := s.users[key.b]
+		users = append(users, postgres.BlockedUser{
+			UserID:    u.id,
+			UserName:  u.userName,
+			UserTag:   u.userTag,
+			BlockedAt: blockedAt,
+		})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].BlockedAt.Equal(users[j].BlockedAt) {
+			return users[i].BlockedAt.After(users[j].BlockedAt)
+		}
+		return users[i].UserID > users[j].UserID
+	})
+	return users, nil
+}
+
+func (s *Store) RemoveBlock(ctx context.Context, blockerID, blockedID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.blocks, pair{blockerID, blockedID})
+	return nil
+}
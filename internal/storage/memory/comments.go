@@ -0,0 +1,255 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"kursach/internal/storage/postgres"
+)
+
+// AddComment повторяет функцию create_comment: между комментатором и
+// авторами поста и родительского комментария не должно быть блокировки,
+// автор поста получает уведомление.
+func (s *Store) AddComment(ctx context.Context, c *postgres.Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	depth := 0
+	if c.ParentID != nil {
+		parent, ok := s.comments[*c.ParentID]
+		if !ok || parent.hidden || parent.PostID != c.PostID {
+			return postgres.ErrParentComment
+		}
+		if parent.depth+1 > postgres.MaxCommentDepth {
+			return postgres.ErrCommentTooDeep
+		}
+		depth = parent.depth + 1
+	}
+
+	p, ok := s.posts[c.PostID]
+	if !ok || p.hidden {
+		return postgres.ErrPostNotFound
+	}
+	if _, ok := s.users[c.AuthorID]; !ok {
+		return errForeignKey
+	}
+	if s.blockedBetween(c.AuthorID, p.authorID) {
+		return postgres.ErrBlocked
+	}
+	if c.ParentID != nil && s.blockedBetween(c.AuthorID, s.comments[*c.ParentID].AuthorID) {
+		return postgres.ErrBlocked
+	}
+
+	s.nextCommentID++
+	c.ID = s.nextCommentID
+	c.CreatedAt = s.now()
+	s.comments[c.ID] = &comment{Comment: *c, depth: depth}
+
+	if p.authorID != c.AuthorID {
+		s.notify(p.authorID, notificationComment, c.ID)
+	}
+	return nil
+}
+
+func (s *Store) UpdateComment(ctx context.Context, commentID int, text string) (*postgres.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[commentID]
+	if !ok || c.hidden {
+		return nil, postgres.ErrCommentNotFound
+	}
+	now := s.now()
+	c.Text = text
+	c.EditedAt = &now
+
+	updated := c.Comment
+	updated.AuthorUserTag = ""
+	return &updated, nil
+}
+
+func (s *Store) GetCommentAuthorID(ctx context.Context, commentID int) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.comments[commentID]
+	if !ok {
+		return 0, postgres.ErrCommentNotFound
+	}
+	return c.AuthorID, nil
+}
+
+func (s *Store) DeleteComment(ctx context.Context, commentID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.comments[commentID]; !ok {
+		return postgres.ErrCommentNotFound
+	}
+	s.deleteComment(commentID)
+	return nil
+}
+
+// deleteComment удаляет комментарий вместе с ответами (ON DELETE CASCADE).
+func (s *Store) deleteComment(commentID int) {
+	delete(s.comments, commentID)
+	for key := range s.commentLikes {
+		if key.b == commentID {
+			delete(s.commentLikes, key)
+		}
+	}
+	for id, c := range s.comments {
+		if c.ParentID != nil && *c.ParentID == commentID {
+			s.deleteComment(id)
+		}
+	}
+}
+
+func (s *Store) GetCommentsByPostID(ctx context.Context, postID int, q postgres.CommentsQuery) ([]postgres.CommentBrief, *postgres.CommentCursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if q.Tree {
+		return postgres.CommentTree(s.commentsFor(postID, q.ViewerID)), nil, nil
+	}
+	page := s.commentPage(postID, postgres.CommentsQuery{ParentID: q.ParentID, Order: q.Order, Limit: q.Limit + 1, After: q.After, ViewerID: q.ViewerID})
+	if len(page) <= q.Limit {
+		return page, nil, nil
+	}
+	page = page[:q.Limit]
+	last := page[len(page)-1]
+	return page, &postgres.CommentCursor{CreatedAt: last.CreatedAt, Likes: last.LikeCount, ID: last.CommentID}, nil
+}
+
+// commentPage повторяет плоскую выборку GetCommentsByPostID без курсора следующей страницы.
+func (s *Store) commentPage(postID int, q postgres.CommentsQuery) []postgres.CommentBrief {
+	var level []postgres.CommentBrief
+	for _, c := range s.commentsFor(postID, q.ViewerID) {
+		if (c.ParentID == nil && q.ParentID == nil) || (c.ParentID != nil && q.ParentID != nil && *c.ParentID == *q.ParentID) {
+			level = append(level, c)
+		}
+	}
+
+	// before сообщает, идёт ли a раньше b в выбранном порядке
+	var before func(a, b postgres.CommentCursor) bool
+	switch q.Order {
+	case postgres.CommentsNewest:
+		before = func(a, b postgres.CommentCursor) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ID > b.ID
+		}
+	case postgres.CommentsTop:
+		before = func(a, b postgres.CommentCursor) bool {
+			if a.Likes != b.Likes {
+				return a.Likes > b.Likes
+			}
+			return a.ID > b.ID
+		}
+	default:
+		before = func(a, b postgres.CommentCursor) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		}
+	}
+	position := func(c postgres.CommentBrief) postgres.CommentCursor {
+		return postgres.CommentCursor{CreatedAt: c.CreatedAt, Likes: c.LikeCount, ID: c.CommentID}
+	}
+	sort.Slice(level, func(i, j int) bool { return before(position(level[i]), position(level[j])) })
+
+	var page []postgres.CommentBrief
+	for _, c := range level {
+		if q.After != nil && !before(*q.After, position(c)) {
+			continue
+		}
+		if len(page) == q.Limit {
+			break
+		}
+		page = append(page, c)
+	}
+	return page
+}
+
+func (s *Store) AddCommentLike(ctx context.Context, userID, commentID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.comments[commentID]
+	if !ok || c.hidden {
+		return postgres.ErrCommentNotFound
+	}
+	if _, ok := s.users[userID]; !ok {
+		return errForeignKey
+	}
+	if s.blockedBetween(userID, c.AuthorID) {
+		return postgres.ErrBlocked
+	}
+	key := pair{userID, commentID}
+	if _, exists := s.commentLikes[key]; !exists {
+		s.commentLikes[key] = s.now()
+	}
+	return nil
+}
+
+func (s *Store) RemoveCommentLike(ctx context.Context, userID, commentID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.commentLikes, pair{userID, commentID})
+	return nil
+}
+
+// commentsOf повторяет выборку из view_comment_with_author_tag.
+// commentsFor — комментарии поста без авторов, с которыми у viewerID есть блокировка.
+func (s *Store) commentsFor(postID, viewerID int) []postgres.CommentBrief {
+	var comments []postgres.CommentBrief
+	for _, c := range s.commentsOf(postID) {
+		if !s.blockedBetween(viewerID, c.AuthorID) {
+			comments = append(comments, c)
+		}
+	}
+	return comments
+}
+
+func (s *Store) commentsOf(postID int) []postgres.CommentBrief {
+	var comments []postgres.CommentBrief
+	for _, c := range s.comments {
+		if c.PostID != postID || c.hidden {
+			continue
+		}
+		replies := 0
+		for _, r := range s.comments {
+			if r.ParentID != nil && *r.ParentID == c.ID && !r.hidden {
+				replies++
+			}
+		}
+		likes := 0
+		for key := range s.commentLikes {
+			if key.b == c.ID {
+				likes++
+			}
+		}
+		comments = append(comments, postgres.CommentBrief{
+			CommentID:  c.ID,
+			PostID:     c.PostID,
+			ParentID:   c.ParentID,
+			Comment:    c.Text,
+			CreatedAt:  c.CreatedAt,
+			EditedAt:   c.EditedAt,
+			AuthorID:   c.AuthorID,
+			AuthorTag:  s.users[c.AuthorID].userTag,
+			ReplyCount: replies,
+			LikeCount:  likes,
+		})
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return comments[i].CommentID < comments[j].CommentID
+	})
+	return comments
+}
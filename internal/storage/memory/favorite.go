@@ -0,0 +1,29 @@
+package memory
+
+import "context"
+
+func (s *Store) AddToFavorites(ctx context.Context, userID, postID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[postID]; !ok {
+		return errForeignKey
+	}
+	if _, ok := s.users[userID]; !ok {
+		return errForeignKey
+	}
+
+	key := pair{userID, postID}
+	if _, exists := s.favorites[key]; !exists {
+		s.favorites[key] = s.now()
+	}
+	return nil
+}
+
+func (s *Store) RemoveFromFavorites(ctx context.Context, userID, postID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.favorites, pair{userID, postID})
+	return nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"kursach/internal/storage/postgres"
+)
+
+// AddFollow повторяет процедуру follow: подписка между заблокированными
+// запрещена, пользователь получает уведомление о подписчике, а подписчик —
+// разложенные посты автора в ленту.
+func (s *Store) AddFollow(ctx context.Context, followerID, followingID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if followerID == followingID {
+		return errCheck
+	}
+	if _, ok := s.users[followerID]; !ok {
+		return errForeignKey
+	}
+	if _, ok := s.users[followingID]; !ok {
+		return errForeignKey
+	}
+	if s.blockedBetween(followerID, followingID) {
+		return postgres.ErrBlocked
+	}
+
+	key := pair{followerID, followingID}
+	if _, exists := s.follows[key]; exists {
+		return nil
+	}
+	s.follows[key] = s.now()
+	s.notify(followingID, notificationFollow, followerID)
+	s.backfillTimeline(followerID, followingID)
+	return nil
+}
+
+func (s *Store) RemoveFollow(ctx context.Context, followerID, followingID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.follows[pair{followerID, followingID}]; exists {
+		delete(s.follows, pair{followerID, followingID})
+		s.pruneTimeline(followerID, followingID)
+	}
+	return nil
+}
+
+func (s *Store) GetUserFollowings(ctx context.Context, followerID int) ([]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var followings []int
+	for key := range s.follows {
+		if key.a == followerID {
+			followings = append(followings, key.b)
+		}
+	}
+	sort.Ints(followings)
+	return followings, nil
+}
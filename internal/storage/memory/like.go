@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+
+	"kursach/internal/storage/postgres"
+)
+
+// AddLike повторяет процедуру like_post: между лайкающим и автором не
+// должно быть блокировки, автор поста получает уведомление.
+func (s *Store) AddLike(ctx context.Context, userID, postID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[postID]
+	if !ok || p.hidden {
+		return postgres.ErrPostNotFound
+	}
+	if _, ok := s.users[userID]; !ok {
+		return errForeignKey
+	}
+	if s.blockedBetween(userID, p.authorID) {
+		return postgres.ErrBlocked
+	}
+
+	key := pair{userID, postID}
+	if _, exists := s.likes[key]; exists {
+		return nil
+	}
+	s.likes[key] = s.now()
+
+	if p.authorID != userID {
+		s.notify(p.authorID, notificationLike, postID)
+	}
+	return nil
+}
+
+func (s *Store) RemoveLike(ctx context.Context, userID, postID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.likes, pair{userID, postID})
+	return nil
+}
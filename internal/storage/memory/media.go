@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+
+	"kursach/internal/storage/postgres"
+)
+
+// ReferencedMediaKeys повторяет postgres.Storage.ReferencedMediaKeys:
+// ключи файлов постов, вложений, их прежних версий и аватаров.
+func (s *Store) ReferencedMediaKeys(ctx context.Context) (map[string]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make(map[string]bool)
+	for _, p := range s.posts {
+		for _, key := range postMediaKeys(p) {
+			keys[key] = true
+		}
+	}
+	for _, u := range s.users {
+		var avatar postgres.Attachment
+		if u.avatarURL != nil {
+			avatar.URL = *u.avatarURL
+		}
+		avatar.Images = u.avatarImages
+		for _, key := range postgres.MediaKeys([]postgres.Attachment{avatar}) {
+			keys[key] = true
+		}
+	}
+	return keys, nil
+}
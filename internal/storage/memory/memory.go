@@ -0,0 +1,176 @@
+// Package memory — хранилище в памяти с той же семантикой, что и
+// postgres (включая хранимые процедуры и представления). Используется
+// для тестов обработчиков без базы данных.
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"kursach/internal/storage/postgres"
+)
+
+var (
+	// errNoRows повторяет sql.ErrNoRows, которую возвращают методы postgres.
+	errNoRows = errors.New("sql: no rows in result set")
+	// errForeignKey и errCheck повторяют нарушения ограничений схемы.
+	errForeignKey = errors.New("insert or update violates foreign key constraint")
+	errCheck      = errors.New("new row violates check constraint")
+)
+
+type pair struct {
+	a, b int
+}
+
+type user struct {
+	id          int
+	email       string
+	password    string
+	role        string
+	suspendedAt *time.Time
+	createdAt   time.Time
+
+	userName     string
+	userTag      string
+	theme        string
+	language     string
+	avatarURL    *string
+	avatarImages postgres.Images
+	description  *string
+}
+
+type session struct {
+	postgres.Session
+	token         string
+	previousToken string
+	revokedAt     *time.Time
+}
+
+type post struct {
+	id          int
+	authorID    int
+	title       string
+	description string
+	imageURL    string
+	images      postgres.Images
+	attachments []postgres.Attachment
+	createdAt   time.Time
+	editedAt    *time.Time
+	hidden      bool
+	fannedOut   bool
+	// fanoutSkipped — у автора слишком много подписчиков, пост читается из posts
+	fanoutSkipped bool
+	revisions     []postgres.PostRevision // старые первыми
+}
+
+type comment struct {
+	postgres.Comment
+	depth  int
+	hidden bool
+}
+
+// Store хранит все данные. Один Store реализует хранилища пользователей,
+// постов и уведомлений.
+type Store struct {
+	mu  sync.RWMutex
+	now func() time.Time
+
+	nextUserID         int
+	nextPostID         int
+	nextCommentID      int
+	nextTagID          int
+	nextNotificationID int
+	nextReportID       int
+	nextActionID       int
+	nextAttachmentID   int
+	nextRevisionID     int
+
+	users    map[int]*user
+	sessions map[string]*session
+
+	posts    map[int]*post
+	tags     map[int]string
+	postTags map[int][]int
+	comments map[int]*comment
+
+	likes        map[pair]time.Time // (user_id, post_id)
+	commentLikes map[pair]time.Time // (user_id, comment_id)
+	follows      map[pair]time.Time // (follower_id, following_id)
+	blocks       map[pair]time.Time // (blocker_id, blocked_id)
+	favorites    map[pair]time.Time // (user_id, post_id)
+	timeline     map[pair]bool      // timeline_entries (user_id, post_id)
+
+	notifications []*postgres.Notification
+
+	reasons []reportReason
+	reports map[int]*postgres.Report
+	actions []postgres.ModerationAction
+}
+
+type reportReason struct {
+	postgres.ReportReason
+	active bool
+}
+
+func New() *Store {
+	return &Store{
+		now:          time.Now,
+		users:        make(map[int]*user),
+		sessions:     make(map[string]*session),
+		posts:        make(map[int]*post),
+		tags:         make(map[int]string),
+		postTags:     make(map[int][]int),
+		comments:     make(map[int]*comment),
+		likes:        make(map[pair]time.Time),
+		commentLikes: make(map[pair]time.Time),
+		follows:      make(map[pair]time.Time),
+		blocks:       make(map[pair]time.Time),
+		favorites:    make(map[pair]time.Time),
+		timeline:     make(map[pair]bool),
+		reports:      make(map[int]*postgres.Report),
+		// как в миграции 0004_report_reasons
+		reasons: []reportReason{
+			{postgres.ReportReason{Code: "spam", Title: "Spam"}, true},
+			{postgres.ReportReason{Code: "harassment", Title: "Harassment or bullying"}, true},
+			{postgres.ReportReason{Code: "hate_speech", Title: "Hate speech"}, true},
+			{postgres.ReportReason{Code: "nudity", Title: "Nudity or sexual content"}, true},
+			{postgres.ReportReason{Code: "violence", Title: "Violence"}, true},
+			{postgres.ReportReason{Code: "impersonation", Title: "Impersonation"}, true},
+			{postgres.ReportReason{Code: "misinformation", Title: "False information"}, true},
+			{postgres.ReportReason{Code: "other", Title: "Other"}, true},
+		},
+	}
+}
+
+// SetClock подменяет источник текущего времени.
+func (s *Store) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// Ping всегда успешен: хранилище в памяти доступно, пока жив процесс.
+func (s *Store) Ping(ctx context.Context) error {
+	return nil
+}
+
+// notify повторяет вставку уведомления из хранимых процедур.
+func (s *Store) notify(userID, typeID, entityID int) {
+	s.nextNotificationID++
+	s.notifications = append(s.notifications, &postgres.Notification{
+		ID:        s.nextNotificationID,
+		UserID:    userID,
+		TypeID:    typeID,
+		EntityID:  entityID,
+		CreatedAt: s.now(),
+	})
+}
+
+// Типы уведомлений (notification_types)
+const (
+	notificationLike    = 1
+	notificationComment = 2
+	notificationFollow  = 3
+)
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"kursach/internal/storage/postgres"
+)
+
+// GetUnreadNotifications повторяет функцию get_unread_notifications.
+func (s *Store) GetUnreadNotifications(ctx context.Context, userID int) ([]postgres.Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var notifications []postgres.Notification
+	for _, n := range s.notifications {
+		if n.UserID == userID && !n.IsRead {
+			notifications = append(notifications, *n)
+		}
+	}
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+	return notifications, nil
+}
+
+// MarkAllAsRead повторяет процедуру mark_all_notifications_as_read.
+func (s *Store) MarkAllAsRead(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, n := range s.notifications {
+		if n.UserID == userID {
+			n.IsRead = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"kursach/internal/storage/postgres"
+)
+
+func (s *Store) CreatePost(ctx context.Context, p *postgres.Post) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[p.AuthorID]; !ok {
+		return errForeignKey
+	}
+
+	for i := range p.Attachments {
+		s.nextAttachmentID++
+		p.Attachments[i].ID = s.nextAttachmentID
+	}
+
+	s.nextPostID++
+	s.posts[s.nextPostID] = &post{
+		id:          s.nextPostID,
+		authorID:    p.AuthorID,
+		title:       p.Title,
+		description: p.Description,
+		imageURL:    p.ImageURL,
+		images:      copyImages(p.Images),
+		attachments: copyAttachments(p.Attachments),
+		createdAt:   p.CreatedAt,
+	}
+	p.ID = strconv.Itoa(s.nextPostID)
+	return nil
+}
+
+// copyImages повторяет JSONB-колонку: значение копируется, NULL становится {}.
+func copyImages(images postgres.Images) postgres.Images {
+	copied := make(postgres.Images, len(images))
+	for name, v := range images {
+		copied[name] = v
+	}
+	return copied
+}
+
+func copyAttachments(attachments []postgres.Attachment) []postgres.Attachment {
+	copied := make([]postgres.Attachment, len(attachments))
+	for i, a := range attachments {
+		a.Images = copyImages(a.Images)
+		copied[i] = a
+	}
+	return copied
+}
+
+func (s *Store) GetOrCreateTag(ctx context.Context, name string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tagID(name), nil
+}
+
+func (s *Store) tagID(name string) int {
+	for id, tag := range s.tags {
+		if tag == name {
+			return id
+		}
+	}
+	s.nextTagID++
+	s.tags[s.nextTagID] = name
+	return s.nextTagID
+}
+
+func (s *Store) AddPostTag(ctx context.Context, postID string, tagID int) error {
+	id, err := strconv.Atoi(postID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.posts[id]; !ok {
+		return errForeignKey
+	}
+	if _, ok := s.tags[tagID]; !ok {
+		return errForeignKey
+	}
+	for _, existing := range s.postTags[id] {
+		if existing == tagID {
+			return errCheck
+		}
+	}
+	s.postTags[id] = append(s.postTags[id], tagID)
+	return nil
+}
+
+func (s *Store) GetTagsByPostID(ctx context.Context, postID int) ([]postgres.TagBrief, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tagsOf(postID), nil
+}
+
+func (s *Store) tagsOf(postID int) []postgres.TagBrief {
+	var tags []postgres.TagBrief
+	for _, tagID := range s.postTags[postID] {
+		tags = append(tags, postgres.TagBrief{TagID: tagID, Name: s.tags[tagID]})
+	}
+	return tags
+}
+
+// summary повторяет строку view_post_summary вместе с комментариями и
+// тегами; комментарии — как их видит viewerID.
+func (s *Store) summary(p *post, viewerID int) postgres.PostResponse {
+	likeCount := 0
+	for key := range s.likes {
+		if key.b == p.id {
+			likeCount++
+		}
+	}
+	return postgres.PostResponse{
+		PostID:       p.id,
+		Title:        p.title,
+		Description:  p.description,
+		ImageURL:     p.imageURL,
+		Images:       copyImages(p.images),
+		Attachments:  copyAttachments(p.attachments),
+		CreatedAt:    p.createdAt,
+		EditedAt:     p.editedAt,
+		AuthorID:     p.authorID,
+		AuthorName:   s.users[p.authorID].userName,
+		LikeCount:    likeCount,
+		CommentCount: len(s.commentsOf(p.id)),
+		Comments:     s.commentPage(p.id, postgres.CommentsQuery{Limit: postgres.FeedCommentPreview, ViewerID: viewerID}),
+		Tags:         s.tagsOf(p.id),
+	}
+}
+
+// page сортирует видимые посты по убыванию даты и возвращает страницу
+// после q.After (или со смещением q.Offset) и курсор следующей.
+func (s *Store) page(posts []*post, q postgres.PostsQuery) ([]postgres.PostResponse, *postgres.PostCursor) {
+	visible := posts[:0]
+	for _, p := range posts {
+		if !s.blockedBetween(q.ViewerID, p.authorID) {
+			visible = append(visible, p)
+		}
+	}
+	posts = visible
+
+	sort.Slice(posts, func(i, j int) bool { return newerPost(posts[i], posts[j]) })
+
+	if q.After != nil {
+		// (created_at, id) < курсора
+		after := *q.After
+		start := sort.Search(len(posts), func(i int) bool {
+			p := posts[i]
+			return p.createdAt.Before(after.CreatedAt) || (p.createdAt.Equal(after.CreatedAt) && p.id < after.ID)
+		})
+		posts = posts[start:]
+	} else {
+		posts = posts[min(q.Offset, len(posts)):]
+	}
+
+	page := []postgres.PostResponse{}
+	for i := 0; i < len(posts) && i < q.Limit; i++ {
+		page = append(page, s.summary(posts[i], q.ViewerID))
+	}
+	if len(posts) <= q.Limit {
+		return page, nil
+	}
+	last := page[len(page)-1]
+	return page, &postgres.PostCursor{CreatedAt: last.CreatedAt, ID: last.PostID}
+}
+
+func (s *Store) GetPosts(ctx context.Context, q postgres.PostsQuery) ([]postgres.PostResponse, *postgres.PostCursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []*post
+	for _, p := range s.posts {
+		if p.hidden || (q.AuthorID != nil && p.authorID != *q.AuthorID) {
+			continue
+		}
+		posts = append(posts, p)
+	}
+	page, next := s.page(posts, q)
+	return page, next, nil
+}
+
+func (s *Store) GetFavoritePosts(ctx context.Context, userID int, q postgres.PostsQuery) ([]postgres.PostResponse, *postgres.PostCursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []*post
+	for key := range s.favorites {
+		if key.a != userID {
+			continue
+		}
+		if p, ok := s.posts[key.b]; ok && !p.hidden {
+			posts = append(posts, p)
+		}
+	}
+	page, next := s.page(posts, q)
+	return page, next, nil
+}
+
+func (s *Store) GetPostAuthorID(ctx context.Context, postID int) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.posts[postID]
+	if !ok {
+		return 0, postgres.ErrPostNotFound
+	}
+	return p.authorID, nil
+}
+
+func (s *Store) GetVisiblePostAuthorID(ctx context.Context, postID int) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.posts[postID]
+	if !ok || p.hidden {
+		return 0, postgres.ErrPostNotFound
+	}
+	return p.authorID, nil
+}
+
+// DeletePost удаляет пост вместе со всем, что ссылается на него (ON DELETE CASCADE),
+// и возвращает ключи его файлов.
+func (s *Store) DeletePost(ctx context.Context, postID int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[postID]
+	if !ok {
+		return nil, postgres.ErrPostNotFound
+	}
+	keys := postMediaKeys(p)
+	s.deletePost(postID)
+	return keys, nil
+}
+
+// postMediaKeys — ключи файлов поста, его вложений и ревизий.
+func postMediaKeys(p *post) []string {
+	attachments := copyAttachments(p.attachments)
+	for _, r := range p.revisions {
+		attachments = append(attachments, r.Attachments...)
+	}
+	cover := postgres.Attachment{URL: p.imageURL, Images: p.images}
+	return postgres.MediaKeys(append(attachments, cover))
+}
+
+func (s *Store) deletePost(postID int) {
+	delete(s.posts, postID)
+	delete(s.postTags, postID)
+	for id, c := range s.comments {
+		if c.PostID == postID {
+			s.deleteComment(id)
+		}
+	}
+	for key := range s.likes {
+		if key.b == postID {
+			delete(s.likes, key)
+		}
+	}
+	for key := range s.favorites {
+		if key.b == postID {
+			delete(s.favorites, key)
+		}
+	}
+	for key := range s.timeline {
+		if key.b == postID {
+			delete(s.timeline, key)
+		}
+	}
+}
+
+func (s *Store) GetAllTags(ctx context.Context) ([]map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]int, 0, len(s.tags))
+	for id := range s.tags {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	var tags []map[string]interface{}
+	for _, id := range ids {
+		tags = append(tags, map[string]interface{}{
+			"tag_id": id,
+			"name":   s.tags[id],
+		})
+	}
+	return tags, nil
+}
+
+// newerPost — порядок лент: (created_at, id) по убыванию.
+func newerPost(a, b *post) bool {
+	if !a.createdAt.Equal(b.createdAt) {
+		return a.createdAt.After(b.createdAt)
+	}
+	return a.id > b.id
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"kursach/internal/storage/postgres"
+)
+
+func (s *Store) GetPostSignals(ctx context.Context, userID int, since, until time.Time, limit int) ([]postgres.PostSignals, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// engaged — сколько раз пользователь взаимодействовал с постами каждого тега
+	engaged := make(map[int]int)
+	engage := func(postID int) {
+		for _, tagID := range s.postTags[postID] {
+			engaged[tagID]++
+		}
+	}
+	for key := range s.likes {
+		if key.a == userID {
+			engage(key.b)
+		}
+	}
+	for key := range s.favorites {
+		if key.a == userID {
+			engage(key.b)
+		}
+	}
+	for _, c := range s.comments {
+		if c.AuthorID == userID && !c.hidden {
+			engage(c.PostID)
+		}
+	}
+
+	var posts []*post
+	for _, p := range s.posts {
+		if p.hidden || p.authorID == userID || s.blockedBetween(userID, p.authorID) {
+			continue
+		}
+		if !p.createdAt.After(since) || p.createdAt.After(until) {
+			continue
+		}
+		posts = append(posts, p)
+	}
+	page, _ := s.page(posts, postgres.PostsQuery{Limit: limit, ViewerID: userID})
+
+	candidates := make([]postgres.PostSignals, 0, len(page))
+	for _, p := range page {
+		c := postgres.PostSignals{
+			PostID:    p.PostID,
+			AuthorID:  p.AuthorID,
+			CreatedAt: p.CreatedAt,
+			Likes:     p.LikeCount,
+			Comments:  p.CommentCount,
+		}
+		_, c.FollowsAuthor = s.follows[pair{userID, p.AuthorID}]
+		for key := range s.favorites {
+			if key.b == p.PostID {
+				c.Favorites++
+			}
+		}
+		for key := range s.likes {
+			if _, following := s.follows[pair{userID, key.a}]; following && key.b == p.PostID {
+				c.FolloweeLikes++
+			}
+		}
+		for _, tagID := range s.postTags[p.PostID] {
+			c.TagAffinity += engaged[tagID]
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+func (s *Store) GetPostsByIDs(ctx context.Context, viewerID int, ids []int) ([]postgres.PostResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := make([]postgres.PostResponse, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := s.posts[id]; ok && !p.hidden {
+			posts = append(posts, s.summary(p, viewerID))
+		}
+	}
+	return posts, nil
+}
@@ -0,0 +1,355 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"kursach/internal/storage/postgres"
+)
+
+func (s *Store) GetReportReasons(ctx context.Context) ([]postgres.ReportReason, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var reasons []postgres.ReportReason
+	for _, reason := range s.reasons {
+		if reason.active {
+			reasons = append(reasons, reason.ReportReason)
+		}
+	}
+	return reasons, nil
+}
+
+func (s *Store) reasonActive(code string) bool {
+	for _, reason := range s.reasons {
+		if reason.Code == code && reason.active {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) CreateReport(ctx context.Context, report *postgres.Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.reasonActive(report.Reason) {
+		return postgres.ErrUnknownReason
+	}
+	if err := s.reportTargetExists(report.ReportTypeID, report.TargetID); err != nil {
+		return err
+	}
+	for _, e := range report.Evidence {
+		if err := s.reportTargetExists(e.ReportTypeID, e.TargetID); err != nil {
+			return err
+		}
+	}
+	for _, existing := range s.reports {
+		if existing.ReporterID == report.ReporterID &&
+			existing.ReportTypeID == report.ReportTypeID &&
+			existing.TargetID == report.TargetID {
+			return postgres.ErrReportExists
+		}
+	}
+
+	s.nextReportID++
+	report.ID = s.nextReportID
+	report.Status = postgres.ReportStatusOpen
+	report.CreatedAt = s.now()
+
+	stored := *report
+	stored.Content = nil
+	stored.Evidence = nil
+	seen := make(map[postgres.ReportEvidence]bool)
+	for _, e := range report.Evidence {
+		if !seen[e] {
+			seen[e] = true
+			stored.Evidence = append(stored.Evidence, e)
+		}
+	}
+	s.reports[stored.ID] = &stored
+	return nil
+}
+
+// reportTargetExists повторяет lockReportTarget.
+func (s *Store) reportTargetExists(reportTypeID, targetID int) error {
+	switch reportTypeID {
+	case postgres.ReportTypePost:
+		if _, ok := s.posts[targetID]; !ok {
+			return postgres.ErrPostNotFound
+		}
+	case postgres.ReportTypeComment:
+		if _, ok := s.comments[targetID]; !ok {
+			return postgres.ErrCommentNotFound
+		}
+	case postgres.ReportTypeUser:
+		if _, ok := s.users[targetID]; !ok {
+			return postgres.ErrUserNotFound
+		}
+	default:
+		return fmt.Errorf("unsupported report type %d", reportTypeID)
+	}
+	return nil
+}
+
+// withContent повторяет reportSelect: к жалобе прикладывается текущее
+// содержимое объекта и отсортированные доказательства.
+func (s *Store) withContent(r *postgres.Report) postgres.Report {
+	report := *r
+	report.Evidence = append([]postgres.ReportEvidence{}, r.Evidence...)
+	sort.Slice(report.Evidence, func(i, j int) bool {
+		if report.Evidence[i].ReportTypeID != report.Evidence[j].ReportTypeID {
+			return report.Evidence[i].ReportTypeID < report.Evidence[j].ReportTypeID
+		}
+		return report.Evidence[i].TargetID < report.Evidence[j].TargetID
+	})
+
+	switch report.ReportTypeID {
+	case postgres.ReportTypePost:
+		if p, ok := s.posts[report.TargetID]; ok {
+			report.Content = &postgres.ReportedContent{
+				AuthorID:    p.authorID,
+				Title:       p.title,
+				Description: p.description,
+				ImageURL:    p.imageURL,
+				Hidden:      p.hidden,
+			}
+		}
+	case postgres.ReportTypeComment:
+		if c, ok := s.comments[report.TargetID]; ok {
+			report.Content = &postgres.ReportedContent{
+				AuthorID: c.AuthorID,
+				Comment:  c.Text,
+				Hidden:   c.hidden,
+			}
+		}
+	case postgres.ReportTypeUser:
+		if u, ok := s.users[report.TargetID]; ok {
+			report.Content = &postgres.ReportedContent{
+				AuthorID: u.id,
+				UserName: u.userName,
+				UserTag:  u.userTag,
+			}
+		}
+	}
+	return report
+}
+
+func (s *Store) GetReports(ctx context.Context, status string, limit, offset int) ([]postgres.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var matching []*postgres.Report
+	for _, r := range s.reports {
+		if r.Status == status {
+			matching = append(matching, r)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		if !matching[i].CreatedAt.Equal(matching[j].CreatedAt) {
+			return matching[i].CreatedAt.Before(matching[j].CreatedAt)
+		}
+		return matching[i].ID < matching[j].ID
+	})
+
+	var reports []postgres.Report
+	for i := offset; i < len(matching) && i < offset+limit; i++ {
+		reports = append(reports, s.withContent(matching[i]))
+	}
+	return reports, nil
+}
+
+func (s *Store) GetReport(ctx context.Context, reportID int) (*postgres.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.reports[reportID]
+	if !ok {
+		return nil, postgres.ErrReportNotFound
+	}
+	report := s.withContent(r)
+	return &report, nil
+}
+
+func (s *Store) openReport(reportID int) (*postgres.Report, error) {
+	r, ok := s.reports[reportID]
+	if !ok {
+		return nil, postgres.ErrReportNotFound
+	}
+	if r.Status != postgres.ReportStatusOpen {
+		return nil, postgres.ErrReportClosed
+	}
+	return r, nil
+}
+
+func (s *Store) AssignReport(ctx context.Context, reportID, moderatorID, assigneeID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.openReport(reportID)
+	if err != nil {
+		return err
+	}
+	if u, ok := s.users[assigneeID]; !ok || u.suspendedAt != nil || (u.role != "moderator" && u.role != "admin") {
+		return postgres.ErrAssignee
+	}
+	r.AssigneeID = &assigneeID
+	s.logModerationAction(reportID, moderatorID, postgres.ModerationAssign, fmt.Sprintf("assigned to %d", assigneeID))
+	return nil
+}
+
+// ResolveReport повторяет транзакцию postgres: при ошибке действия жалоба
+// остаётся открытой, а содержимое не меняется.
+func (s *Store) ResolveReport(ctx context.Context, reportID, moderatorID int, action, note string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.openReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	status := postgres.ReportStatusResolved
+	switch action {
+	case postgres.ModerationDismiss:
+		status = postgres.ReportStatusDismissed
+	case postgres.ModerationHideContent:
+		if r.ReportTypeID == postgres.ReportTypeUser {
+			return nil, postgres.ErrActionTarget
+		}
+		s.setContentHidden(r.ReportTypeID, r.TargetID)
+	case postgres.ModerationDeleteContent:
+		if r.ReportTypeID == postgres.ReportTypeUser {
+			return nil, postgres.ErrActionTarget
+		}
+		keys, err = s.deleteContent(r.ReportTypeID, r.TargetID)
+	case postgres.ModerationSuspendAuthor:
+		err = s.suspendContentAuthor(r.ReportTypeID, r.TargetID)
+	default:
+		return nil, fmt.Errorf("unknown moderation action %q", action)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	r.Status = status
+	r.ResolvedAt = &now
+	if r.AssigneeID == nil {
+		r.AssigneeID = &moderatorID
+	}
+	s.logModerationAction(reportID, moderatorID, action, note)
+	return keys, nil
+}
+
+func (s *Store) GetModerationActions(ctx context.Context, reportID int) ([]postgres.ModerationAction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var actions []postgres.ModerationAction
+	for _, a := range s.actions {
+		if a.ReportID == reportID {
+			actions = append(actions, a)
+		}
+	}
+	return actions, nil
+}
+
+func (s *Store) logModerationAction(reportID, moderatorID int, action, note string) {
+	s.nextActionID++
+	s.actions = append(s.actions, postgres.ModerationAction{
+		ID:          s.nextActionID,
+		ReportID:    reportID,
+		ModeratorID: moderatorID,
+		Action:      action,
+		Note:        note,
+		CreatedAt:   s.now(),
+	})
+}
+
+func (s *Store) setContentHidden(reportTypeID, targetID int) {
+	switch reportTypeID {
+	case postgres.ReportTypePost:
+		if p, ok := s.posts[targetID]; ok {
+			p.hidden = true
+		}
+	case postgres.ReportTypeComment:
+		if c, ok := s.comments[targetID]; ok {
+			c.hidden = true
+		}
+	}
+}
+
+func (s *Store) deleteContent(reportTypeID, targetID int) ([]string, error) {
+	switch reportTypeID {
+	case postgres.ReportTypePost:
+		p, ok := s.posts[targetID]
+		if !ok {
+			return nil, postgres.ErrReportTarget
+		}
+		keys := postMediaKeys(p)
+		s.deletePost(targetID)
+		return keys, nil
+	case postgres.ReportTypeComment:
+		if _, ok := s.comments[targetID]; !ok {
+			return nil, postgres.ErrReportTarget
+		}
+		s.deleteComment(targetID)
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported report type %d", reportTypeID)
+}
+
+func (s *Store) suspendContentAuthor(reportTypeID, targetID int) error {
+	var authorID int
+	switch reportTypeID {
+	case postgres.ReportTypePost:
+		p, ok := s.posts[targetID]
+		if !ok {
+			return postgres.ErrReportTarget
+		}
+		authorID = p.authorID
+	case postgres.ReportTypeComment:
+		c, ok := s.comments[targetID]
+		if !ok {
+			return postgres.ErrReportTarget
+		}
+		authorID = c.AuthorID
+	case postgres.ReportTypeUser:
+		if _, ok := s.users[targetID]; !ok {
+			return postgres.ErrReportTarget
+		}
+		authorID = targetID
+	default:
+		return fmt.Errorf("unsupported report type %d", reportTypeID)
+	}
+
+	now := s.now()
+	s.users[authorID].suspendedAt = &now
+	s.revokeAllSessions(authorID)
+	return nil
+}
+
+func (s *Store) GetReportsByReporter(ctx context.Context, reporterID int) ([]postgres.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var reports []postgres.Report
+	for _, r := range s.reports {
+		if r.ReporterID == reporterID {
+			report := *r
+			report.AssigneeID = nil
+			report.Evidence = nil
+			reports = append(reports, report)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if !reports[i].CreatedAt.Equal(reports[j].CreatedAt) {
+			return reports[i].CreatedAt.After(reports[j].CreatedAt)
+		}
+		return reports[i].ID > reports[j].ID
+	})
+	return reports, nil
+}
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"kursach/internal/storage/postgres"
+)
+
+func (s *Store) GetPost(ctx context.Context, postID int) (*postgres.PostResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.posts[postID]
+	if !ok || p.hidden {
+		return nil, postgres.ErrPostNotFound
+	}
+	post := s.summary(p, 0)
+	return &post, nil
+}
+
+// UpdatePost повторяет postgres.UpdatePost: прежнее состояние поста
+// сохраняется в истории правок.
+func (s *Store) UpdatePost(ctx context.Context, postID, editorID int, update postgres.PostUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[postID]
+	if !ok || p.hidden {
+		return postgres.ErrPostNotFound
+	}
+	if _, ok := s.users[editorID]; !ok {
+		return errForeignKey
+	}
+
+	var attachments []postgres.Attachment
+	if update.Attachments != nil {
+		var err error
+		if attachments, err = s.mergeAttachments(p, update.Attachments); err != nil {
+			return err
+		}
+	}
+
+	now := s.now()
+	tags := []string{}
+	for _, tag := range s.tagsOf(postID) {
+		tags = append(tags, tag.Name)
+	}
+	sort.Strings(tags)
+	s.nextRevisionID++
+	p.revisions = append(p.revisions, postgres.PostRevision{
+		RevisionID:  s.nextRevisionID,
+		PostID:      postID,
+		EditorID:    editorID,
+		Title:       p.title,
+		Description: p.description,
+		Tags:        tags,
+		Attachments: copyAttachments(p.attachments),
+		CreatedAt:   now,
+	})
+
+	if update.Title != nil {
+		p.title = *update.Title
+	}
+	if update.Description != nil {
+		p.description = *update.Description
+	}
+	p.editedAt = &now
+
+	if update.Tags != nil {
+		s.postTags[postID] = nil
+		for _, name := range update.Tags {
+			tagID := s.tagID(name)
+			if !slices.Contains(s.postTags[postID], tagID) {
+				s.postTags[postID] = append(s.postTags[postID], tagID)
+			}
+		}
+	}
+	if update.Attachments != nil {
+		p.attachments = attachments
+		imageURL, images := postgres.Cover(attachments)
+		p.imageURL, p.images = imageURL, copyImages(images)
+	}
+	return nil
+}
+
+// mergeAttachments дополняет существующие вложения из next данными поста
+// и выдаёт ID новым; в next записываются итоговые значения.
+func (s *Store) mergeAttachments(p *post, next []postgres.Attachment) ([]postgres.Attachment, error) {
+	existing := make(map[int]postgres.Attachment, len(p.attachments))
+	for _, a := range p.attachments {
+		existing[a.ID] = a
+	}
+	kept := make(map[int]bool)
+	for i, a := range next {
+		if a.ID == 0 {
+			continue
+		}
+		old, ok := existing[a.ID]
+		if !ok || kept[a.ID] {
+			return nil, postgres.ErrAttachmentNotFound
+		}
+		kept[a.ID] = true
+		old.AltText = a.AltText
+		next[i] = old
+	}
+	for i := range next {
+		if next[i].ID == 0 {
+			s.nextAttachmentID++
+			next[i].ID = s.nextAttachmentID
+		}
+	}
+	return copyAttachments(next), nil
+}
+
+func (s *Store) GetPostRevisions(ctx context.Context, postID int) ([]postgres.PostRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := []postgres.PostRevision{}
+	p, ok := s.posts[postID]
+	if !ok {
+		return revisions, nil
+	}
+	for i := len(p.revisions) - 1; i >= 0; i-- {
+		r := p.revisions[i]
+		r.Tags = append([]string{}, r.Tags...)
+		r.Attachments = copyAttachments(r.Attachments)
+		revisions = append(revisions, r)
+	}
+	return revisions, nil
+}
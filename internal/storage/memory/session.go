@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"kursach/internal/storage/postgres"
+)
+
+func (s *Store) sessionActive(sess *session) bool {
+	return sess.revokedAt == nil && sess.ExpiresAt.After(s.now())
+}
+
+func (s *Store) CreateSession(ctx context.Context, sess *postgres.Session, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	sess.CreatedAt = now
+	sess.LastUsedAt = now
+	s.sessions[sess.SessionID] = &session{Session: *sess, token: tokenHash}
+	return nil
+}
+
+func (s *Store) RotateSession(ctx context.Context, oldHash, newHash, ip string, expiresAt time.Time) (*postgres.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sess := range s.sessions {
+		if sess.token == oldHash && s.sessionActive(sess) {
+			sess.previousToken = sess.token
+			sess.token = newHash
+			sess.IP = ip
+			sess.ExpiresAt = expiresAt
+			sess.LastUsedAt = s.now()
+			result := sess.Session
+			return &result, nil
+		}
+	}
+
+	// повторное использование уже обменянного токена отзывает сессию
+	for _, sess := range s.sessions {
+		if sess.previousToken == oldHash && sess.revokedAt == nil {
+			now := s.now()
+			sess.revokedAt = &now
+		}
+	}
+	return nil, postgres.ErrSessionNotFound
+}
+
+func (s *Store) TouchSession(ctx context.Context, sessionID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[sessionID]
+	if !ok || !s.sessionActive(sess) {
+		return false, nil
+	}
+	sess.LastUsedAt = s.now()
+	return true, nil
+}
+
+func (s *Store) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[sessionID]
+	if !ok || sess.UserID != userID || sess.revokedAt != nil {
+		return postgres.ErrSessionNotFound
+	}
+	now := s.now()
+	sess.revokedAt = &now
+	return nil
+}
+
+func (s *Store) RevokeAllSessions(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revokeAllSessions(userID)
+	return nil
+}
+
+func (s *Store) revokeAllSessions(userID int) {
+	now := s.now()
+	for _, sess := range s.sessions {
+		if sess.UserID == userID && sess.revokedAt == nil {
+			sess.revokedAt = &now
+		}
+	}
+}
+
+func (s *Store) GetActiveSessions(ctx context.Context, userID int) ([]postgres.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sessions []postgres.Session
+	for _, sess := range s.sessions {
+		if sess.UserID == userID && s.sessionActive(sess) {
+			sessions = append(sessions, sess.Session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
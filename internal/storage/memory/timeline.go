@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"kursach/internal/storage/postgres"
+)
+
+func (s *Store) GetTimeline(ctx context.Context, userID int, q postgres.PostsQuery) ([]postgres.PostResponse, *postgres.PostCursor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []*post
+	for _, p := range s.posts {
+		if p.hidden || s.blockedBetween(userID, p.authorID) {
+			continue
+		}
+		_, following := s.follows[pair{userID, p.authorID}]
+		if s.timeline[pair{userID, p.id}] || p.authorID == userID || (following && !p.fannedOut) {
+			posts = append(posts, p)
+		}
+	}
+	q.ViewerID = userID
+	page, next := s.page(posts, q)
+	return page, next, nil
+}
+
+// FanOutPost повторяет раскладку поста по лентам подписчиков автора.
+func (s *Store) FanOutPost(ctx context.Context, postID string, maxFollowers int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := strconv.Atoi(postID)
+	if err != nil {
+		return false, err
+	}
+	p, ok := s.posts[id]
+	if !ok || p.fannedOut {
+		return false, nil
+	}
+
+	var followers []int
+	for key := range s.follows {
+		if key.b == p.authorID {
+			followers = append(followers, key.a)
+		}
+	}
+	if len(followers) > maxFollowers {
+		p.fanoutSkipped = true
+		return false, nil
+	}
+	for _, followerID := range followers {
+		if !s.blockedBetween(followerID, p.authorID) {
+			s.timeline[pair{followerID, p.id}] = true
+		}
+	}
+	p.fannedOut = true
+	return true, nil
+}
+
+// BackfillTimeline раскладывает неразложенные посты, кроме помеченных fanoutSkipped.
+func (s *Store) BackfillTimeline(ctx context.Context, maxFollowers int) (int, error) {
+	s.mu.RLock()
+	var ids []int
+	for _, p := range s.posts {
+		if !p.fannedOut && !p.fanoutSkipped {
+			ids = append(ids, p.id)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Ints(ids)
+
+	fannedOut := 0
+	for _, id := range ids {
+		ok, err := s.FanOutPost(ctx, strconv.Itoa(id), maxFollowers)
+		if err != nil {
+			return fannedOut, err
+		}
+		if ok {
+			fannedOut++
+		}
+	}
+	return fannedOut, nil
+}
+
+// backfillTimeline добавляет в ленту пользователя последние
+// postgres.FollowBackfillLimit разложенных постов автора, как процедура follow.
+func (s *Store) backfillTimeline(userID, authorID int) {
+	var posts []*post
+	for _, p := range s.posts {
+		if p.authorID == authorID && p.fannedOut {
+			posts = append(posts, p)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return newerPost(posts[i], posts[j]) })
+	if len(posts) > postgres.FollowBackfillLimit {
+		posts = posts[:postgres.FollowBackfillLimit]
+	}
+	for _, p := range posts {
+		s.timeline[pair{userID, p.id}] = true
+	}
+}
+
+// pruneTimeline убирает из ленты пользователя посты автора.
+func (s *Store) pruneTimeline(userID, authorID int) {
+	for key := range s.timeline {
+		if key.a == userID && s.posts[key.b].authorID == authorID {
+			delete(s.timeline, key)
+		}
+	}
+}
+
+// blockedBetween сообщает, заблокировал ли кто-то из двух пользователей другого.
+func (s *Store) blockedBetween(a, b int) bool {
+	_, ab := s.blocks[pair{a, b}]
+	_, ba := s.blocks[pair{b, a}]
+	return ab || ba
+}
@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"kursach/internal/storage/postgres"
+)
+
+func (s *Store) userByTag(tag string) *user {
+	for _, u := range s.users {
+		if u.userTag == tag {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Store) userByEmail(email string) *user {
+	for _, u := range s.users {
+		if u.email == email {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Store) IsUserTagTaken(ctx context.Context, tag string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.userByTag(tag) != nil, nil
+}
+
+func (s *Store) IsEmailTaken(ctx context.Context, email string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.userByEmail(email) != nil, nil
+}
+
+// CreateFullUser повторяет процедуру create_full_user.
+func (s *Store) CreateFullUser(ctx context.Context, email, password, name, tag string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userByEmail(email) != nil || s.userByTag(tag) != nil {
+		return fmt.Errorf("creating user: %w", errors.New("duplicate key value violates unique constraint"))
+	}
+
+	s.nextUserID++
+	s.users[s.nextUserID] = &user{
+		id:        s.nextUserID,
+		email:     email,
+		password:  password,
+		role:      "user",
+		createdAt: s.now(),
+		userName:  name,
+		userTag:   tag,
+		theme:     "light",
+		language:  "en",
+	}
+	return nil
+}
+
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (int, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u := s.userByEmail(email)
+	if u == nil {
+		return 0, "", fmt.Errorf("user not found")
+	}
+	return u.id, u.password, nil
+}
+
+func userInfo(u *user) map[string]interface{} {
+	info := map[string]interface{}{
+		"user_id":     u.id,
+		"user_name":   u.userName,
+		"user_tag":    u.userTag,
+		"theme":       u.theme,
+		"language":    u.language,
+		"avatar_url":  "",
+		"images":      copyImages(u.avatarImages),
+		"description": "",
+	}
+	if u.avatarURL != nil {
+		info["avatar_url"] = *u.avatarURL
+	}
+	if u.description != nil {
+		info["description"] = *u.description
+	}
+	return info
+}
+
+func (s *Store) GetUserInfo(ctx context.Context, userID int) (map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return nil, fmt.Errorf("user not found: %w", errNoRows)
+	}
+	return userInfo(u), nil
+}
+
+func (s *Store) GetUserInfoByTag(ctx context.Context, userTag string) (map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u := s.userByTag(userTag)
+	if u == nil {
+		return nil, fmt.Errorf("user not found: %w", errNoRows)
+	}
+	return userInfo(u), nil
+}
+
+// UpdateUser обновляет колонки user_info; неизвестная колонка — ошибка, как в SQL.
+func (s *Store) UpdateUser(ctx context.Context, userID int, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return nil
+	}
+	updated := *u
+	for key, value := range updates {
+		str := fmt.Sprint(value)
+		switch key {
+		case "user_name":
+			updated.userName = str
+		case "user_tag":
+			if other := s.userByTag(str); other != nil && other.id != userID {
+				return errors.New("duplicate key value violates unique constraint")
+			}
+			updated.userTag = str
+		case "theme":
+			updated.theme = str
+		case "language":
+			updated.language = str
+		case "avatar_url":
+			updated.avatarURL = &str
+		case "avatar_images":
+			images, ok := value.(postgres.Images)
+			if !ok {
+				return fmt.Errorf("invalid input syntax for type json: %v", value)
+			}
+			updated.avatarImages = copyImages(images)
+		case "description":
+			updated.description = &str
+		default:
+			return fmt.Errorf("column %q of relation \"user_info\" does not exist", key)
+		}
+	}
+	*u = updated
+	return nil
+}
+
+func (s *Store) GetUserRole(ctx context.Context, userID int) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return "", postgres.ErrUserNotFound
+	}
+	return u.role, nil
+}
+
+func (s *Store) SetUserRole(ctx context.Context, userID int, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return postgres.ErrUserNotFound
+	}
+	u.role = role
+	return nil
+}
+
+func (s *Store) IsUserSuspended(ctx context.Context, userID int) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return false, postgres.ErrUserNotFound
+	}
+	return u.suspendedAt != nil, nil
+}
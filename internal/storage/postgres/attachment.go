@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// AttachmentKindImage — вид вложения-картинки (post_attachments.kind).
+const AttachmentKindImage = "image"
+
+// Attachment — вложение поста. В базе URL хранит ключ медиахранилища,
+// абсолютной ссылкой он становится в обработчике.
+type Attachment struct {
+	ID          int    `json:"attachment_id"`
+	Kind        string `json:"kind"` // image или video
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	AltText     string `json:"alt_text"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	Images      Images `json:"images"`
+}
+
+// MediaKeys возвращает все ключи медиахранилища, на которые ссылается вложение.
+func (a Attachment) MediaKeys() []string {
+	keys := []string{a.URL}
+	for _, v := range a.Images {
+		keys = append(keys, v.URL)
+	}
+	return keys
+}
+
+// Cover возвращает обложку поста — первую картинку среди вложений.
+func Cover(attachments []Attachment) (string, Images) {
+	for _, a := range attachments {
+		if a.Kind == AttachmentKindImage {
+			return a.URL, a.Images
+		}
+	}
+	return "", Images{}
+}
+
+func insertAttachments(ctx context.Context, tx *sql.Tx, postID string, attachments []Attachment) error {
+	for i := range attachments {
+		if err := insertAttachment(ctx, tx, postID, i, &attachments[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertAttachment(ctx context.Context, tx *sql.Tx, postID string, position int, a *Attachment) error {
+	const query = `
+		INSERT INTO post_attachments (post_id, position, kind, media_key, content_type, alt_text, width, height, images)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0), NULLIF($8, 0), $9)
+		RETURNING attachment_id
+	`
+	return tx.QueryRowContext(ctx, query,
+		postID, position, a.Kind, a.URL, a.ContentType, a.AltText, a.Width, a.Height, a.Images,
+	).Scan(&a.ID)
+}
+
+func (s *PostStorage) GetAttachmentsByPostID(ctx context.Context, postID int) ([]Attachment, error) {
+	return getAttachments(ctx, s.db, postID)
+}
+
+func getAttachments(ctx context.Context, q interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}, postID int) ([]Attachment, error) {
+	const query = `
+		SELECT attachment_id, kind, media_key, content_type, alt_text,
+		       COALESCE(width, 0), COALESCE(height, 0), images
+		FROM post_attachments
+		WHERE post_id = $1
+		ORDER BY position
+	`
+	rows, err := q.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []Attachment{}
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(&a.ID, &a.Kind, &a.URL, &a.ContentType, &a.AltText, &a.Width, &a.Height, &a.Images); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
@@ -1,14 +1,75 @@
 package postgres
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
 
+	"github.com/lib/pq"
+)
+
+var ErrBlocked = errors.New("user is blocked")
+
+// blockedErrCode — SQLSTATE, с которым процедуры отклоняют действие между
+// пользователями, один из которых заблокировал другого.
+const blockedErrCode = "UB001"
+
+// postNotFoundErrCode — SQLSTATE, с которым процедуры отклоняют лайк или
+// комментарий к несуществующему или скрытому посту.
+const postNotFoundErrCode = "UP404"
+
+// foreignKeyViolation — SQLSTATE нарушения внешнего ключа.
+const foreignKeyViolation = "23503"
+
+// procedureError заменяет ошибки процедур о блокировке и о недоступном
+// посте на ErrBlocked и ErrPostNotFound.
+func procedureError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case blockedErrCode:
+			return ErrBlocked
+		case postNotFoundErrCode:
+			return ErrPostNotFound
+		}
+	}
+	return err
+}
+
+// BlockedUser — пользователь из списка заблокированных.
+type BlockedUser struct {
+	UserID    int       `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	UserTag   string    `json:"user_tag"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// AddUserBlock блокирует пользователя, удаляет подписки между двумя
+// пользователями в обе стороны и посты каждого из ленты другого. Если
+// кого-то из пользователей нет, возвращает ErrUserNotFound.
 func (s *PostStorage) AddUserBlock(ctx context.Context, blockerID, blockedID int) error {
 	const query = `
-        INSERT INTO user_blocks (blocker_id, blocked_id)
-        VALUES ($1, $2)
-        ON CONFLICT DO NOTHING
+        WITH blocked AS (
+            INSERT INTO user_blocks (blocker_id, blocked_id)
+            VALUES ($1, $2)
+            ON CONFLICT DO NOTHING
+            RETURNING blocker_id, blocked_id
+        ), unfollowed AS (
+            DELETE FROM follows f
+            USING blocked b
+            WHERE (f.follower_id = b.blocker_id AND f.following_id = b.blocked_id)
+               OR (f.follower_id = b.blocked_id AND f.following_id = b.blocker_id)
+        )
+        DELETE FROM timeline_entries te
+        USING blocked b
+        WHERE (te.user_id = b.blocker_id AND te.author_id = b.blocked_id)
+           OR (te.user_id = b.blocked_id AND te.author_id = b.blocker_id)
     `
 	_, err := s.db.ExecContext(ctx, query, blockerID, blockedID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return ErrUserNotFound
+	}
 	return err
 }
 
@@ -22,6 +83,41 @@ func (s *PostStorage) IsUserBlocked(ctx context.Context, blockerID, blockedID in
 	err := s.db.QueryRowContext(ctx, query, blockerID, blockedID).Scan(&exists)
 	return exists, err
 }
+
+// IsBlockedBetween сообщает, заблокировал ли кто-то из двух пользователей другого.
+func (s *PostStorage) IsBlockedBetween(ctx context.Context, userID, otherID int) (bool, error) {
+	var blocked bool
+	err := s.db.QueryRowContext(ctx, `SELECT is_blocked_between($1, $2)`, userID, otherID).Scan(&blocked)
+	return blocked, err
+}
+
+// GetBlockedUsers возвращает тех, кого заблокировал пользователь, начиная
+// с последних.
+func (s *PostStorage) GetBlockedUsers(ctx context.Context, blockerID int) ([]BlockedUser, error) {
+	const query = `
+		SELECT ub.blocked_id, ui.user_name, ui.user_tag, ub.created_at
+		FROM user_blocks ub
+		JOIN user_info ui ON ui.user_id = ub.blocked_id
+		WHERE ub.blocker_id = $1
+		ORDER BY ub.created_at DESC, ub.blocked_id DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []BlockedUser{}
+	for rows.Next() {
+		var u BlockedUser
+		if err := rows.Scan(&u.UserID, &u.UserName, &u.UserTag, &u.BlockedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
 func (s *PostStorage) RemoveBlock(ctx context.Context, blockerID, blockedID int) error {
 	const query = `
 		DELETE FROM user_blocks
@@ -2,39 +2,151 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 )
 
+// MaxCommentDepth — наибольшая вложенность ответов (0 — комментарий к посту).
+const MaxCommentDepth = 5
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrParentComment   = errors.New("parent comment not found in this post")
+	ErrCommentTooDeep  = errors.New("comment reply depth limit reached")
+)
+
 type Comment struct {
-	ID            int       `json:"comment_id"`
-	AuthorID      int       `json:"author_id"`
-	AuthorUserTag string    `json:"author_user_tag,omitempty"`
-	PostID        int       `json:"post_id"`
-	Text          string    `json:"comment"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID            int        `json:"comment_id"`
+	AuthorID      int        `json:"author_id"`
+	AuthorUserTag string     `json:"author_user_tag,omitempty"`
+	PostID        int        `json:"post_id"`
+	ParentID      *int       `json:"parent_id"`
+	Text          string     `json:"comment"`
+	CreatedAt     time.Time  `json:"created_at"`
+	EditedAt      *time.Time `json:"edited_at"`
+}
+
+// Порядок комментариев в плоском списке
+const (
+	CommentsOldest = "oldest"
+	CommentsNewest = "newest"
+	CommentsTop    = "top" // больше лайков — выше
+)
+
+// CommentsQuery задаёт выборку GetCommentsByPostID. При Tree возвращаются
+// все комментарии поста деревом (ответы — в Replies) по времени. Иначе —
+// плоский список одного уровня: ответы на ParentID или, если он nil,
+// комментарии к самому посту, в порядке Order, по Limit штук после After.
+// Комментарии авторов, с которыми у ViewerID есть блокировка, не
+// возвращаются (0 — анонимный читатель).
+type CommentsQuery struct {
+	Tree     bool
+	ParentID *int
+	Order    string
+	Limit    int
+	After    *CommentCursor
+	ViewerID int
+}
+
+// CommentCursor — позиция в плоском списке комментариев: последний
+// комментарий предыдущей страницы.
+type CommentCursor struct {
+	CreatedAt time.Time `json:"t"`
+	Likes     int       `json:"l,omitempty"`
+	ID        int       `json:"id"`
 }
 
+// AddComment добавляет комментарий или, если задан ParentID, ответ на
+// видимый комментарий того же поста. Если автор поста или комментария
+// заблокировал комментатора или заблокирован им, возвращает ErrBlocked;
+// к удалённому или скрытому посту — ErrPostNotFound.
 func (s *PostStorage) AddComment(ctx context.Context, comment *Comment) error {
-	const query = `SELECT create_comment($1, $2, $3)`
-	err := s.db.QueryRowContext(ctx, query, comment.AuthorID, comment.PostID, comment.Text).
-		Scan(&comment.ID)
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	if comment.ParentID != nil {
+		var (
+			postID int
+			depth  int
+		)
+		err := tx.QueryRowContext(ctx,
+			`SELECT post_id, depth FROM comments WHERE comment_id = $1 AND NOT is_hidden FOR SHARE`, *comment.ParentID,
+		).Scan(&postID, &depth)
+		if err == sql.ErrNoRows || (err == nil && postID != comment.PostID) {
+			return ErrParentComment
+		}
+		if err != nil {
+			return err
+		}
+		if depth+1 > MaxCommentDepth {
+			return ErrCommentTooDeep
+		}
+	}
+
+	const query = `SELECT create_comment($1, $2, $3, $4)`
+	err = tx.QueryRowContext(ctx, query, comment.AuthorID, comment.PostID, comment.Text, comment.ParentID).
+		Scan(&comment.ID)
+	if err != nil {
+		return procedureError(err)
+	}
 
 	// Получим CreatedAt по ID
 	const createdAtQuery = `SELECT created_at FROM comments WHERE comment_id = $1`
-	return s.db.QueryRowContext(ctx, createdAtQuery, comment.ID).
-		Scan(&comment.CreatedAt)
+	if err := tx.QueryRowContext(ctx, createdAtQuery, comment.ID).Scan(&comment.CreatedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateComment меняет текст видимого комментария и отмечает время правки.
+func (s *PostStorage) UpdateComment(ctx context.Context, commentID int, text string) (*Comment, error) {
+	const query = `
+		UPDATE comments
+		SET comment = $2, edited_at = now()
+		WHERE comment_id = $1 AND NOT is_hidden
+		RETURNING comment_id, author_id, post_id, parent_id, comment, created_at, edited_at
+	`
+	var c Comment
+	err := s.db.QueryRowContext(ctx, query, commentID, text).Scan(
+		&c.ID, &c.AuthorID, &c.PostID, &c.ParentID, &c.Text, &c.CreatedAt, &c.EditedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *PostStorage) GetCommentAuthorID(ctx context.Context, commentID int) (int, error) {
+	const query = `SELECT author_id FROM comments WHERE comment_id = $1`
+	var authorID int
+	err := s.db.QueryRowContext(ctx, query, commentID).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		return 0, ErrCommentNotFound
+	}
+	return authorID, err
+}
+
+func (s *PostStorage) DeleteComment(ctx context.Context, commentID int) error {
+	return deleteComment(ctx, s.db, commentID)
 }
 
-func (s *PostStorage) DeleteComment(ctx context.Context, commentID int, userID int) error {
+// deleteComment удаляет комментарий вместе с ответами (ON DELETE CASCADE).
+func deleteComment(ctx context.Context, db interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}, commentID int) error {
 	const query = `
 		DELETE FROM comments
-		WHERE comment_id = $1 AND author_id = $2
+		WHERE comment_id = $1
 	`
-	res, err := s.db.ExecContext(ctx, query, commentID, userID)
+	res, err := db.ExecContext(ctx, query, commentID)
 	if err != nil {
 		return err
 	}
@@ -43,33 +155,154 @@ func (s *PostStorage) DeleteComment(ctx context.Context, commentID int, userID i
 		return err
 	}
 	if rows == 0 {
-		return errors.New("no comment deleted")
+		return ErrCommentNotFound
 	}
 	return nil
 }
 
-func (s *PostStorage) GetCommentsByPostID(ctx context.Context, postID int) ([]CommentBrief, error) {
-	const query = `
-		SELECT comment_id, post_id, comment, comment_created_at, author_id, author_user_tag
+// GetCommentsByPostID возвращает комментарии поста деревом или страницей
+// плоского списка (см. CommentsQuery) и курсор следующей страницы (nil,
+// если она последняя).
+func (s *PostStorage) GetCommentsByPostID(ctx context.Context, postID int, q CommentsQuery) ([]CommentBrief, *CommentCursor, error) {
+	query := `
+		SELECT comment_id, post_id, parent_id, comment, comment_created_at, edited_at, author_id, author_user_tag,
+		       reply_count, like_count
 		FROM view_comment_with_author_tag
-		WHERE post_id = $1
-		ORDER BY comment_created_at ASC
+		WHERE post_id = $1 AND NOT is_blocked_between($2, author_id)
 	`
+	args := []any{postID, q.ViewerID}
+	if q.Tree {
+		query += ` ORDER BY comment_created_at ASC, comment_id ASC`
+	} else {
+		query += ` AND parent_id IS NOT DISTINCT FROM $3`
+		args = append(args, q.ParentID)
 
-	rows, err := s.db.QueryContext(ctx, query, postID)
+		var order string
+		switch q.Order {
+		case CommentsNewest:
+			order = `comment_created_at DESC, comment_id DESC`
+			if q.After != nil {
+				query += ` AND (comment_created_at, comment_id) < ($4, $5)`
+				args = append(args, q.After.CreatedAt, q.After.ID)
+			}
+		case CommentsTop:
+			order = `like_count DESC, comment_id DESC`
+			if q.After != nil {
+				query += ` AND (like_count, comment_id) < ($4, $5)`
+				args = append(args, q.After.Likes, q.After.ID)
+			}
+		default:
+			order = `comment_created_at ASC, comment_id ASC`
+			if q.After != nil {
+				query += ` AND (comment_created_at, comment_id) > ($4, $5)`
+				args = append(args, q.After.CreatedAt, q.After.ID)
+			}
+		}
+		// берём на одну строку больше, чтобы узнать, есть ли следующая страница
+		args = append(args, q.Limit+1)
+		query += fmt.Sprintf(` ORDER BY %s LIMIT $%d`, order, len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	var comments []CommentBrief
 	for rows.Next() {
 		var c CommentBrief
-		if err := rows.Scan(&c.CommentID, &c.PostID, &c.Comment, &c.CreatedAt, &c.AuthorID, &c.AuthorTag); err != nil {
-			return nil, err
+		if err := rows.Scan(
+			&c.CommentID, &c.PostID, &c.ParentID, &c.Comment, &c.CreatedAt, &c.EditedAt,
+			&c.AuthorID, &c.AuthorTag, &c.ReplyCount, &c.LikeCount,
+		); err != nil {
+			return nil, nil, err
 		}
 		comments = append(comments, c)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if q.Tree {
+		return CommentTree(comments), nil, nil
+	}
+	return pageComments(comments, q.Limit)
+}
 
-	return comments, nil
+// pageComments отрезает лишнюю строку и строит курсор следующей страницы.
+func pageComments(comments []CommentBrief, limit int) ([]CommentBrief, *CommentCursor, error) {
+	if len(comments) <= limit {
+		return comments, nil, nil
+	}
+	comments = comments[:limit]
+	last := comments[len(comments)-1]
+	return comments, &CommentCursor{CreatedAt: last.CreatedAt, Likes: last.LikeCount, ID: last.CommentID}, nil
+}
+
+// AddCommentLike ставит лайк комментарию; если между пользователем и
+// автором комментария есть блокировка, возвращает ErrBlocked.
+func (s *PostStorage) AddCommentLike(ctx context.Context, userID, commentID int) error {
+	const query = `
+		INSERT INTO comment_likes (user_id, comment_id)
+		SELECT $1, comment_id FROM comments
+		WHERE comment_id = $2 AND NOT is_hidden AND NOT is_blocked_between($1, author_id)
+		ON CONFLICT DO NOTHING
+	`
+	res, err := s.db.ExecContext(ctx, query, userID, commentID)
+	if err != nil {
+		return err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows > 0 {
+		return err
+	}
+	// ничего не вставлено: лайк уже есть, комментария нет или автор заблокирован
+	var blocked bool
+	err = s.db.QueryRowContext(ctx,
+		`SELECT is_blocked_between($1, author_id) FROM comments WHERE comment_id = $2 AND NOT is_hidden`, userID, commentID,
+	).Scan(&blocked)
+	if err == sql.ErrNoRows {
+		return ErrCommentNotFound
+	}
+	if err == nil && blocked {
+		return ErrBlocked
+	}
+	return err
+}
+
+func (s *PostStorage) RemoveCommentLike(ctx context.Context, userID, commentID int) error {
+	const query = `
+		DELETE FROM comment_likes
+		WHERE user_id = $1 AND comment_id = $2
+	`
+	_, err := s.db.ExecContext(ctx, query, userID, commentID)
+	return err
+}
+
+// CommentTree раскладывает упорядоченный по времени плоский список в
+// дерево. Ответы на комментарии, которых нет в списке (скрытые), отбрасываются.
+func CommentTree(comments []CommentBrief) []CommentBrief {
+	children := make(map[int][]CommentBrief)
+	present := make(map[int]bool, len(comments))
+	for _, c := range comments {
+		present[c.CommentID] = true
+	}
+	var roots []CommentBrief
+	for _, c := range comments {
+		switch {
+		case c.ParentID == nil:
+			roots = append(roots, c)
+		case present[*c.ParentID]:
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+
+	var attach func(list []CommentBrief) []CommentBrief
+	attach = func(list []CommentBrief) []CommentBrief {
+		for i := range list {
+			list[i].Replies = attach(children[list[i].CommentID])
+		}
+		return list
+	}
+	return attach(roots)
 }
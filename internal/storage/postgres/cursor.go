@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor упаковывает позицию в списке в непрозрачную для клиента строку.
+func EncodeCursor(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err) // курсоры — простые структуры, ошибка здесь означает баг
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor распаковывает строку, полученную от EncodeCursor.
+func DecodeCursor(s string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
@@ -2,17 +2,27 @@ package postgres
 
 import "context"
 
+// AddFollow подписывает пользователя; если между ними есть блокировка,
+// возвращает ErrBlocked.
 func (s *PostStorage) AddFollow(ctx context.Context, followerID, followingID int) error {
 	const query = `
 		CALL follow($1, $2)
 	`
 	_, err := s.db.ExecContext(ctx, query, followerID, followingID)
-	return err
+	return procedureError(err)
 }
+
+// RemoveFollow удаляет подписку вместе с постами автора в ленте подписчика.
 func (s *PostStorage) RemoveFollow(ctx context.Context, followerID, followingID int) error {
 	const query = `
-		DELETE FROM follows
-		WHERE follower_id = $1 AND following_id = $2
+		WITH removed AS (
+			DELETE FROM follows
+			WHERE follower_id = $1 AND following_id = $2
+			RETURNING follower_id, following_id
+		)
+		DELETE FROM timeline_entries te
+		USING removed r
+		WHERE te.user_id = r.follower_id AND te.author_id = r.following_id
 	`
 	_, err := s.db.ExecContext(ctx, query, followerID, followingID)
 	return err
@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// ImageVariant — уменьшенная копия картинки. В базе URL хранит ключ
+// медиахранилища, абсолютной ссылкой он становится в обработчике.
+type ImageVariant struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// Images — варианты картинки по названию (thumbnail, feed, full),
+// хранятся в JSONB-колонке.
+type Images map[string]ImageVariant
+
+func (i Images) Value() (driver.Value, error) {
+	if i == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(i)
+}
+
+func (i *Images) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*i = Images{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Images", src)
+	}
+	images := Images{}
+	if err := json.Unmarshal(data, &images); err != nil {
+		return err
+	}
+	*i = images
+	return nil
+}
@@ -2,10 +2,13 @@ package postgres
 
 import "context"
 
+// AddLike ставит лайк; если между пользователем и автором поста есть
+// блокировка, возвращает ErrBlocked, если пост удалён или скрыт —
+// ErrPostNotFound.
 func (s *PostStorage) AddLike(ctx context.Context, userID, postID int) error {
 	const query = `CALL like_post($1, $2)`
 	_, err := s.db.ExecContext(ctx, query, userID, postID)
-	return err
+	return procedureError(err)
 }
 
 func (s *PostStorage) RemoveLike(ctx context.Context, userID, postID int) error {
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// MediaRewrite — результат RewriteMediaURLs.
+type MediaRewrite struct {
+	Posts   int
+	Avatars int
+}
+
+// RewriteMediaURLs заменяет значения posts.image_url и user_info.avatar_url
+// на rewrite(значение) в одной транзакции. При dryRun изменения только
+// подсчитываются и откатываются.
+func (s *Storage) RewriteMediaURLs(ctx context.Context, rewrite func(string) string, dryRun bool) (MediaRewrite, error) {
+	const op = "storage.postgres.RewriteMediaURLs"
+
+	var result MediaRewrite
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	result.Posts, err = rewriteColumn(ctx, tx, rewrite,
+		`SELECT post_id, image_url FROM posts WHERE image_url <> '' FOR UPDATE`,
+		`UPDATE posts SET image_url = $2 WHERE post_id = $1`)
+	if err != nil {
+		return result, fmt.Errorf("%s: posts: %w", op, err)
+	}
+	result.Avatars, err = rewriteColumn(ctx, tx, rewrite,
+		`SELECT user_id, avatar_url FROM user_info WHERE avatar_url IS NOT NULL AND avatar_url <> '' FOR UPDATE`,
+		`UPDATE user_info SET avatar_url = $2 WHERE user_id = $1`)
+	if err != nil {
+		return result, fmt.Errorf("%s: user_info: %w", op, err)
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+	return result, nil
+}
+
+func rewriteColumn(ctx context.Context, tx *sql.Tx, rewrite func(string) string, selectQuery, updateQuery string) (int, error) {
+	type row struct {
+		id    int
+		value string
+	}
+
+	rows, err := tx.QueryContext(ctx, selectQuery)
+	if err != nil {
+		return 0, err
+	}
+	var changed []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.value); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if v := rewrite(r.value); v != r.value {
+			changed = append(changed, row{id: r.id, value: v})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// обновляем после чтения: одно соединение не может читать и писать одновременно
+	for _, r := range changed {
+		if _, err := tx.ExecContext(ctx, updateQuery, r.id, r.value); err != nil {
+			return 0, err
+		}
+	}
+	return len(changed), nil
+}
+
+// ReferencedMediaKeys возвращает все ключи медиахранилища, на которые
+// ссылаются посты, вложения (и их прежние версии) и аватары, включая
+// уменьшенные копии.
+func (s *Storage) ReferencedMediaKeys(ctx context.Context) (map[string]bool, error) {
+	const op = "storage.postgres.ReferencedMediaKeys"
+
+	const query = `
+		SELECT image_url FROM posts WHERE image_url <> ''
+		UNION
+		SELECT v->>'url' FROM posts, jsonb_each(images) AS e(k, v)
+		UNION
+		SELECT media_key FROM post_attachments
+		UNION
+		SELECT v->>'url' FROM post_attachments, jsonb_each(images) AS e(k, v)
+		UNION
+		SELECT a->>'url' FROM post_revisions, jsonb_array_elements(attachments) AS a
+		UNION
+		SELECT v->>'url'
+		FROM post_revisions, jsonb_array_elements(attachments) AS a,
+		     jsonb_each(CASE WHEN jsonb_typeof(a->'images') = 'object' THEN a->'images' ELSE '{}' END) AS e(k, v)
+		UNION
+		SELECT avatar_url FROM user_info WHERE avatar_url IS NOT NULL AND avatar_url <> ''
+		UNION
+		SELECT v->>'url' FROM user_info, jsonb_each(avatar_images) AS e(k, v)
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]bool)
+	for rows.Next() {
+		var key sql.NullString
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if key.Valid && key.String != "" {
+			keys[key.String] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return keys, nil
+}
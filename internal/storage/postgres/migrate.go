@@ -0,0 +1,298 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID — ключ advisory-блокировки, чтобы несколько
+// экземпляров сервера не применяли миграции одновременно.
+const migrationLockID = 727_001
+
+var migrationName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		m := migrationName.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file %q", entry.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := fs.ReadFile(migrationFiles, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.up = string(body)
+		} else {
+			migration.down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// withMigrationLock создаёт таблицу schema_migrations и выполняет fn
+// под advisory-блокировкой на отдельном соединении.
+func (s *Storage) withMigrationLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	const createTable = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT        NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`
+	if _, err := conn.ExecContext(ctx, createTable); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// MigrateUp применяет все ещё не применённые миграции и возвращает их.
+func (s *Storage) MigrateUp(ctx context.Context) ([]Migration, error) {
+	const op = "storage.postgres.MigrateUp"
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var done []Migration
+	err = s.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			err := runInTx(ctx, conn, m.up,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return done, fmt.Errorf("%s: %w", op, err)
+	}
+	return done, nil
+}
+
+// MigrateDown откатывает последние steps применённых миграций.
+func (s *Storage) MigrateDown(ctx context.Context, steps int) ([]Migration, error) {
+	const op = "storage.postgres.MigrateDown"
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var done []Migration
+	err = s.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			err := runInTx(ctx, conn, m.down,
+				`DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return done, fmt.Errorf("%s: %w", op, err)
+	}
+	return done, nil
+}
+
+// MigrateBaseline отмечает миграции до version включительно применёнными,
+// не выполняя их. Нужна для баз, созданных до появления schema_migrations:
+// их схема уже совпадает с этими миграциями, и повторный CREATE TABLE упал бы.
+func (s *Storage) MigrateBaseline(ctx context.Context, version int) ([]Migration, error) {
+	const op = "storage.postgres.MigrateBaseline"
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	known := false
+	for _, m := range migrations {
+		known = known || m.Version == version
+	}
+	if !known {
+		return nil, fmt.Errorf("%s: unknown migration version %d", op, version)
+	}
+
+	var done []Migration
+	err = s.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		// Отмечать нечего, если схемы ещё нет: её создаст migrate up
+		var exists bool
+		if err := conn.QueryRowContext(ctx, `SELECT to_regclass('users') IS NOT NULL`).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return errors.New("database has no existing schema, run migrate up instead")
+		}
+
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if m.Version > version {
+				break
+			}
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			_, err := conn.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return done, fmt.Errorf("%s: %w", op, err)
+	}
+	return done, nil
+}
+
+// MigrationStatus возвращает все известные миграции и время их применения.
+func (s *Storage) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	const op = "storage.postgres.MigrationStatus"
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var statuses []MigrationStatus
+	err = s.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			status := MigrationStatus{Version: m.Version, Name: m.Name}
+			if at, ok := applied[m.Version]; ok {
+				status.AppliedAt = &at
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return statuses, nil
+}
+
+func runInTx(ctx context.Context, conn *sql.Conn, script, bookkeeping string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,19 @@
+package postgres
+
+import "testing"
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("loadMigrations: no migrations")
+	}
+	// версии идут подряд с 1: baseline отмечает префикс, а не выборку
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s: version %d, want %d", m.Version, m.Name, m.Version, i+1)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+DROP VIEW IF EXISTS view_comment_with_author_tag;
+DROP VIEW IF EXISTS view_favorite_post_summary;
+DROP VIEW IF EXISTS view_post_summary;
+
+DROP PROCEDURE IF EXISTS mark_all_notifications_as_read(INTEGER);
+DROP FUNCTION IF EXISTS get_unread_notifications(INTEGER);
+DROP FUNCTION IF EXISTS create_comment(INTEGER, INTEGER, TEXT);
+DROP PROCEDURE IF EXISTS follow(INTEGER, INTEGER);
+DROP PROCEDURE IF EXISTS like_post(INTEGER, INTEGER);
+DROP PROCEDURE IF EXISTS create_full_user(TEXT, TEXT, TEXT, TEXT);
+
+DROP TABLE IF EXISTS reports;
+DROP TABLE IF EXISTS report_types;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS notification_types;
+DROP TABLE IF EXISTS favorite_posts;
+DROP TABLE IF EXISTS user_blocks;
+DROP TABLE IF EXISTS follows;
+DROP TABLE IF EXISTS likes;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS post_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS user_tokens;
+DROP TABLE IF EXISTS user_info;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,249 @@
+-- Базовая схема: таблицы, процедуры и представления, на которые
+-- опирается слой хранения.
+
+CREATE TABLE users (
+    user_id    SERIAL PRIMARY KEY,
+    email      TEXT        NOT NULL UNIQUE,
+    password   TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE user_info (
+    user_id     INTEGER PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+    user_name   TEXT NOT NULL,
+    user_tag    TEXT NOT NULL UNIQUE,
+    theme       TEXT NOT NULL DEFAULT 'light',
+    language    TEXT NOT NULL DEFAULT 'en',
+    avatar_url  TEXT,
+    description TEXT
+);
+
+CREATE TABLE user_tokens (
+    token      TEXT PRIMARY KEY,
+    user_id    INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE posts (
+    post_id     SERIAL PRIMARY KEY,
+    author_id   INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    title       TEXT        NOT NULL DEFAULT '',
+    description TEXT        NOT NULL DEFAULT '',
+    image_url   TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX posts_author_created_idx ON posts (author_id, created_at DESC);
+CREATE INDEX posts_created_idx ON posts (created_at DESC);
+
+CREATE TABLE tags (
+    tag_id SERIAL PRIMARY KEY,
+    name   TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE post_tags (
+    post_id INTEGER NOT NULL REFERENCES posts (post_id) ON DELETE CASCADE,
+    tag_id  INTEGER NOT NULL REFERENCES tags (tag_id) ON DELETE CASCADE,
+    PRIMARY KEY (post_id, tag_id)
+);
+
+CREATE TABLE comments (
+    comment_id SERIAL PRIMARY KEY,
+    author_id  INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    post_id    INTEGER     NOT NULL REFERENCES posts (post_id) ON DELETE CASCADE,
+    comment    TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX comments_post_created_idx ON comments (post_id, created_at);
+
+CREATE TABLE likes (
+    user_id    INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    post_id    INTEGER     NOT NULL REFERENCES posts (post_id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE INDEX likes_post_idx ON likes (post_id);
+
+CREATE TABLE follows (
+    follower_id  INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    following_id INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (follower_id, following_id),
+    CHECK (follower_id <> following_id)
+);
+
+CREATE INDEX follows_following_idx ON follows (following_id);
+
+CREATE TABLE user_blocks (
+    blocker_id INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    blocked_id INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (blocker_id, blocked_id)
+);
+
+CREATE INDEX user_blocks_blocked_idx ON user_blocks (blocked_id);
+
+CREATE TABLE favorite_posts (
+    user_id    INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    post_id    INTEGER     NOT NULL REFERENCES posts (post_id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE TABLE notification_types (
+    type_id INTEGER PRIMARY KEY,
+    name    TEXT NOT NULL UNIQUE
+);
+
+INSERT INTO notification_types (type_id, name)
+VALUES (1, 'like'), (2, 'comment'), (3, 'follow');
+
+-- entity_id: для like — post_id, для comment — comment_id, для follow — follower_id
+CREATE TABLE notifications (
+    notification_id SERIAL PRIMARY KEY,
+    user_id         INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    type_id         INTEGER     NOT NULL REFERENCES notification_types (type_id),
+    entity_id       INTEGER     NOT NULL,
+    is_read         BOOLEAN     NOT NULL DEFAULT false,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX notifications_unread_idx ON notifications (user_id) WHERE NOT is_read;
+
+CREATE TABLE report_types (
+    report_type_id INTEGER PRIMARY KEY,
+    name           TEXT NOT NULL UNIQUE
+);
+
+INSERT INTO report_types (report_type_id, name)
+VALUES (1, 'post'), (2, 'comment');
+
+CREATE TABLE reports (
+    report_id      SERIAL PRIMARY KEY,
+    reporter_id    INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    target_id      INTEGER     NOT NULL,
+    report_type_id INTEGER     NOT NULL REFERENCES report_types (report_type_id),
+    description    TEXT        NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE PROCEDURE create_full_user(p_email TEXT, p_password TEXT, p_user_name TEXT, p_user_tag TEXT)
+LANGUAGE plpgsql AS $$
+DECLARE
+    v_user_id INTEGER;
+BEGIN
+    INSERT INTO users (email, password)
+    VALUES (p_email, p_password)
+    RETURNING user_id INTO v_user_id;
+
+    INSERT INTO user_info (user_id, user_name, user_tag)
+    VALUES (v_user_id, p_user_name, p_user_tag);
+END;
+$$;
+
+CREATE PROCEDURE like_post(p_user_id INTEGER, p_post_id INTEGER)
+LANGUAGE plpgsql AS $$
+DECLARE
+    v_author_id INTEGER;
+BEGIN
+    INSERT INTO likes (user_id, post_id)
+    VALUES (p_user_id, p_post_id)
+    ON CONFLICT DO NOTHING;
+
+    IF FOUND THEN
+        SELECT author_id INTO v_author_id FROM posts WHERE post_id = p_post_id;
+        IF v_author_id IS DISTINCT FROM p_user_id THEN
+            INSERT INTO notifications (user_id, type_id, entity_id)
+            VALUES (v_author_id, 1, p_post_id);
+        END IF;
+    END IF;
+END;
+$$;
+
+CREATE PROCEDURE follow(p_follower_id INTEGER, p_following_id INTEGER)
+LANGUAGE plpgsql AS $$
+BEGIN
+    INSERT INTO follows (follower_id, following_id)
+    VALUES (p_follower_id, p_following_id)
+    ON CONFLICT DO NOTHING;
+
+    IF FOUND THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (p_following_id, 3, p_follower_id);
+    END IF;
+END;
+$$;
+
+CREATE FUNCTION create_comment(p_author_id INTEGER, p_post_id INTEGER, p_comment TEXT)
+RETURNS INTEGER
+LANGUAGE plpgsql AS $$
+DECLARE
+    v_comment_id INTEGER;
+    v_post_author_id INTEGER;
+BEGIN
+    INSERT INTO comments (author_id, post_id, comment)
+    VALUES (p_author_id, p_post_id, p_comment)
+    RETURNING comment_id INTO v_comment_id;
+
+    SELECT author_id INTO v_post_author_id FROM posts WHERE post_id = p_post_id;
+    IF v_post_author_id IS DISTINCT FROM p_author_id THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (v_post_author_id, 2, v_comment_id);
+    END IF;
+
+    RETURN v_comment_id;
+END;
+$$;
+
+CREATE FUNCTION get_unread_notifications(p_user_id INTEGER)
+RETURNS TABLE (
+    notification_id INTEGER,
+    user_id         INTEGER,
+    type_id         INTEGER,
+    entity_id       INTEGER,
+    is_read         BOOLEAN,
+    created_at      TIMESTAMPTZ
+)
+LANGUAGE sql STABLE AS $$
+    SELECT n.notification_id, n.user_id, n.type_id, n.entity_id, n.is_read, n.created_at
+    FROM notifications n
+    WHERE n.user_id = p_user_id AND NOT n.is_read
+    ORDER BY n.created_at DESC;
+$$;
+
+CREATE PROCEDURE mark_all_notifications_as_read(p_user_id INTEGER)
+LANGUAGE sql AS $$
+    UPDATE notifications SET is_read = true
+    WHERE user_id = p_user_id AND NOT is_read;
+$$;
+
+CREATE VIEW view_post_summary AS
+SELECT p.post_id,
+       p.title,
+       p.description,
+       p.image_url,
+       p.created_at AS post_created_at,
+       p.author_id,
+       ui.user_name AS author_user_name,
+       (SELECT count(*) FROM likes l WHERE l.post_id = p.post_id)::INTEGER AS like_count
+FROM posts p
+JOIN user_info ui ON ui.user_id = p.author_id;
+
+CREATE VIEW view_favorite_post_summary AS
+SELECT vps.*,
+       fp.user_id AS favorited_by_user_id
+FROM favorite_posts fp
+JOIN view_post_summary vps ON vps.post_id = fp.post_id;
+
+CREATE VIEW view_comment_with_author_tag AS
+SELECT c.comment_id,
+       c.post_id,
+       c.comment,
+       c.created_at AS comment_created_at,
+       c.author_id,
+       ui.user_tag AS author_user_tag
+FROM comments c
+JOIN user_info ui ON ui.user_id = c.author_id;
@@ -0,0 +1,8 @@
+DROP TABLE user_tokens;
+
+CREATE TABLE user_tokens (
+    token      TEXT PRIMARY KEY,
+    user_id    INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,21 @@
+-- user_tokens становится таблицей сессий: хранится хеш refresh-токена,
+-- а access-токены ссылаются на сессию через jti. Ранее выданные JWT
+-- в новой схеме недействительны, поэтому таблица пересоздаётся.
+
+DROP TABLE user_tokens;
+
+CREATE TABLE user_tokens (
+    session_id     UUID PRIMARY KEY,
+    token          TEXT        NOT NULL UNIQUE,
+    previous_token TEXT,
+    user_id        INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    device         TEXT        NOT NULL DEFAULT '',
+    ip             TEXT        NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at     TIMESTAMPTZ NOT NULL,
+    revoked_at     TIMESTAMPTZ
+);
+
+CREATE INDEX user_tokens_user_idx ON user_tokens (user_id) WHERE revoked_at IS NULL;
+CREATE INDEX user_tokens_previous_idx ON user_tokens (previous_token);
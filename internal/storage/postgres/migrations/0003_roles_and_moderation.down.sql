@@ -0,0 +1,36 @@
+CREATE OR REPLACE VIEW view_comment_with_author_tag AS
+SELECT c.comment_id,
+       c.post_id,
+       c.comment,
+       c.created_at AS comment_created_at,
+       c.author_id,
+       ui.user_tag AS author_user_tag
+FROM comments c
+JOIN user_info ui ON ui.user_id = c.author_id;
+
+CREATE OR REPLACE VIEW view_post_summary AS
+SELECT p.post_id,
+       p.title,
+       p.description,
+       p.image_url,
+       p.created_at AS post_created_at,
+       p.author_id,
+       ui.user_name AS author_user_name,
+       (SELECT count(*) FROM likes l WHERE l.post_id = p.post_id)::INTEGER AS like_count
+FROM posts p
+JOIN user_info ui ON ui.user_id = p.author_id;
+
+DROP TABLE moderation_actions;
+
+ALTER TABLE reports
+    DROP CONSTRAINT reports_reporter_target_key,
+    DROP COLUMN resolved_at,
+    DROP COLUMN assignee_id,
+    DROP COLUMN status;
+
+ALTER TABLE comments DROP COLUMN is_hidden;
+ALTER TABLE posts DROP COLUMN is_hidden;
+
+ALTER TABLE users
+    DROP COLUMN suspended_at,
+    DROP COLUMN role;
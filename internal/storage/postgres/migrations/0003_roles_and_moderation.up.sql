@@ -0,0 +1,61 @@
+-- Роли пользователей, блокировка аккаунтов, скрытие контента
+-- модераторами и разбор жалоб.
+
+ALTER TABLE users
+    ADD COLUMN role         TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin')),
+    ADD COLUMN suspended_at TIMESTAMPTZ;
+
+ALTER TABLE posts ADD COLUMN is_hidden BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE comments ADD COLUMN is_hidden BOOLEAN NOT NULL DEFAULT false;
+
+-- Повторные жалобы одного пользователя на один объект схлопываются
+DELETE FROM reports r
+USING reports d
+WHERE r.reporter_id = d.reporter_id
+  AND r.report_type_id = d.report_type_id
+  AND r.target_id = d.target_id
+  AND r.report_id > d.report_id;
+
+ALTER TABLE reports
+    ADD COLUMN status      TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved', 'dismissed')),
+    ADD COLUMN assignee_id INTEGER REFERENCES users (user_id) ON DELETE SET NULL,
+    ADD COLUMN resolved_at TIMESTAMPTZ,
+    ADD CONSTRAINT reports_reporter_target_key UNIQUE (reporter_id, report_type_id, target_id);
+
+CREATE INDEX reports_status_created_idx ON reports (status, created_at);
+
+CREATE TABLE moderation_actions (
+    action_id    SERIAL PRIMARY KEY,
+    report_id    INTEGER     NOT NULL REFERENCES reports (report_id) ON DELETE CASCADE,
+    moderator_id INTEGER     NOT NULL REFERENCES users (user_id),
+    action       TEXT        NOT NULL,
+    note         TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX moderation_actions_report_idx ON moderation_actions (report_id);
+
+-- Скрытый модератором контент не попадает в ленты и комментарии
+CREATE OR REPLACE VIEW view_post_summary AS
+SELECT p.post_id,
+       p.title,
+       p.description,
+       p.image_url,
+       p.created_at AS post_created_at,
+       p.author_id,
+       ui.user_name AS author_user_name,
+       (SELECT count(*) FROM likes l WHERE l.post_id = p.post_id)::INTEGER AS like_count
+FROM posts p
+JOIN user_info ui ON ui.user_id = p.author_id
+WHERE NOT p.is_hidden;
+
+CREATE OR REPLACE VIEW view_comment_with_author_tag AS
+SELECT c.comment_id,
+       c.post_id,
+       c.comment,
+       c.created_at AS comment_created_at,
+       c.author_id,
+       ui.user_tag AS author_user_tag
+FROM comments c
+JOIN user_info ui ON ui.user_id = c.author_id
+WHERE NOT c.is_hidden;
@@ -0,0 +1,8 @@
+DROP TABLE report_evidence;
+
+ALTER TABLE reports DROP COLUMN reason_code;
+
+DROP TABLE report_reasons;
+
+DELETE FROM reports WHERE report_type_id = 3;
+DELETE FROM report_types WHERE report_type_id = 3;
@@ -0,0 +1,32 @@
+-- Жалобы на пользователей, справочник причин и доказательства.
+
+INSERT INTO report_types (report_type_id, name) VALUES (3, 'user');
+
+CREATE TABLE report_reasons (
+    reason_code TEXT PRIMARY KEY,
+    title       TEXT    NOT NULL,
+    is_active   BOOLEAN NOT NULL DEFAULT true,
+    sort_order  INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO report_reasons (reason_code, title, sort_order)
+VALUES ('spam', 'Spam', 10),
+       ('harassment', 'Harassment or bullying', 20),
+       ('hate_speech', 'Hate speech', 30),
+       ('nudity', 'Nudity or sexual content', 40),
+       ('violence', 'Violence', 50),
+       ('impersonation', 'Impersonation', 60),
+       ('misinformation', 'False information', 70),
+       ('other', 'Other', 1000);
+
+ALTER TABLE reports
+    ADD COLUMN reason_code TEXT NOT NULL DEFAULT 'other' REFERENCES report_reasons (reason_code);
+
+ALTER TABLE reports ALTER COLUMN reason_code DROP DEFAULT;
+
+CREATE TABLE report_evidence (
+    report_id      INTEGER NOT NULL REFERENCES reports (report_id) ON DELETE CASCADE,
+    report_type_id INTEGER NOT NULL REFERENCES report_types (report_type_id),
+    target_id      INTEGER NOT NULL,
+    PRIMARY KEY (report_id, report_type_id, target_id)
+);
@@ -0,0 +1,28 @@
+-- Уменьшенные копии картинок постов и аватаров:
+-- {"thumbnail": {"url": <ключ>, "width": .., "height": ..}, "feed": .., "full": ..}
+
+ALTER TABLE posts ADD COLUMN images JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE user_info ADD COLUMN avatar_images JSONB NOT NULL DEFAULT '{}';
+
+-- view_favorite_post_summary раскрывает vps.* при создании, поэтому пересоздаётся
+DROP VIEW view_favorite_post_summary;
+
+CREATE OR REPLACE VIEW view_post_summary AS
+SELECT p.post_id,
+       p.title,
+       p.description,
+       p.image_url,
+       p.created_at AS post_created_at,
+       p.author_id,
+       ui.user_name AS author_user_name,
+       (SELECT count(*) FROM likes l WHERE l.post_id = p.post_id)::INTEGER AS like_count,
+       p.images
+FROM posts p
+JOIN user_info ui ON ui.user_id = p.author_id
+WHERE NOT p.is_hidden;
+
+CREATE VIEW view_favorite_post_summary AS
+SELECT vps.*,
+       fp.user_id AS favorited_by_user_id
+FROM favorite_posts fp
+JOIN view_post_summary vps ON vps.post_id = fp.post_id;
@@ -0,0 +1,34 @@
+-- Упорядоченные вложения поста (картинки и видео) с альтернативным текстом.
+-- posts.image_url и posts.images остаются обложкой — первой картинкой
+-- поста — для клиентов, которые ещё не читают attachments.
+
+CREATE TABLE post_attachments (
+    attachment_id SERIAL PRIMARY KEY,
+    post_id       INTEGER NOT NULL REFERENCES posts (post_id) ON DELETE CASCADE,
+    position      INTEGER NOT NULL,
+    kind          TEXT    NOT NULL CHECK (kind IN ('image', 'video')),
+    media_key     TEXT    NOT NULL,
+    content_type  TEXT    NOT NULL,
+    alt_text      TEXT    NOT NULL DEFAULT '',
+    width         INTEGER,
+    height        INTEGER,
+    images        JSONB   NOT NULL DEFAULT '{}',
+    UNIQUE (post_id, position)
+);
+
+-- Существующие картинки постов становятся первым вложением
+INSERT INTO post_attachments (post_id, position, kind, media_key, content_type, images)
+SELECT post_id,
+       0,
+       'image',
+       image_url,
+       CASE
+           WHEN lower(image_url) LIKE '%.png' THEN 'image/png'
+           WHEN lower(image_url) LIKE '%.gif' THEN 'image/gif'
+           WHEN lower(image_url) LIKE '%.webp' THEN 'image/webp'
+           WHEN lower(image_url) LIKE '%.jpg' OR lower(image_url) LIKE '%.jpeg' THEN 'image/jpeg'
+           ELSE 'application/octet-stream'
+       END,
+       images
+FROM posts
+WHERE image_url <> '';
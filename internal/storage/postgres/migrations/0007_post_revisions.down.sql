@@ -0,0 +1,25 @@
+DROP VIEW view_favorite_post_summary;
+DROP VIEW view_post_summary;
+
+CREATE VIEW view_post_summary AS
+SELECT p.post_id,
+       p.title,
+       p.description,
+       p.image_url,
+       p.created_at AS post_created_at,
+       p.author_id,
+       ui.user_name AS author_user_name,
+       (SELECT count(*) FROM likes l WHERE l.post_id = p.post_id)::INTEGER AS like_count,
+       p.images
+FROM posts p
+JOIN user_info ui ON ui.user_id = p.author_id
+WHERE NOT p.is_hidden;
+
+CREATE VIEW view_favorite_post_summary AS
+SELECT vps.*,
+       fp.user_id AS favorited_by_user_id
+FROM favorite_posts fp
+JOIN view_post_summary vps ON vps.post_id = fp.post_id;
+
+DROP TABLE post_revisions;
+ALTER TABLE posts DROP COLUMN edited_at;
@@ -0,0 +1,40 @@
+-- Редактирование постов: время последней правки и история версий.
+-- Строка post_revisions — состояние поста до правки, created_at — время правки.
+
+ALTER TABLE posts ADD COLUMN edited_at TIMESTAMPTZ;
+
+CREATE TABLE post_revisions (
+    revision_id SERIAL PRIMARY KEY,
+    post_id     INTEGER     NOT NULL REFERENCES posts (post_id) ON DELETE CASCADE,
+    editor_id   INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    title       TEXT        NOT NULL,
+    description TEXT        NOT NULL,
+    tags        TEXT[]      NOT NULL DEFAULT '{}',
+    attachments JSONB       NOT NULL DEFAULT '[]',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX post_revisions_post_created_idx ON post_revisions (post_id, created_at DESC);
+
+DROP VIEW view_favorite_post_summary;
+
+CREATE OR REPLACE VIEW view_post_summary AS
+SELECT p.post_id,
+       p.title,
+       p.description,
+       p.image_url,
+       p.created_at AS post_created_at,
+       p.author_id,
+       ui.user_name AS author_user_name,
+       (SELECT count(*) FROM likes l WHERE l.post_id = p.post_id)::INTEGER AS like_count,
+       p.images,
+       p.edited_at
+FROM posts p
+JOIN user_info ui ON ui.user_id = p.author_id
+WHERE NOT p.is_hidden;
+
+CREATE VIEW view_favorite_post_summary AS
+SELECT vps.*,
+       fp.user_id AS favorited_by_user_id
+FROM favorite_posts fp
+JOIN view_post_summary vps ON vps.post_id = fp.post_id;
@@ -0,0 +1,41 @@
+DROP VIEW view_comment_with_author_tag;
+
+CREATE VIEW view_comment_with_author_tag AS
+SELECT c.comment_id,
+       c.post_id,
+       c.comment,
+       c.created_at AS comment_created_at,
+       c.author_id,
+       ui.user_tag AS author_user_tag
+FROM comments c
+JOIN user_info ui ON ui.user_id = c.author_id
+WHERE NOT c.is_hidden;
+
+DROP FUNCTION create_comment(INTEGER, INTEGER, TEXT, INTEGER);
+
+CREATE FUNCTION create_comment(p_author_id INTEGER, p_post_id INTEGER, p_comment TEXT)
+RETURNS INTEGER
+LANGUAGE plpgsql AS $$
+DECLARE
+    v_comment_id INTEGER;
+    v_post_author_id INTEGER;
+BEGIN
+    INSERT INTO comments (author_id, post_id, comment)
+    VALUES (p_author_id, p_post_id, p_comment)
+    RETURNING comment_id INTO v_comment_id;
+
+    SELECT author_id INTO v_post_author_id FROM posts WHERE post_id = p_post_id;
+    IF v_post_author_id IS DISTINCT FROM p_author_id THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (v_post_author_id, 2, v_comment_id);
+    END IF;
+
+    RETURN v_comment_id;
+END;
+$$;
+
+-- ответы остаются обычными комментариями к посту
+ALTER TABLE comments
+    DROP COLUMN edited_at,
+    DROP COLUMN depth,
+    DROP COLUMN parent_id;
@@ -0,0 +1,51 @@
+-- Ответы на комментарии и их редактирование. depth — уровень вложенности
+-- (0 — комментарий к посту), ограничение на глубину проверяется в коде.
+
+ALTER TABLE comments
+    ADD COLUMN parent_id INTEGER REFERENCES comments (comment_id) ON DELETE CASCADE,
+    ADD COLUMN depth     INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN edited_at TIMESTAMPTZ;
+
+CREATE INDEX comments_parent_created_idx ON comments (parent_id, created_at);
+
+DROP FUNCTION create_comment(INTEGER, INTEGER, TEXT);
+
+CREATE FUNCTION create_comment(p_author_id INTEGER, p_post_id INTEGER, p_comment TEXT, p_parent_id INTEGER DEFAULT NULL)
+RETURNS INTEGER
+LANGUAGE plpgsql AS $$
+DECLARE
+    v_comment_id INTEGER;
+    v_post_author_id INTEGER;
+    v_depth INTEGER := 0;
+BEGIN
+    IF p_parent_id IS NOT NULL THEN
+        SELECT depth + 1 INTO v_depth FROM comments WHERE comment_id = p_parent_id;
+    END IF;
+
+    INSERT INTO comments (author_id, post_id, comment, parent_id, depth)
+    VALUES (p_author_id, p_post_id, p_comment, p_parent_id, v_depth)
+    RETURNING comment_id INTO v_comment_id;
+
+    SELECT author_id INTO v_post_author_id FROM posts WHERE post_id = p_post_id;
+    IF v_post_author_id IS DISTINCT FROM p_author_id THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (v_post_author_id, 2, v_comment_id);
+    END IF;
+
+    RETURN v_comment_id;
+END;
+$$;
+
+CREATE OR REPLACE VIEW view_comment_with_author_tag AS
+SELECT c.comment_id,
+       c.post_id,
+       c.comment,
+       c.created_at AS comment_created_at,
+       c.author_id,
+       ui.user_tag AS author_user_tag,
+       c.parent_id,
+       c.edited_at,
+       (SELECT count(*) FROM comments r WHERE r.parent_id = c.comment_id AND NOT r.is_hidden)::INTEGER AS reply_count
+FROM comments c
+JOIN user_info ui ON ui.user_id = c.author_id
+WHERE NOT c.is_hidden;
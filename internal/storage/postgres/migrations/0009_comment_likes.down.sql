@@ -0,0 +1,41 @@
+DROP VIEW view_favorite_post_summary;
+DROP VIEW view_post_summary;
+
+CREATE VIEW view_post_summary AS
+SELECT p.post_id,
+       p.title,
+       p.description,
+       p.image_url,
+       p.created_at AS post_created_at,
+       p.author_id,
+       ui.user_name AS author_user_name,
+       (SELECT count(*) FROM likes l WHERE l.post_id = p.post_id)::INTEGER AS like_count,
+       p.images,
+       p.edited_at
+FROM posts p
+JOIN user_info ui ON ui.user_id = p.author_id
+WHERE NOT p.is_hidden;
+
+CREATE VIEW view_favorite_post_summary AS
+SELECT vps.*,
+       fp.user_id AS favorited_by_user_id
+FROM favorite_posts fp
+JOIN view_post_summary vps ON vps.post_id = fp.post_id;
+
+DROP VIEW view_comment_with_author_tag;
+
+CREATE VIEW view_comment_with_author_tag AS
+SELECT c.comment_id,
+       c.post_id,
+       c.comment,
+       c.created_at AS comment_created_at,
+       c.author_id,
+       ui.user_tag AS author_user_tag,
+       c.parent_id,
+       c.edited_at,
+       (SELECT count(*) FROM comments r WHERE r.parent_id = c.comment_id AND NOT r.is_hidden)::INTEGER AS reply_count
+FROM comments c
+JOIN user_info ui ON ui.user_id = c.author_id
+WHERE NOT c.is_hidden;
+
+DROP TABLE comment_likes;
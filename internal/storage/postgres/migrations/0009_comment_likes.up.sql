@@ -0,0 +1,50 @@
+-- Лайки комментариев (для сортировки «самые популярные») и число
+-- комментариев поста в ленте.
+
+CREATE TABLE comment_likes (
+    user_id    INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    comment_id INTEGER     NOT NULL REFERENCES comments (comment_id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, comment_id)
+);
+
+CREATE INDEX comment_likes_comment_idx ON comment_likes (comment_id);
+
+CREATE OR REPLACE VIEW view_comment_with_author_tag AS
+SELECT c.comment_id,
+       c.post_id,
+       c.comment,
+       c.created_at AS comment_created_at,
+       c.author_id,
+       ui.user_tag AS author_user_tag,
+       c.parent_id,
+       c.edited_at,
+       (SELECT count(*) FROM comments r WHERE r.parent_id = c.comment_id AND NOT r.is_hidden)::INTEGER AS reply_count,
+       (SELECT count(*) FROM comment_likes cl WHERE cl.comment_id = c.comment_id)::INTEGER AS like_count
+FROM comments c
+JOIN user_info ui ON ui.user_id = c.author_id
+WHERE NOT c.is_hidden;
+
+DROP VIEW view_favorite_post_summary;
+
+CREATE OR REPLACE VIEW view_post_summary AS
+SELECT p.post_id,
+       p.title,
+       p.description,
+       p.image_url,
+       p.created_at AS post_created_at,
+       p.author_id,
+       ui.user_name AS author_user_name,
+       (SELECT count(*) FROM likes l WHERE l.post_id = p.post_id)::INTEGER AS like_count,
+       p.images,
+       p.edited_at,
+       (SELECT count(*) FROM comments c WHERE c.post_id = p.post_id AND NOT c.is_hidden)::INTEGER AS comment_count
+FROM posts p
+JOIN user_info ui ON ui.user_id = p.author_id
+WHERE NOT p.is_hidden;
+
+CREATE VIEW view_favorite_post_summary AS
+SELECT vps.*,
+       fp.user_id AS favorited_by_user_id
+FROM favorite_posts fp
+JOIN view_post_summary vps ON vps.post_id = fp.post_id;
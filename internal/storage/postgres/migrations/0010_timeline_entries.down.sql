@@ -0,0 +1,17 @@
+CREATE OR REPLACE PROCEDURE follow(p_follower_id INTEGER, p_following_id INTEGER)
+LANGUAGE plpgsql AS $$
+BEGIN
+    INSERT INTO follows (follower_id, following_id)
+    VALUES (p_follower_id, p_following_id)
+    ON CONFLICT DO NOTHING;
+
+    IF FOUND THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (p_following_id, 3, p_follower_id);
+    END IF;
+END;
+$$;
+
+DROP TABLE timeline_entries;
+
+ALTER TABLE posts DROP COLUMN fanned_out;
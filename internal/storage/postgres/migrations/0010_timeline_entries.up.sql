@@ -0,0 +1,38 @@
+-- Домашняя лента: посты раскладываются по лентам подписчиков при записи.
+-- posts.fanned_out — пост уже разложен; остальные посты подписок (авторы
+-- с большим числом подписчиков, ещё не обработанные и созданные до этой
+-- миграции) читаются из posts при запросе ленты.
+
+ALTER TABLE posts ADD COLUMN fanned_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE timeline_entries (
+    user_id    INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    post_id    INTEGER     NOT NULL REFERENCES posts (post_id) ON DELETE CASCADE,
+    author_id  INTEGER     NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE INDEX timeline_entries_user_created_idx ON timeline_entries (user_id, created_at DESC, post_id DESC);
+CREATE INDEX timeline_entries_author_idx ON timeline_entries (author_id);
+
+-- При подписке в ленту сразу попадают уже разложенные посты автора
+CREATE OR REPLACE PROCEDURE follow(p_follower_id INTEGER, p_following_id INTEGER)
+LANGUAGE plpgsql AS $$
+BEGIN
+    INSERT INTO follows (follower_id, following_id)
+    VALUES (p_follower_id, p_following_id)
+    ON CONFLICT DO NOTHING;
+
+    IF FOUND THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (p_following_id, 3, p_follower_id);
+
+        INSERT INTO timeline_entries (user_id, post_id, author_id, created_at)
+        SELECT p_follower_id, p.post_id, p.author_id, p.created_at
+        FROM posts p
+        WHERE p.author_id = p_following_id AND p.fanned_out
+        ON CONFLICT DO NOTHING;
+    END IF;
+END;
+$$;
@@ -0,0 +1,68 @@
+CREATE OR REPLACE FUNCTION create_comment(p_author_id INTEGER, p_post_id INTEGER, p_comment TEXT, p_parent_id INTEGER DEFAULT NULL)
+RETURNS INTEGER
+LANGUAGE plpgsql AS $$
+DECLARE
+    v_comment_id INTEGER;
+    v_post_author_id INTEGER;
+    v_depth INTEGER := 0;
+BEGIN
+    IF p_parent_id IS NOT NULL THEN
+        SELECT depth + 1 INTO v_depth FROM comments WHERE comment_id = p_parent_id;
+    END IF;
+
+    INSERT INTO comments (author_id, post_id, comment, parent_id, depth)
+    VALUES (p_author_id, p_post_id, p_comment, p_parent_id, v_depth)
+    RETURNING comment_id INTO v_comment_id;
+
+    SELECT author_id INTO v_post_author_id FROM posts WHERE post_id = p_post_id;
+    IF v_post_author_id IS DISTINCT FROM p_author_id THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (v_post_author_id, 2, v_comment_id);
+    END IF;
+
+    RETURN v_comment_id;
+END;
+$$;
+
+CREATE OR REPLACE PROCEDURE follow(p_follower_id INTEGER, p_following_id INTEGER)
+LANGUAGE plpgsql AS $$
+BEGIN
+    INSERT INTO follows (follower_id, following_id)
+    VALUES (p_follower_id, p_following_id)
+    ON CONFLICT DO NOTHING;
+
+    IF FOUND THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (p_following_id, 3, p_follower_id);
+
+        INSERT INTO timeline_entries (user_id, post_id, author_id, created_at)
+        SELECT p_follower_id, p.post_id, p.author_id, p.created_at
+        FROM posts p
+        WHERE p.author_id = p_following_id AND p.fanned_out
+        ON CONFLICT DO NOTHING;
+    END IF;
+END;
+$$;
+
+CREATE OR REPLACE PROCEDURE like_post(p_user_id INTEGER, p_post_id INTEGER)
+LANGUAGE plpgsql AS $$
+DECLARE
+    v_author_id INTEGER;
+BEGIN
+    INSERT INTO likes (user_id, post_id)
+    VALUES (p_user_id, p_post_id)
+    ON CONFLICT DO NOTHING;
+
+    IF FOUND THEN
+        SELECT author_id INTO v_author_id FROM posts WHERE post_id = p_post_id;
+        IF v_author_id IS DISTINCT FROM p_user_id THEN
+            INSERT INTO notifications (user_id, type_id, entity_id)
+            VALUES (v_author_id, 1, p_post_id);
+        END IF;
+    END IF;
+END;
+$$;
+
+DROP FUNCTION is_blocked_between(INTEGER, INTEGER);
+
+ALTER TABLE user_blocks DROP CONSTRAINT user_blocks_not_self;
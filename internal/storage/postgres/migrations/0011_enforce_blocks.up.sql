@@ -0,0 +1,101 @@
+-- Блокировки действуют в обе стороны: заблокированный и заблокировавший
+-- не могут лайкать посты, комментировать и подписываться друг на друга.
+-- Процедуры отклоняют такие действия с SQLSTATE UB001.
+
+CREATE FUNCTION is_blocked_between(p_user_id INTEGER, p_other_id INTEGER)
+RETURNS BOOLEAN
+LANGUAGE sql STABLE AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM user_blocks
+        WHERE (blocker_id = p_user_id AND blocked_id = p_other_id)
+           OR (blocker_id = p_other_id AND blocked_id = p_user_id)
+    );
+$$;
+
+-- Блокировка самого себя запретила бы пользователю лайкать и
+-- комментировать собственные посты
+DELETE FROM user_blocks WHERE blocker_id = blocked_id;
+ALTER TABLE user_blocks ADD CONSTRAINT user_blocks_not_self CHECK (blocker_id <> blocked_id);
+
+-- Блокировка уже удаляет подписки в обе стороны
+DELETE FROM follows f
+USING user_blocks b
+WHERE (f.follower_id = b.blocker_id AND f.following_id = b.blocked_id)
+   OR (f.follower_id = b.blocked_id AND f.following_id = b.blocker_id);
+
+CREATE OR REPLACE PROCEDURE like_post(p_user_id INTEGER, p_post_id INTEGER)
+LANGUAGE plpgsql AS $$
+DECLARE
+    v_author_id INTEGER;
+BEGIN
+    SELECT author_id INTO v_author_id FROM posts WHERE post_id = p_post_id;
+    IF is_blocked_between(p_user_id, v_author_id) THEN
+        RAISE EXCEPTION 'user is blocked' USING ERRCODE = 'UB001';
+    END IF;
+
+    INSERT INTO likes (user_id, post_id)
+    VALUES (p_user_id, p_post_id)
+    ON CONFLICT DO NOTHING;
+
+    IF FOUND AND v_author_id IS DISTINCT FROM p_user_id THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (v_author_id, 1, p_post_id);
+    END IF;
+END;
+$$;
+
+CREATE OR REPLACE PROCEDURE follow(p_follower_id INTEGER, p_following_id INTEGER)
+LANGUAGE plpgsql AS $$
+BEGIN
+    IF is_blocked_between(p_follower_id, p_following_id) THEN
+        RAISE EXCEPTION 'user is blocked' USING ERRCODE = 'UB001';
+    END IF;
+
+    INSERT INTO follows (follower_id, following_id)
+    VALUES (p_follower_id, p_following_id)
+    ON CONFLICT DO NOTHING;
+
+    IF FOUND THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (p_following_id, 3, p_follower_id);
+
+        INSERT INTO timeline_entries (user_id, post_id, author_id, created_at)
+        SELECT p_follower_id, p.post_id, p.author_id, p.created_at
+        FROM posts p
+        WHERE p.author_id = p_following_id AND p.fanned_out
+        ON CONFLICT DO NOTHING;
+    END IF;
+END;
+$$;
+
+-- Ответить нельзя ни автору поста, ни автору комментария, если между
+-- ними и комментатором есть блокировка
+CREATE OR REPLACE FUNCTION create_comment(p_author_id INTEGER, p_post_id INTEGER, p_comment TEXT, p_parent_id INTEGER DEFAULT NULL)
+RETURNS INTEGER
+LANGUAGE plpgsql AS $$
+DECLARE
+    v_comment_id INTEGER;
+    v_post_author_id INTEGER;
+    v_parent_author_id INTEGER;
+    v_depth INTEGER := 0;
+BEGIN
+    SELECT author_id INTO v_post_author_id FROM posts WHERE post_id = p_post_id;
+    IF p_parent_id IS NOT NULL THEN
+        SELECT depth + 1, author_id INTO v_depth, v_parent_author_id FROM comments WHERE comment_id = p_parent_id;
+    END IF;
+    IF is_blocked_between(p_author_id, v_post_author_id) OR is_blocked_between(p_author_id, v_parent_author_id) THEN
+        RAISE EXCEPTION 'user is blocked' USING ERRCODE = 'UB001';
+    END IF;
+
+    INSERT INTO comments (author_id, post_id, comment, parent_id, depth)
+    VALUES (p_author_id, p_post_id, p_comment, p_parent_id, v_depth)
+    RETURNING comment_id INTO v_comment_id;
+
+    IF v_post_author_id IS DISTINCT FROM p_author_id THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (v_post_author_id, 2, v_comment_id);
+    END IF;
+
+    RETURN v_comment_id;
+END;
+$$;
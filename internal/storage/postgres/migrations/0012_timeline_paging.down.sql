@@ -0,0 +1,4 @@
+DROP INDEX posts_not_fanned_out_idx;
+
+DROP INDEX posts_author_created_idx;
+CREATE INDEX posts_author_created_idx ON posts (author_id, created_at DESC);
@@ -0,0 +1,9 @@
+-- Домашняя лента листается по индексам: собственные посты автора и
+-- неразложенные посты подписок читаются с тем же курсором
+-- (created_at, post_id), что и timeline_entries.
+
+DROP INDEX posts_author_created_idx;
+CREATE INDEX posts_author_created_idx ON posts (author_id, created_at DESC, post_id DESC);
+
+CREATE INDEX posts_not_fanned_out_idx ON posts (author_id, created_at DESC, post_id DESC)
+WHERE NOT fanned_out;
@@ -0,0 +1,60 @@
+-- Скрытый модератором пост недоступен так же, как удалённый: лайкать и
+-- комментировать его нельзя. Процедуры отклоняют такие действия с
+-- SQLSTATE UP404.
+
+CREATE OR REPLACE PROCEDURE like_post(p_user_id INTEGER, p_post_id INTEGER)
+LANGUAGE plpgsql AS $$
+DECLARE
+    v_author_id INTEGER;
+BEGIN
+    SELECT author_id INTO v_author_id FROM posts WHERE post_id = p_post_id AND NOT is_hidden;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'post not found' USING ERRCODE = 'UP404';
+    END IF;
+    IF is_blocked_between(p_user_id, v_author_id) THEN
+        RAISE EXCEPTION 'user is blocked' USING ERRCODE = 'UB001';
+    END IF;
+
+    INSERT INTO likes (user_id, post_id)
+    VALUES (p_user_id, p_post_id)
+    ON CONFLICT DO NOTHING;
+
+    IF FOUND AND v_author_id IS DISTINCT FROM p_user_id THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (v_author_id, 1, p_post_id);
+    END IF;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION create_comment(p_author_id INTEGER, p_post_id INTEGER, p_comment TEXT, p_parent_id INTEGER DEFAULT NULL)
+RETURNS INTEGER
+LANGUAGE plpgsql AS $$
+DECLARE
+    v_comment_id INTEGER;
+    v_post_author_id INTEGER;
+    v_parent_author_id INTEGER;
+    v_depth INTEGER := 0;
+BEGIN
+    SELECT author_id INTO v_post_author_id FROM posts WHERE post_id = p_post_id AND NOT is_hidden;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'post not found' USING ERRCODE = 'UP404';
+    END IF;
+    IF p_parent_id IS NOT NULL THEN
+        SELECT depth + 1, author_id INTO v_depth, v_parent_author_id FROM comments WHERE comment_id = p_parent_id;
+    END IF;
+    IF is_blocked_between(p_author_id, v_post_author_id) OR is_blocked_between(p_author_id, v_parent_author_id) THEN
+        RAISE EXCEPTION 'user is blocked' USING ERRCODE = 'UB001';
+    END IF;
+
+    INSERT INTO comments (author_id, post_id, comment, parent_id, depth)
+    VALUES (p_author_id, p_post_id, p_comment, p_parent_id, v_depth)
+    RETURNING comment_id INTO v_comment_id;
+
+    IF v_post_author_id IS DISTINCT FROM p_author_id THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (v_post_author_id, 2, v_comment_id);
+    END IF;
+
+    RETURN v_comment_id;
+END;
+$$;
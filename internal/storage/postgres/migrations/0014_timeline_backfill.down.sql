@@ -0,0 +1,27 @@
+CREATE OR REPLACE PROCEDURE follow(p_follower_id INTEGER, p_following_id INTEGER)
+LANGUAGE plpgsql AS $$
+BEGIN
+    IF is_blocked_between(p_follower_id, p_following_id) THEN
+        RAISE EXCEPTION 'user is blocked' USING ERRCODE = 'UB001';
+    END IF;
+
+    INSERT INTO follows (follower_id, following_id)
+    VALUES (p_follower_id, p_following_id)
+    ON CONFLICT DO NOTHING;
+
+    IF FOUND THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (p_following_id, 3, p_follower_id);
+
+        INSERT INTO timeline_entries (user_id, post_id, author_id, created_at)
+        SELECT p_follower_id, p.post_id, p.author_id, p.created_at
+        FROM posts p
+        WHERE p.author_id = p_following_id AND p.fanned_out
+        ON CONFLICT DO NOTHING;
+    END IF;
+END;
+$$;
+
+DROP INDEX posts_backfill_idx;
+
+ALTER TABLE posts DROP COLUMN fanout_skipped;
@@ -0,0 +1,35 @@
+-- Посты авторов, у которых подписчиков больше лимита раскладки, помечаются
+-- fanout_skipped: они читаются из posts при запросе ленты, и
+-- kursach timeline backfill больше их не перебирает.
+ALTER TABLE posts ADD COLUMN fanout_skipped BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX posts_backfill_idx ON posts (post_id)
+WHERE NOT fanned_out AND NOT fanout_skipped;
+
+-- При подписке в ленту копируются только последние 200 разложенных постов
+-- автора, а не все: у давнего автора их могут быть десятки тысяч.
+CREATE OR REPLACE PROCEDURE follow(p_follower_id INTEGER, p_following_id INTEGER)
+LANGUAGE plpgsql AS $$
+BEGIN
+    IF is_blocked_between(p_follower_id, p_following_id) THEN
+        RAISE EXCEPTION 'user is blocked' USING ERRCODE = 'UB001';
+    END IF;
+
+    INSERT INTO follows (follower_id, following_id)
+    VALUES (p_follower_id, p_following_id)
+    ON CONFLICT DO NOTHING;
+
+    IF FOUND THEN
+        INSERT INTO notifications (user_id, type_id, entity_id)
+        VALUES (p_following_id, 3, p_follower_id);
+
+        INSERT INTO timeline_entries (user_id, post_id, author_id, created_at)
+        SELECT p_follower_id, p.post_id, p.author_id, p.created_at
+        FROM posts p
+        WHERE p.author_id = p_following_id AND p.fanned_out
+        ORDER BY p.created_at DESC, p.post_id DESC
+        LIMIT 200
+        ON CONFLICT DO NOTHING;
+    END IF;
+END;
+$$;
@@ -3,43 +3,67 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+var ErrPostNotFound = errors.New("post not found")
+
+// FeedCommentPreview — сколько первых комментариев встраивается в пост ленты.
+const FeedCommentPreview = 3
+
 type PostStorage struct {
 	db *sql.DB
 }
 
+// Post — новый пост. ImageURL и Images — обложка (первая картинка из Attachments).
 type Post struct {
-	ID          string    `json:"id"`
-	AuthorID    int       `json:"author_id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	ImageURL    string    `json:"image_url"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          string       `json:"id"`
+	AuthorID    int          `json:"author_id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	ImageURL    string       `json:"image_url"`
+	Images      Images       `json:"images"`
+	Attachments []Attachment `json:"attachments"`
+	CreatedAt   time.Time    `json:"created_at"`
 }
 
+// PostResponse — пост в ленте. CommentCount — все комментарии поста,
+// Comments — первые FeedCommentPreview из них, остальные отдаёт
+// GET /posts/{id}/comments.
 type PostResponse struct {
-	PostID      int            `json:"post_id"`
-	Title       string         `json:"title"`
-	Description string         `json:"description"`
-	ImageURL    string         `json:"image_url"`
-	CreatedAt   time.Time      `json:"created_at"`
-	AuthorID    int            `json:"author_id"`
-	AuthorName  string         `json:"author_name"`
-	LikeCount   int            `json:"like_count"`
-	Comments    []CommentBrief `json:"comments"`
-	Tags        []TagBrief     `json:"tags"`
+	PostID       int            `json:"post_id"`
+	Title        string         `json:"title"`
+	Description  string         `json:"description"`
+	ImageURL     string         `json:"image_url"`
+	Images       Images         `json:"images"`
+	Attachments  []Attachment   `json:"attachments"`
+	CreatedAt    time.Time      `json:"created_at"`
+	EditedAt     *time.Time     `json:"edited_at"`
+	AuthorID     int            `json:"author_id"`
+	AuthorName   string         `json:"author_name"`
+	LikeCount    int            `json:"like_count"`
+	CommentCount int            `json:"comment_count"`
+	Comments     []CommentBrief `json:"comments"`
+	Tags         []TagBrief     `json:"tags"`
 }
 
 type CommentBrief struct {
-	CommentID int       `json:"comment_id"`
-	PostID    int       `json:"post_id"`
-	Comment   string    `json:"comment"`
-	CreatedAt time.Time `json:"created_at"`
-	AuthorID  int       `json:"author_id"`
-	AuthorTag string    `json:"author_tag"`
+	CommentID  int            `json:"comment_id"`
+	PostID     int            `json:"post_id"`
+	ParentID   *int           `json:"parent_id"`
+	Comment    string         `json:"comment"`
+	CreatedAt  time.Time      `json:"created_at"`
+	EditedAt   *time.Time     `json:"edited_at"`
+	AuthorID   int            `json:"author_id"`
+	AuthorTag  string         `json:"author_tag"`
+	ReplyCount int            `json:"reply_count"`
+	LikeCount  int            `json:"like_count"`
+	Replies    []CommentBrief `json:"replies,omitempty"`
 }
 
 type TagBrief struct {
@@ -51,20 +75,36 @@ func NewPostStorage(db *sql.DB) *PostStorage {
 	return &PostStorage{db: db}
 }
 
+// CreatePost сохраняет пост вместе с вложениями в одной транзакции.
 func (s *PostStorage) CreatePost(ctx context.Context, post *Post) error {
 	const query = `
-		INSERT INTO posts (author_id, title, description, image_url, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO posts (author_id, title, description, image_url, images, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING post_id
 	`
-	return s.db.QueryRowContext(ctx, query,
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query,
 		post.AuthorID,
 		post.Title,
 		post.Description,
 		post.ImageURL,
+		post.Images,
 		post.CreatedAt,
 	).Scan(&post.ID)
+	if err != nil {
+		return err
+	}
+	if err := insertAttachments(ctx, tx, post.ID, post.Attachments); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
+
 func (s *PostStorage) GetOrCreateTag(ctx context.Context, name string) (int, error) {
 	var tagID int
 	err := s.db.QueryRowContext(ctx, "SELECT tag_id FROM tags WHERE name=$1", name).Scan(&tagID)
@@ -105,135 +145,297 @@ func (s *PostStorage) GetTagsByPostID(ctx context.Context, postID int) ([]TagBri
 	return tags, nil
 }
 
-func (s *PostStorage) GetPosts(ctx context.Context, userID *int, startIndex, amount int) ([]PostResponse, bool, error) {
-	var (
-		posts []PostResponse
-		rows  *sql.Rows
-		err   error
-	)
-	log.Printf("Fetching posts: startIndex=%d, amount=%d, userID=%d", startIndex, amount, userID)
+// PostsQuery задаёт страницу ленты: посты по убыванию (post_created_at,
+// post_id), Limit штук после After. Offset — устаревший startIndex для
+// клиентов без курсора, вместе с After не используется. Посты и
+// комментарии авторов, с которыми у ViewerID есть блокировка, не
+// возвращаются (0 — анонимный читатель).
+type PostsQuery struct {
+	AuthorID *int
+	Limit    int
+	Offset   int
+	After    *PostCursor
+	ViewerID int
+}
+
+// PostCursor — позиция в ленте: последний пост предыдущей страницы.
+type PostCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int       `json:"id"`
+}
 
-	baseQuery := `
-		SELECT post_id, title, description, image_url, post_created_at, author_id, author_user_name, like_count
+// GetPosts возвращает страницу всех постов или постов одного автора и
+// курсор следующей страницы (nil, если она последняя).
+func (s *PostStorage) GetPosts(ctx context.Context, q PostsQuery) ([]PostResponse, *PostCursor, error) {
+	log.Printf("Fetching posts: offset=%d, limit=%d, after=%v", q.Offset, q.Limit, q.After)
+
+	query := `
+		SELECT post_id, title, description, image_url, images, post_created_at, edited_at, author_id, author_user_name, like_count, comment_count
 		FROM view_post_summary
+		WHERE TRUE
 	`
-	args := []interface{}{}
+	var args []any
+	if q.AuthorID != nil {
+		args = append(args, *q.AuthorID)
+		query += fmt.Sprintf(` AND author_id = $%d`, len(args))
+	}
+	query, args = pagePostsQuery(query, args, q)
+	return s.queryPosts(ctx, q.Limit, q.ViewerID, query, args...)
+}
 
-	if userID != nil {
-		baseQuery += ` WHERE author_id = $1 ORDER BY post_created_at DESC LIMIT $2 OFFSET $3`
-		args = append(args, *userID, amount, startIndex)
-	} else {
-		baseQuery += ` ORDER BY post_created_at DESC LIMIT $1 OFFSET $2`
-		args = append(args, amount, startIndex)
+// pagePostsQuery дописывает к запросу условие курсора, порядок и лимит.
+// Строк берётся на одну больше: лишняя показывает, есть ли следующая
+// страница, без COUNT(*) по всей ленте.
+func pagePostsQuery(query string, args []any, q PostsQuery) (string, []any) {
+	if q.ViewerID != 0 {
+		args = append(args, q.ViewerID)
+		query += fmt.Sprintf(` AND NOT is_blocked_between($%d, author_id)`, len(args))
 	}
+	if q.After != nil {
+		args = append(args, q.After.CreatedAt, q.After.ID)
+		query += fmt.Sprintf(` AND (post_created_at, post_id) < ($%d, $%d)`, len(args)-1, len(args))
+	}
+	args = append(args, q.Limit+1)
+	query += fmt.Sprintf(` ORDER BY post_created_at DESC, post_id DESC LIMIT $%d`, len(args))
+	if q.After == nil && q.Offset > 0 {
+		args = append(args, q.Offset)
+		query += fmt.Sprintf(` OFFSET $%d`, len(args))
+	}
+	return query, args
+}
 
-	rows, err = s.db.QueryContext(ctx, baseQuery, args...)
+// queryPosts читает страницу постов из view_post_summary (запрос должен
+// вернуть до limit+1 строк) и догружает комментарии, теги и вложения
+// пакетно — число запросов не зависит от размера страницы.
+func (s *PostStorage) queryPosts(ctx context.Context, limit, viewerID int, query string, args ...any) ([]PostResponse, *PostCursor, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, false, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
+	posts := []PostResponse{}
 	for rows.Next() {
 		var post PostResponse
 		if err := rows.Scan(
-			&post.PostID, &post.Title, &post.Description, &post.ImageURL,
-			&post.CreatedAt, &post.AuthorID, &post.AuthorName, &post.LikeCount,
+			&post.PostID, &post.Title, &post.Description, &post.ImageURL, &post.Images,
+			&post.CreatedAt, &post.EditedAt, &post.AuthorID, &post.AuthorName, &post.LikeCount, &post.CommentCount,
 		); err != nil {
-			return nil, false, err
-		}
-
-		// Комментарии
-		comments, err := s.GetCommentsByPostID(ctx, post.PostID)
-		if err != nil {
-			return nil, false, err
-		}
-		post.Comments = comments
-
-		// Теги
-		tags, err := s.GetTagsByPostID(ctx, post.PostID)
-		if err != nil {
-			return nil, false, err
+			return nil, nil, err
 		}
-		post.Tags = tags
-
 		posts = append(posts, post)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	rows.Close()
 
-	// Проверка, есть ли еще посты
-	var totalCount int
-	countQuery := "SELECT COUNT(*) FROM view_post_summary"
-	if userID != nil {
-		countQuery += " WHERE author_id = $1"
-		err = s.db.QueryRowContext(ctx, countQuery, *userID).Scan(&totalCount)
-	} else {
-		err = s.db.QueryRowContext(ctx, countQuery).Scan(&totalCount)
+	var next *PostCursor
+	if len(posts) > limit {
+		posts = posts[:limit]
+		last := posts[len(posts)-1]
+		next = &PostCursor{CreatedAt: last.CreatedAt, ID: last.PostID}
 	}
-	if err != nil {
-		return nil, false, err
+	if err := s.attachPostDetails(ctx, posts, viewerID); err != nil {
+		return nil, nil, err
 	}
-
-	hasMore := startIndex+amount < totalCount
-
-	return posts, hasMore, nil
+	return posts, next, nil
 }
 
-func (s *PostStorage) DeletePost(ctx context.Context, postID int) error {
-	const query = `DELETE FROM posts WHERE post_id = $1`
-	_, err := s.db.ExecContext(ctx, query, postID)
-	return err
-}
-
-func (s *PostStorage) GetFavoritePosts(ctx context.Context, userID, startIndex, amount int) ([]PostResponse, bool, error) {
-	const baseQuery = `
-		SELECT post_id, title, description, image_url, post_created_at, author_id, author_user_name, like_count
-		FROM view_favorite_post_summary
-		WHERE favorited_by_user_id = $1
-		ORDER BY post_created_at DESC
-		LIMIT $2 OFFSET $3
-	`
+// attachPostDetails загружает первые комментарии, теги и вложения для
+// всех постов тремя запросами. Комментарии авторов, с которыми у viewerID
+// есть блокировка, пропускаются.
+func (s *PostStorage) attachPostDetails(ctx context.Context, posts []PostResponse, viewerID int) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(posts))
+	byID := make(map[int]*PostResponse, len(posts))
+	for i := range posts {
+		ids[i] = int64(posts[i].PostID)
+		byID[posts[i].PostID] = &posts[i]
+		posts[i].Attachments = []Attachment{}
+	}
 
-	rows, err := s.db.QueryContext(ctx, baseQuery, userID, amount, startIndex)
+	// Комментарии: первые FeedCommentPreview верхнего уровня в каждом посте
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT comment_id, post_id, parent_id, comment, comment_created_at, edited_at, author_id, author_user_tag,
+		       reply_count, like_count
+		FROM (
+			SELECT v.*, row_number() OVER (PARTITION BY post_id ORDER BY comment_created_at, comment_id) AS n
+			FROM view_comment_with_author_tag v
+			WHERE post_id = ANY($1) AND parent_id IS NULL AND NOT is_blocked_between($3, author_id)
+		) c
+		WHERE n <= $2
+		ORDER BY post_id, comment_created_at, comment_id
+	`, pq.Array(ids), FeedCommentPreview, viewerID)
 	if err != nil {
-		return nil, false, err
+		return err
 	}
 	defer rows.Close()
-
-	var posts []PostResponse
 	for rows.Next() {
-		var post PostResponse
+		var c CommentBrief
 		if err := rows.Scan(
-			&post.PostID, &post.Title, &post.Description, &post.ImageURL,
-			&post.CreatedAt, &post.AuthorID, &post.AuthorName, &post.LikeCount,
+			&c.CommentID, &c.PostID, &c.ParentID, &c.Comment, &c.CreatedAt, &c.EditedAt,
+			&c.AuthorID, &c.AuthorTag, &c.ReplyCount, &c.LikeCount,
 		); err != nil {
-			return nil, false, err
+			return err
 		}
+		byID[c.PostID].Comments = append(byID[c.PostID].Comments, c)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
 
-		comments, err := s.GetCommentsByPostID(ctx, post.PostID)
-		if err != nil {
-			return nil, false, err
+	// Теги
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT pt.post_id, t.tag_id, t.name
+		FROM post_tags pt
+		JOIN tags t ON t.tag_id = pt.tag_id
+		WHERE pt.post_id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			postID int
+			t      TagBrief
+		)
+		if err := rows.Scan(&postID, &t.TagID, &t.Name); err != nil {
+			return err
 		}
-		post.Comments = comments
-
-		tags, err := s.GetTagsByPostID(ctx, post.PostID)
-		if err != nil {
-			return nil, false, err
+		byID[postID].Tags = append(byID[postID].Tags, t)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	// Вложения
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT post_id, attachment_id, kind, media_key, content_type, alt_text,
+		       COALESCE(width, 0), COALESCE(height, 0), images
+		FROM post_attachments
+		WHERE post_id = ANY($1)
+		ORDER BY post_id, position
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			postID int
+			a      Attachment
+		)
+		if err := rows.Scan(&postID, &a.ID, &a.Kind, &a.URL, &a.ContentType, &a.AltText, &a.Width, &a.Height, &a.Images); err != nil {
+			return err
 		}
-		post.Tags = tags
+		byID[postID].Attachments = append(byID[postID].Attachments, a)
+	}
+	return rows.Err()
+}
 
-		posts = append(posts, post)
+func (s *PostStorage) GetPostAuthorID(ctx context.Context, postID int) (int, error) {
+	const query = `SELECT author_id FROM posts WHERE post_id = $1`
+	var authorID int
+	err := s.db.QueryRowContext(ctx, query, postID).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		return 0, ErrPostNotFound
+	}
+	return authorID, err
+}
+
+// GetVisiblePostAuthorID — как GetPostAuthorID, но скрытый модератором пост
+// считается несуществующим.
+func (s *PostStorage) GetVisiblePostAuthorID(ctx context.Context, postID int) (int, error) {
+	const query = `SELECT author_id FROM posts WHERE post_id = $1 AND NOT is_hidden`
+	var authorID int
+	err := s.db.QueryRowContext(ctx, query, postID).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		return 0, ErrPostNotFound
 	}
+	return authorID, err
+}
 
-	var totalCount int
-	err = s.db.QueryRowContext(ctx,
-		`SELECT COUNT(*) FROM view_favorite_post_summary WHERE favorited_by_user_id = $1`, userID,
-	).Scan(&totalCount)
+// DeletePost удаляет пост и возвращает ключи его файлов в медиахранилище,
+// чтобы вызывающий удалил и их.
+func (s *PostStorage) DeletePost(ctx context.Context, postID int) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	hasMore := startIndex+amount < totalCount
+	keys, err := deletePost(ctx, tx, postID)
+	if err != nil {
+		return nil, err
+	}
+	return keys, tx.Commit()
+}
 
-	return posts, hasMore, nil
+// deletePost удаляет пост в транзакции tx; ревизии, вложения и записи лент
+// удаляются каскадно, а ключи всех их файлов возвращаются.
+func deletePost(ctx context.Context, tx *sql.Tx, postID int) ([]string, error) {
+	var (
+		imageURL string
+		images   Images
+	)
+	err := tx.QueryRowContext(ctx,
+		`SELECT image_url, images FROM posts WHERE post_id = $1 FOR UPDATE`, postID,
+	).Scan(&imageURL, &images)
+	if err == sql.ErrNoRows {
+		return nil, ErrPostNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := getAttachments(ctx, tx, postID)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := getRevisionAttachments(ctx, tx, postID)
+	if err != nil {
+		return nil, err
+	}
+	cover := Attachment{URL: imageURL, Images: images}
+	keys := MediaKeys(append(append(attachments, revisions...), cover))
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM posts WHERE post_id = $1`, postID); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// MediaKeys собирает уникальные непустые ключи файлов вложений.
+func MediaKeys(attachments []Attachment) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, a := range attachments {
+		for _, key := range a.MediaKeys() {
+			if key != "" && !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// GetFavoritePosts возвращает страницу избранного пользователя в том же
+// порядке, что и лента; AuthorID в q не учитывается.
+func (s *PostStorage) GetFavoritePosts(ctx context.Context, userID int, q PostsQuery) ([]PostResponse, *PostCursor, error) {
+	query := `
+		SELECT post_id, title, description, image_url, images, post_created_at, edited_at, author_id, author_user_name, like_count, comment_count
+		FROM view_favorite_post_summary
+		WHERE favorited_by_user_id = $1
+	`
+	query, args := pagePostsQuery(query, []any{userID}, q)
+	return s.queryPosts(ctx, q.Limit, q.ViewerID, query, args...)
 }
 
 func (s *PostStorage) GetAllTags(ctx context.Context) ([]map[string]interface{}, error) {
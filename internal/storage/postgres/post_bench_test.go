@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// Бенчмарк ленты на заполненной базе. Нужна пустая база PostgreSQL, в
+// которой можно создать схему:
+//
+//	KURSACH_BENCH_DSN="host=localhost user=postgres password=postgres dbname=bench sslmode=disable" \
+//		go test ./internal/storage/postgres -run '^$' -bench Feed
+//
+// Сравниваются прежняя загрузка (страница, затем комментарии, теги и
+// вложения отдельным запросом на каждый пост и COUNT(*) по всей ленте) и
+// нынешняя GetPosts с пакетной догрузкой через ANY($1).
+
+const (
+	benchUsers           = 200
+	benchPosts           = 5000
+	benchCommentsPerPost = 5
+	benchSchema          = "feed_bench"
+)
+
+func BenchmarkFeed(b *testing.B) {
+	dsn := os.Getenv("KURSACH_BENCH_DSN")
+	if dsn == "" {
+		b.Skip("KURSACH_BENCH_DSN is not set")
+	}
+	ctx := context.Background()
+	db := openBenchDB(b, dsn)
+	s := NewPostStorage(db)
+
+	for _, amount := range []int{10, 50} {
+		b.Run(fmt.Sprintf("per-post/amount=%d", amount), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, _, err := getPostsPerPost(ctx, s, amount); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("batched/amount=%d", amount), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, _, err := s.GetPosts(ctx, PostsQuery{Limit: amount}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// openBenchDB создаёт отдельную схему, применяет миграции и заполняет её.
+// После бенчмарка схема удаляется.
+func openBenchDB(b *testing.B, dsn string) *sql.DB {
+	b.Helper()
+	ctx := context.Background()
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer admin.Close()
+	if _, err := admin.ExecContext(ctx, `DROP SCHEMA IF EXISTS `+benchSchema+` CASCADE; CREATE SCHEMA `+benchSchema); err != nil {
+		b.Fatal(err)
+	}
+
+	// lib/pq передаёт неизвестные параметры DSN серверу как настройки сессии
+	db, err := sql.Open("postgres", dsn+" search_path="+benchSchema)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		db.Close()
+		if admin, err := sql.Open("postgres", dsn); err == nil {
+			admin.Exec(`DROP SCHEMA IF EXISTS ` + benchSchema + ` CASCADE`)
+			admin.Close()
+		}
+	})
+
+	if _, err := (&Storage{db: db}).MigrateUp(ctx); err != nil {
+		b.Fatal(err)
+	}
+	seed := []string{
+		fmt.Sprintf(`INSERT INTO users (email, password)
+			SELECT 'user' || n || '@example.com', 'x' FROM generate_series(1, %d) n`, benchUsers),
+		`INSERT INTO user_info (user_id, user_name, user_tag)
+			SELECT user_id, 'user' || user_id, 'user' || user_id FROM users`,
+		fmt.Sprintf(`INSERT INTO posts (author_id, title, description, created_at)
+			SELECT 1 + n %% %d, 'post ' || n, 'description', now() - n * interval '1 minute'
+			FROM generate_series(1, %d) n`, benchUsers, benchPosts),
+		`INSERT INTO tags (name) SELECT 'tag' || n FROM generate_series(1, 50) n`,
+		`INSERT INTO post_tags (post_id, tag_id)
+			SELECT post_id, 1 + post_id % 50 FROM posts
+			UNION ALL
+			SELECT post_id, 1 + (post_id + 7) % 50 FROM posts`,
+		`INSERT INTO post_attachments (post_id, position, kind, media_key, content_type)
+			SELECT post_id, 0, 'image', 'posts/' || post_id || '.jpg', 'image/jpeg' FROM posts`,
+		fmt.Sprintf(`INSERT INTO comments (author_id, post_id, comment)
+			SELECT 1 + (p.post_id + n) %% %d, p.post_id, 'comment ' || n
+			FROM posts p, generate_series(1, %d) n`, benchUsers, benchCommentsPerPost),
+		`ANALYZE`,
+	}
+	for _, q := range seed {
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return db
+}
+
+// getPostsPerPost повторяет загрузку ленты до пакетных запросов: 1 + 3·amount
+// запросов и COUNT(*) по всему представлению.
+func getPostsPerPost(ctx context.Context, s *PostStorage, amount int) ([]PostResponse, bool, error) {
+	const query = `
+		SELECT post_id, title, description, image_url, images, post_created_at, edited_at, author_id, author_user_name, like_count, comment_count
+		FROM view_post_summary
+		ORDER BY post_created_at DESC LIMIT $1 OFFSET 0
+	`
+	rows, err := s.db.QueryContext(ctx, query, amount)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var posts []PostResponse
+	for rows.Next() {
+		var post PostResponse
+		if err := rows.Scan(
+			&post.PostID, &post.Title, &post.Description, &post.ImageURL, &post.Images,
+			&post.CreatedAt, &post.EditedAt, &post.AuthorID, &post.AuthorName, &post.LikeCount, &post.CommentCount,
+		); err != nil {
+			return nil, false, err
+		}
+		if post.Comments, _, err = s.GetCommentsByPostID(ctx, post.PostID, CommentsQuery{Limit: FeedCommentPreview}); err != nil {
+			return nil, false, err
+		}
+		if post.Tags, err = s.GetTagsByPostID(ctx, post.PostID); err != nil {
+			return nil, false, err
+		}
+		if post.Attachments, err = s.GetAttachmentsByPostID(ctx, post.PostID); err != nil {
+			return nil, false, err
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM view_post_summary`).Scan(&total); err != nil {
+		return nil, false, err
+	}
+	return posts, amount < total, nil
+}
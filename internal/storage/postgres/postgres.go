@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	_ "github.com/lib/pq"
@@ -15,6 +16,15 @@ func (s *Storage) DB() *sql.DB {
 	return s.db
 }
 
+// Ping проверяет соединение с базой (для /readyz).
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
 func New(cfg config.PostgresCfg) (*Storage, error) {
 	const op = "storage.postgres.new"
 
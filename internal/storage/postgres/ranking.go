@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PostSignals — то, что известно о посте-кандидате в ленту «Для вас» с
+// точки зрения конкретного пользователя.
+type PostSignals struct {
+	PostID        int
+	AuthorID      int
+	CreatedAt     time.Time
+	Likes         int
+	Comments      int
+	Favorites     int
+	FollowsAuthor bool // пользователь подписан на автора
+	FolloweeLikes int  // лайки от тех, на кого пользователь подписан
+	TagAffinity   int  // сколько раз пользователь лайкал, добавлял в избранное или комментировал посты с тегами этого поста
+}
+
+// GetPostSignals возвращает до limit самых новых видимых постов, созданных
+// в промежутке (since, until], кроме собственных постов пользователя и
+// постов авторов, с которыми есть блокировка в любую сторону.
+func (s *PostStorage) GetPostSignals(ctx context.Context, userID int, since, until time.Time, limit int) ([]PostSignals, error) {
+	const query = `
+		WITH engaged_tags AS (
+			SELECT pt.tag_id, count(*) AS weight
+			FROM post_tags pt
+			JOIN (
+				SELECT post_id FROM likes WHERE user_id = $1
+				UNION ALL
+				SELECT post_id FROM favorite_posts WHERE user_id = $1
+				UNION ALL
+				SELECT post_id FROM comments WHERE author_id = $1 AND NOT is_hidden
+			) e ON e.post_id = pt.post_id
+			GROUP BY pt.tag_id
+		)
+		SELECT vps.post_id, vps.author_id, vps.post_created_at, vps.like_count, vps.comment_count,
+		       (SELECT count(*) FROM favorite_posts fp WHERE fp.post_id = vps.post_id)::INTEGER,
+		       EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = $1 AND f.following_id = vps.author_id),
+		       (SELECT count(*) FROM likes l
+		        JOIN follows f ON f.following_id = l.user_id AND f.follower_id = $1
+		        WHERE l.post_id = vps.post_id)::INTEGER,
+		       COALESCE((SELECT sum(et.weight) FROM post_tags pt
+		                 JOIN engaged_tags et ON et.tag_id = pt.tag_id
+		                 WHERE pt.post_id = vps.post_id), 0)::INTEGER
+		FROM view_post_summary vps
+		WHERE vps.author_id <> $1
+		  AND vps.post_created_at > $2 AND vps.post_created_at <= $3
+		  AND NOT is_blocked_between($1, vps.author_id)
+		ORDER BY vps.post_created_at DESC, vps.post_id DESC
+		LIMIT $4
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, since, until, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []PostSignals
+	for rows.Next() {
+		var c PostSignals
+		if err := rows.Scan(
+			&c.PostID, &c.AuthorID, &c.CreatedAt, &c.Likes, &c.Comments,
+			&c.Favorites, &c.FollowsAuthor, &c.FolloweeLikes, &c.TagAffinity,
+		); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// GetPostsByIDs возвращает видимые посты в порядке ids; скрытые и
+// удалённые пропускаются. Первые комментарии — как в ленте viewerID.
+func (s *PostStorage) GetPostsByIDs(ctx context.Context, viewerID int, ids []int) ([]PostResponse, error) {
+	if len(ids) == 0 {
+		return []PostResponse{}, nil
+	}
+	postIDs := make([]int64, len(ids))
+	for i, id := range ids {
+		postIDs[i] = int64(id)
+	}
+
+	const query = `
+		SELECT post_id, title, description, image_url, images, post_created_at, edited_at, author_id, author_user_name, like_count, comment_count
+		FROM view_post_summary
+		WHERE post_id = ANY($1)
+	`
+	posts, _, err := s.queryPosts(ctx, len(ids), viewerID, query, pq.Array(postIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]PostResponse, len(posts))
+	for _, p := range posts {
+		byID[p.PostID] = p
+	}
+	ordered := make([]PostResponse, 0, len(posts))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			ordered = append(ordered, p)
+		}
+	}
+	return ordered, nil
+}
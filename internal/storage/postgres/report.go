@@ -1,12 +1,582 @@
 package postgres
 
-import "context"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Типы объектов жалоб и доказательств (report_type_id)
+const (
+	ReportTypePost    = 1
+	ReportTypeComment = 2
+	ReportTypeUser    = 3
+)
+
+// Статусы жалоб
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+// Действия модератора при разборе жалобы
+const (
+	ModerationAssign        = "assign"
+	ModerationDismiss       = "dismiss"
+	ModerationHideContent   = "hide_content"
+	ModerationDeleteContent = "delete_content"
+	ModerationSuspendAuthor = "suspend_author"
+)
+
+var (
+	ErrReportExists   = errors.New("report already exists")
+	ErrReportNotFound = errors.New("report not found")
+	ErrReportClosed   = errors.New("report already closed")
+	ErrReportTarget   = errors.New("reported content no longer exists")
+	ErrUnknownReason  = errors.New("unknown report reason")
+	ErrActionTarget   = errors.New("action is not applicable to the reported object")
+	ErrAssignee       = errors.New("assignee is not a moderator")
+)
+
+type Report struct {
+	ID           int              `json:"report_id"`
+	ReporterID   int              `json:"reporter_id"`
+	ReportTypeID int              `json:"report_type_id"`
+	TargetID     int              `json:"target_id"`
+	Reason       string           `json:"reason"`
+	Description  string           `json:"description"`
+	Status       string           `json:"status"`
+	AssigneeID   *int             `json:"assignee_id"`
+	CreatedAt    time.Time        `json:"created_at"`
+	ResolvedAt   *time.Time       `json:"resolved_at"`
+	Content      *ReportedContent `json:"content"`
+	Evidence     []ReportEvidence `json:"evidence"`
+}
+
+// ReportedContent — содержимое, на которое пожаловались (nil, если уже удалено).
+// Для жалобы на пользователя AuthorID — сам пользователь.
+type ReportedContent struct {
+	AuthorID    int    `json:"author_id"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+	UserName    string `json:"user_name,omitempty"`
+	UserTag     string `json:"user_tag,omitempty"`
+	Hidden      bool   `json:"hidden"`
+}
+
+// ReportEvidence — ссылка на пост или комментарий, приложенная к жалобе.
+type ReportEvidence struct {
+	ReportTypeID int `json:"report_type_id"`
+	TargetID     int `json:"target_id"`
+}
+
+// ReportReason — причина жалобы из справочника report_reasons.
+type ReportReason struct {
+	Code  string `json:"code"`
+	Title string `json:"title"`
+}
+
+type ModerationAction struct {
+	ID          int       `json:"action_id"`
+	ReportID    int       `json:"report_id"`
+	ModeratorID int       `json:"moderator_id"`
+	Action      string    `json:"action"`
+	Note        string    `json:"note"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// GetReportReasons возвращает активные причины жалоб.
+func (s *PostStorage) GetReportReasons(ctx context.Context) ([]ReportReason, error) {
+	const query = `
+		SELECT reason_code, title
+		FROM report_reasons
+		WHERE is_active
+		ORDER BY sort_order, reason_code
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reasons []ReportReason
+	for rows.Next() {
+		var reason ReportReason
+		if err := rows.Scan(&reason.Code, &reason.Title); err != nil {
+			return nil, err
+		}
+		reasons = append(reasons, reason)
+	}
+	return reasons, rows.Err()
+}
+
+// CreateReport создаёт жалобу вместе с доказательствами и возвращает её ID.
+// Повторная жалоба того же пользователя на тот же объект возвращает ErrReportExists,
+// несуществующий объект или доказательство — ErrPostNotFound, ErrCommentNotFound
+// или ErrUserNotFound.
+func (s *PostStorage) CreateReport(ctx context.Context, report *Report) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var reasonExists bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM report_reasons WHERE reason_code = $1 AND is_active)`, report.Reason,
+	).Scan(&reasonExists)
+	if err != nil {
+		return err
+	}
+	if !reasonExists {
+		return ErrUnknownReason
+	}
+
+	// Объект жалобы и доказательства блокируются до конца транзакции,
+	// чтобы их не удалили между проверкой и вставкой
+	if err := lockReportTarget(ctx, tx, report.ReportTypeID, report.TargetID); err != nil {
+		return err
+	}
+	for _, e := range report.Evidence {
+		if err := lockReportTarget(ctx, tx, e.ReportTypeID, e.TargetID); err != nil {
+			return err
+		}
+	}
+
+	const query = `
+		INSERT INTO reports (reporter_id, target_id, report_type_id, reason_code, description)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (reporter_id, report_type_id, target_id) DO NOTHING
+		RETURNING report_id, status, created_at
+	`
+	err = tx.QueryRowContext(ctx, query,
+		report.ReporterID, report.TargetID, report.ReportTypeID, report.Reason, report.Description,
+	).Scan(&report.ID, &report.Status, &report.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrReportExists
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, e := range report.Evidence {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO report_evidence (report_id, report_type_id, target_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+			report.ID, e.ReportTypeID, e.TargetID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const reportSelect = `
+	SELECT r.report_id, r.reporter_id, r.report_type_id, r.target_id, r.reason_code, r.description,
+	       r.status, r.assignee_id, r.created_at, r.resolved_at,
+	       COALESCE(p.author_id, c.author_id, u.user_id), p.title, p.description, p.image_url, c.comment,
+	       u.user_name, u.user_tag, COALESCE(p.is_hidden, c.is_hidden, false)
+	FROM reports r
+	LEFT JOIN posts p ON r.report_type_id = 1 AND p.post_id = r.target_id
+	LEFT JOIN comments c ON r.report_type_id = 2 AND c.comment_id = r.target_id
+	LEFT JOIN user_info u ON r.report_type_id = 3 AND u.user_id = r.target_id
+`
+
+func scanReport(row interface{ Scan(...any) error }) (Report, error) {
+	var (
+		report                          Report
+		assigneeID, authorID            sql.NullInt64
+		resolvedAt                      sql.NullTime
+		title, description, image, text sql.NullString
+		userName, userTag               sql.NullString
+		hidden                          bool
+	)
+	err := row.Scan(
+		&report.ID, &report.ReporterID, &report.ReportTypeID, &report.TargetID, &report.Reason, &report.Description,
+		&report.Status, &assigneeID, &report.CreatedAt, &resolvedAt,
+		&authorID, &title, &description, &image, &text, &userName, &userTag, &hidden,
+	)
+	if err != nil {
+		return Report{}, err
+	}
+	if assigneeID.Valid {
+		id := int(assigneeID.Int64)
+		report.AssigneeID = &id
+	}
+	if resolvedAt.Valid {
+		report.ResolvedAt = &resolvedAt.Time
+	}
+	if authorID.Valid {
+		report.Content = &ReportedContent{
+			AuthorID:    int(authorID.Int64),
+			Title:       title.String,
+			Description: description.String,
+			ImageURL:    image.String,
+			Comment:     text.String,
+			UserName:    userName.String,
+			UserTag:     userTag.String,
+			Hidden:      hidden,
+		}
+	}
+	return report, nil
+}
+
+// GetReports возвращает жалобы с указанным статусом, старые — первыми.
+func (s *PostStorage) GetReports(ctx context.Context, status string, limit, offset int) ([]Report, error) {
+	query := reportSelect + `
+		WHERE r.status = $1
+		ORDER BY r.created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := s.db.QueryContext(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []Report
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.attachEvidence(ctx, reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func (s *PostStorage) GetReport(ctx context.Context, reportID int) (*Report, error) {
+	report, err := scanReport(s.db.QueryRowContext(ctx, reportSelect+` WHERE r.report_id = $1`, reportID))
+	if err == sql.ErrNoRows {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []Report{report}
+	if err := s.attachEvidence(ctx, reports); err != nil {
+		return nil, err
+	}
+	return &reports[0], nil
+}
+
+// attachEvidence загружает доказательства для всех жалоб одним запросом.
+func (s *PostStorage) attachEvidence(ctx context.Context, reports []Report) error {
+	if len(reports) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(reports))
+	byID := make(map[int]*Report, len(reports))
+	for i := range reports {
+		ids[i] = int64(reports[i].ID)
+		byID[reports[i].ID] = &reports[i]
+		reports[i].Evidence = []ReportEvidence{}
+	}
 
-func (s *PostStorage) CreateReport(ctx context.Context, reporterID, targetID, reportTypeID int, description string) error {
 	const query = `
-		INSERT INTO reports (reporter_id, target_id, report_type_id, description)
+		SELECT report_id, report_type_id, target_id
+		FROM report_evidence
+		WHERE report_id = ANY($1)
+		ORDER BY report_id, report_type_id, target_id
+	`
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			reportID int
+			e        ReportEvidence
+		)
+		if err := rows.Scan(&reportID, &e.ReportTypeID, &e.TargetID); err != nil {
+			return err
+		}
+		byID[reportID].Evidence = append(byID[reportID].Evidence, e)
+	}
+	return rows.Err()
+}
+
+// AssignReport назначает открытую жалобу модератору.
+func (s *PostStorage) AssignReport(ctx context.Context, reportID, moderatorID, assigneeID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenReport(ctx, tx, reportID, nil); err != nil {
+		return err
+	}
+	var canModerate bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT role IN ('moderator', 'admin') AND suspended_at IS NULL FROM users WHERE user_id = $1`, assigneeID,
+	).Scan(&canModerate)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if !canModerate {
+		return ErrAssignee
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE reports SET assignee_id = $2 WHERE report_id = $1`, reportID, assigneeID); err != nil {
+		return err
+	}
+	note := fmt.Sprintf("assigned to %d", assigneeID)
+	if err := logModerationAction(ctx, tx, reportID, moderatorID, ModerationAssign, note); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ResolveReport закрывает жалобу, применяя действие к объекту жалобы,
+// и записывает действие в журнал модерации. Всё выполняется в одной транзакции.
+func (s *PostStorage) ResolveReport(ctx context.Context, reportID, moderatorID int, action, note string) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var report Report
+	if err := lockOpenReport(ctx, tx, reportID, &report); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	status := ReportStatusResolved
+	switch action {
+	case ModerationDismiss:
+		status = ReportStatusDismissed
+	case ModerationHideContent:
+		if report.ReportTypeID == ReportTypeUser {
+			return nil, ErrActionTarget
+		}
+		err = setContentHidden(ctx, tx, report.ReportTypeID, report.TargetID)
+	case ModerationDeleteContent:
+		if report.ReportTypeID == ReportTypeUser {
+			return nil, ErrActionTarget
+		}
+		keys, err = deleteContent(ctx, tx, report.ReportTypeID, report.TargetID)
+	case ModerationSuspendAuthor:
+		err = suspendContentAuthor(ctx, tx, report.ReportTypeID, report.TargetID)
+	default:
+		return nil, fmt.Errorf("unknown moderation action %q", action)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	const resolve = `
+		UPDATE reports
+		SET status = $2, resolved_at = now(), assignee_id = COALESCE(assignee_id, $3)
+		WHERE report_id = $1
+	`
+	if _, err := tx.ExecContext(ctx, resolve, reportID, status, moderatorID); err != nil {
+		return nil, err
+	}
+	if err := logModerationAction(ctx, tx, reportID, moderatorID, action, note); err != nil {
+		return nil, err
+	}
+	return keys, tx.Commit()
+}
+
+// GetModerationActions возвращает журнал действий по жалобе.
+func (s *PostStorage) GetModerationActions(ctx context.Context, reportID int) ([]ModerationAction, error) {
+	const query = `
+		SELECT action_id, report_id, moderator_id, action, note, created_at
+		FROM moderation_actions
+		WHERE report_id = $1
+		ORDER BY created_at ASC
+	`
+	rows, err := s.db.QueryContext(ctx, query, reportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []ModerationAction
+	for rows.Next() {
+		var a ModerationAction
+		if err := rows.Scan(&a.ID, &a.ReportID, &a.ModeratorID, &a.Action, &a.Note, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
+
+func lockOpenReport(ctx context.Context, tx *sql.Tx, reportID int, report *Report) error {
+	const query = `
+		SELECT report_type_id, target_id, status
+		FROM reports WHERE report_id = $1
+		FOR UPDATE
+	`
+	var r Report
+	err := tx.QueryRowContext(ctx, query, reportID).Scan(&r.ReportTypeID, &r.TargetID, &r.Status)
+	if err == sql.ErrNoRows {
+		return ErrReportNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if r.Status != ReportStatusOpen {
+		return ErrReportClosed
+	}
+	if report != nil {
+		*report = r
+	}
+	return nil
+}
+
+func logModerationAction(ctx context.Context, tx *sql.Tx, reportID, moderatorID int, action, note string) error {
+	const query = `
+		INSERT INTO moderation_actions (report_id, moderator_id, action, note)
 		VALUES ($1, $2, $3, $4)
 	`
-	_, err := s.db.ExecContext(ctx, query, reporterID, targetID, reportTypeID, description)
+	_, err := tx.ExecContext(ctx, query, reportID, moderatorID, action, note)
+	return err
+}
+
+func contentTable(reportTypeID int) (table, idColumn string, err error) {
+	switch reportTypeID {
+	case ReportTypePost:
+		return "posts", "post_id", nil
+	case ReportTypeComment:
+		return "comments", "comment_id", nil
+	case ReportTypeUser:
+		return "users", "user_id", nil
+	}
+	return "", "", fmt.Errorf("unsupported report type %d", reportTypeID)
+}
+
+// lockReportTarget проверяет, что объект жалобы существует, и возвращает
+// ErrPostNotFound, ErrCommentNotFound или ErrUserNotFound, если его нет.
+func lockReportTarget(ctx context.Context, tx *sql.Tx, reportTypeID, targetID int) error {
+	table, idColumn, err := contentTable(reportTypeID)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`SELECT 1 FROM %s WHERE %s = $1 FOR KEY SHARE`, pq.QuoteIdentifier(table), pq.QuoteIdentifier(idColumn))
+	var one int
+	err = tx.QueryRowContext(ctx, query, targetID).Scan(&one)
+	if err != sql.ErrNoRows {
+		return err
+	}
+	switch reportTypeID {
+	case ReportTypePost:
+		return ErrPostNotFound
+	case ReportTypeComment:
+		return ErrCommentNotFound
+	}
+	return ErrUserNotFound
+}
+
+func setContentHidden(ctx context.Context, tx *sql.Tx, reportTypeID, targetID int) error {
+	table, idColumn, err := contentTable(reportTypeID)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`UPDATE %s SET is_hidden = true WHERE %s = $1`, pq.QuoteIdentifier(table), pq.QuoteIdentifier(idColumn))
+	_, err = tx.ExecContext(ctx, query, targetID)
 	return err
 }
+
+// deleteContent удаляет пост или комментарий тем же путём, что и их
+// авторы, и возвращает ключи файлов поста для удаления из медиахранилища.
+func deleteContent(ctx context.Context, tx *sql.Tx, reportTypeID, targetID int) ([]string, error) {
+	var (
+		keys []string
+		err  error
+	)
+	switch reportTypeID {
+	case ReportTypePost:
+		keys, err = deletePost(ctx, tx, targetID)
+	case ReportTypeComment:
+		err = deleteComment(ctx, tx, targetID)
+	default:
+		return nil, fmt.Errorf("unsupported report type %d", reportTypeID)
+	}
+	if errors.Is(err, ErrPostNotFound) || errors.Is(err, ErrCommentNotFound) {
+		return nil, ErrReportTarget
+	}
+	return keys, err
+}
+
+// suspendContentAuthor блокирует автора объекта жалобы (или самого пользователя,
+// если жалоба на профиль) и отзывает все его сессии.
+func suspendContentAuthor(ctx context.Context, tx *sql.Tx, reportTypeID, targetID int) error {
+	table, idColumn, err := contentTable(reportTypeID)
+	if err != nil {
+		return err
+	}
+	authorColumn := "author_id"
+	if reportTypeID == ReportTypeUser {
+		authorColumn = "user_id"
+	}
+	var authorID int
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s = $1`,
+		pq.QuoteIdentifier(authorColumn), pq.QuoteIdentifier(table), pq.QuoteIdentifier(idColumn))
+	if err := tx.QueryRowContext(ctx, query, targetID).Scan(&authorID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrReportTarget
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET suspended_at = now() WHERE user_id = $1`, authorID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE user_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`, authorID)
+	return err
+}
+
+// GetReportsByReporter возвращает жалобы, поданные пользователем, новые — первыми.
+func (s *PostStorage) GetReportsByReporter(ctx context.Context, reporterID int) ([]Report, error) {
+	const query = `
+		SELECT report_id, reporter_id, report_type_id, target_id, reason_code, description,
+		       status, created_at, resolved_at
+		FROM reports
+		WHERE reporter_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, reporterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []Report
+	for rows.Next() {
+		var (
+			report     Report
+			resolvedAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&report.ID, &report.ReporterID, &report.ReportTypeID, &report.TargetID, &report.Reason,
+			&report.Description, &report.Status, &report.CreatedAt, &resolvedAt,
+		); err != nil {
+			return nil, err
+		}
+		if resolvedAt.Valid {
+			report.ResolvedAt = &resolvedAt.Time
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
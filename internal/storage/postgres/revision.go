@@ -0,0 +1,277 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// PostUpdate — изменения поста. Nil-поля остаются без изменений.
+// В Attachments вложение с ID — уже существующее (меняются позиция и
+// AltText), без ID — новое; вложения поста, которых нет в списке, удаляются.
+type PostUpdate struct {
+	Title       *string
+	Description *string
+	Tags        []string
+	Attachments []Attachment
+}
+
+// PostRevision — состояние поста до правки. CreatedAt — время правки,
+// EditorID — кто её сделал.
+type PostRevision struct {
+	RevisionID  int          `json:"revision_id"`
+	PostID      int          `json:"post_id"`
+	EditorID    int          `json:"editor_id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Tags        []string     `json:"tags"`
+	Attachments []Attachment `json:"attachments"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// GetPost возвращает видимый пост с первыми комментариями, тегами и вложениями.
+func (s *PostStorage) GetPost(ctx context.Context, postID int) (*PostResponse, error) {
+	const query = `
+		SELECT post_id, title, description, image_url, images, post_created_at, edited_at, author_id, author_user_name, like_count, comment_count
+		FROM view_post_summary
+		WHERE post_id = $1
+	`
+	posts, _, err := s.queryPosts(ctx, 1, 0, query, postID)
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, ErrPostNotFound
+	}
+	return &posts[0], nil
+}
+
+// UpdatePost применяет изменения к видимому посту, сохраняя прежнее
+// состояние в post_revisions. Скрытый модератором пост не редактируется.
+func (s *PostStorage) UpdatePost(ctx context.Context, postID, editorID int, update PostUpdate) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var title, description string
+	err = tx.QueryRowContext(ctx,
+		`SELECT title, description FROM posts WHERE post_id = $1 AND NOT is_hidden FOR UPDATE`, postID,
+	).Scan(&title, &description)
+	if err == sql.ErrNoRows {
+		return ErrPostNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	tags, err := getTagNames(ctx, tx, postID)
+	if err != nil {
+		return err
+	}
+	attachments, err := getAttachments(ctx, tx, postID)
+	if err != nil {
+		return err
+	}
+	attachmentsJSON, err := json.Marshal(attachments)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO post_revisions (post_id, editor_id, title, description, tags, attachments)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, postID, editorID, title, description, pq.Array(tags), attachmentsJSON)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE posts
+		SET title = COALESCE($2, title), description = COALESCE($3, description), edited_at = now()
+		WHERE post_id = $1
+	`, postID, update.Title, update.Description)
+	if err != nil {
+		return err
+	}
+
+	if update.Tags != nil {
+		if err := replaceTags(ctx, tx, postID, update.Tags); err != nil {
+			return err
+		}
+	}
+	if update.Attachments != nil {
+		if err := replaceAttachments(ctx, tx, postID, attachments, update.Attachments); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func getTagNames(ctx context.Context, tx *sql.Tx, postID int) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT t.name
+		FROM post_tags pt
+		JOIN tags t ON t.tag_id = pt.tag_id
+		WHERE pt.post_id = $1
+		ORDER BY t.name
+	`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tags = append(tags, name)
+	}
+	return tags, rows.Err()
+}
+
+func replaceTags(ctx context.Context, tx *sql.Tx, postID int, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM post_tags WHERE post_id = $1`, postID); err != nil {
+		return err
+	}
+	for _, name := range tags {
+		var tagID int
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO tags (name) VALUES ($1)
+			ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING tag_id
+		`, name).Scan(&tagID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO post_tags (post_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, postID, tagID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceAttachments приводит вложения поста к списку next и обновляет обложку.
+// Вложения с ID дополняются данными из current.
+func replaceAttachments(ctx context.Context, tx *sql.Tx, postID int, current, next []Attachment) error {
+	existing := make(map[int]Attachment, len(current))
+	for _, a := range current {
+		existing[a.ID] = a
+	}
+	kept := make(map[int]bool)
+	for i, a := range next {
+		if a.ID == 0 {
+			continue
+		}
+		old, ok := existing[a.ID]
+		if !ok || kept[a.ID] {
+			return ErrAttachmentNotFound
+		}
+		kept[a.ID] = true
+		old.AltText = a.AltText
+		next[i] = old
+	}
+
+	for _, a := range current {
+		if !kept[a.ID] {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM post_attachments WHERE attachment_id = $1`, a.ID); err != nil {
+				return err
+			}
+		}
+	}
+	// Временно уводим позиции в отрицательные, чтобы не нарушить UNIQUE (post_id, position)
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE post_attachments SET position = -1 - position WHERE post_id = $1`, postID); err != nil {
+		return err
+	}
+	for i := range next {
+		a := &next[i]
+		if a.ID != 0 {
+			_, err := tx.ExecContext(ctx,
+				`UPDATE post_attachments SET position = $2, alt_text = $3 WHERE attachment_id = $1`, a.ID, i, a.AltText)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err := insertAttachment(ctx, tx, strconv.Itoa(postID), i, a); err != nil {
+			return err
+		}
+	}
+
+	imageURL, images := Cover(next)
+	_, err := tx.ExecContext(ctx, `UPDATE posts SET image_url = $2, images = $3 WHERE post_id = $1`, postID, imageURL, images)
+	return err
+}
+
+// GetPostRevisions возвращает историю правок поста, новые первыми.
+func (s *PostStorage) GetPostRevisions(ctx context.Context, postID int) ([]PostRevision, error) {
+	const query = `
+		SELECT revision_id, post_id, editor_id, title, description, tags, attachments, created_at
+		FROM post_revisions
+		WHERE post_id = $1
+		ORDER BY created_at DESC, revision_id DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []PostRevision{}
+	for rows.Next() {
+		var (
+			r           PostRevision
+			attachments []byte
+		)
+		if err := rows.Scan(
+			&r.RevisionID, &r.PostID, &r.EditorID, &r.Title, &r.Description,
+			pq.Array(&r.Tags), &attachments, &r.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(attachments, &r.Attachments); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+// getRevisionAttachments возвращает вложения из всех версий поста — их
+// файлы остаются в хранилище, пока жив пост.
+func getRevisionAttachments(ctx context.Context, tx *sql.Tx, postID int) ([]Attachment, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT attachments FROM post_revisions WHERE post_id = $1`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []Attachment
+	for rows.Next() {
+		var (
+			data        []byte
+			attachments []Attachment
+		)
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &attachments); err != nil {
+			return nil, err
+		}
+		all = append(all, attachments...)
+	}
+	return all, rows.Err()
+}
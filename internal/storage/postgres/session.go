@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session — запись user_tokens. В колонке token хранится хеш текущего
+// refresh-токена, в previous_token — хеш предыдущего (для обнаружения
+// повторного использования уже обменянного токена).
+type Session struct {
+	SessionID  string    `json:"session_id"`
+	UserID     int       `json:"user_id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (s *UserStorage) CreateSession(ctx context.Context, session *Session, tokenHash string) error {
+	const query = `
+		INSERT INTO user_tokens (session_id, token, user_id, device, ip, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at, last_used_at
+	`
+	err := s.db.QueryRowContext(ctx, query,
+		session.SessionID, tokenHash, session.UserID, session.Device, session.IP, session.ExpiresAt,
+	).Scan(&session.CreatedAt, &session.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("creating session: %w", err)
+	}
+	return nil
+}
+
+// RotateSession обменивает refresh-токен на новый. Если предъявлен уже
+// обменянный токен, сессия считается скомпрометированной и отзывается.
+func (s *UserStorage) RotateSession(ctx context.Context, oldHash, newHash, ip string, expiresAt time.Time) (*Session, error) {
+	const query = `
+		UPDATE user_tokens
+		SET previous_token = token, token = $2, ip = $3, expires_at = $4, last_used_at = now()
+		WHERE token = $1 AND revoked_at IS NULL AND expires_at > now()
+		RETURNING session_id, user_id, device, ip, created_at, last_used_at, expires_at
+	`
+	var session Session
+	err := s.db.QueryRowContext(ctx, query, oldHash, newHash, ip, expiresAt).Scan(
+		&session.SessionID, &session.UserID, &session.Device, &session.IP,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		const revokeReused = `
+			UPDATE user_tokens SET revoked_at = now()
+			WHERE previous_token = $1 AND revoked_at IS NULL
+		`
+		if _, err := s.db.ExecContext(ctx, revokeReused, oldHash); err != nil {
+			return nil, fmt.Errorf("revoking reused session: %w", err)
+		}
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rotating session: %w", err)
+	}
+	return &session, nil
+}
+
+// TouchSession проверяет, что сессия не отозвана и не истекла,
+// и обновляет время последнего использования.
+func (s *UserStorage) TouchSession(ctx context.Context, sessionID string) (bool, error) {
+	if sessionID == "" {
+		// токен выпущен без привязки к сессии
+		return false, nil
+	}
+	const query = `
+		UPDATE user_tokens SET last_used_at = now()
+		WHERE session_id = $1 AND revoked_at IS NULL AND expires_at > now()
+	`
+	res, err := s.db.ExecContext(ctx, query, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("touching session: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (s *UserStorage) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	const query = `
+		UPDATE user_tokens SET revoked_at = now()
+		WHERE session_id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+	res, err := s.db.ExecContext(ctx, query, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("revoking session: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (s *UserStorage) RevokeAllSessions(ctx context.Context, userID int) error {
+	const query = `
+		UPDATE user_tokens SET revoked_at = now()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+	if _, err := s.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("revoking sessions: %w", err)
+	}
+	return nil
+}
+
+func (s *UserStorage) GetActiveSessions(ctx context.Context, userID int) ([]Session, error) {
+	const query = `
+		SELECT session_id, user_id, device, ip, created_at, last_used_at, expires_at
+		FROM user_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > now()
+		ORDER BY last_used_at DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(
+			&session.SessionID, &session.UserID, &session.Device, &session.IP,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// FollowBackfillLimit — сколько последних разложенных постов автора
+// процедура follow копирует в ленту нового подписчика (миграция 0014).
+// Более старые посты в его ленту не попадают.
+const FollowBackfillLimit = 200
+
+// GetTimeline возвращает домашнюю ленту пользователя: его собственные посты
+// и посты тех, на кого он подписан, кроме авторов, с которыми есть
+// блокировка в любую сторону. Страница собирается из трёх источников,
+// каждый из которых читается по индексу с тем же курсором и лимитом:
+// разложенные посты из timeline_entries, собственные посты и
+// неразложенные посты подписок (авторы с большим числом подписчиков и
+// посты, до которых раскладка ещё не дошла).
+func (s *PostStorage) GetTimeline(ctx context.Context, userID int, q PostsQuery) ([]PostResponse, *PostCursor, error) {
+	args := []any{userID, q.Offset + q.Limit + 1}
+	var afterEntry, afterPost string
+	if q.After != nil {
+		args = append(args, q.After.CreatedAt, q.After.ID)
+		afterEntry = ` AND (te.created_at, te.post_id) < ($3, $4)`
+		afterPost = ` AND (p.created_at, p.post_id) < ($3, $4)`
+	}
+
+	query := fmt.Sprintf(`
+		SELECT post_id, title, description, image_url, images, post_created_at, edited_at, author_id, author_user_name, like_count, comment_count
+		FROM view_post_summary
+		WHERE post_id IN (
+			(SELECT te.post_id FROM timeline_entries te
+			 JOIN posts p ON p.post_id = te.post_id
+			 WHERE te.user_id = $1 AND NOT p.is_hidden%[1]s
+			 ORDER BY te.created_at DESC, te.post_id DESC
+			 LIMIT $2)
+			UNION ALL
+			(SELECT p.post_id FROM posts p
+			 WHERE p.author_id = $1 AND NOT p.is_hidden%[2]s
+			 ORDER BY p.created_at DESC, p.post_id DESC
+			 LIMIT $2)
+			UNION ALL
+			(SELECT np.post_id FROM follows f
+			 CROSS JOIN LATERAL (
+				SELECT p.post_id, p.created_at FROM posts p
+				WHERE p.author_id = f.following_id AND NOT p.fanned_out AND NOT p.is_hidden%[2]s
+				ORDER BY p.created_at DESC, p.post_id DESC
+				LIMIT $2
+			 ) np
+			 WHERE f.follower_id = $1
+			 ORDER BY np.created_at DESC, np.post_id DESC
+			 LIMIT $2)
+		)
+	`, afterEntry, afterPost)
+	q.ViewerID = userID
+	query, args = pagePostsQuery(query, args, q)
+	return s.queryPosts(ctx, q.Limit, q.ViewerID, query, args...)
+}
+
+// FanOutPost раскладывает пост по лентам подписчиков автора. Если
+// подписчиков больше maxFollowers, пост помечается fanout_skipped, остаётся
+// читаемым из posts и возвращается false. Повторный вызов для разложенного
+// поста ничего не делает.
+func (s *PostStorage) FanOutPost(ctx context.Context, postID string, maxFollowers int) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var authorID int
+	err = tx.QueryRowContext(ctx,
+		`SELECT author_id FROM posts WHERE post_id = $1 AND NOT fanned_out FOR UPDATE`, postID,
+	).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var followers int
+	err = tx.QueryRowContext(ctx,
+		`SELECT count(*) FROM follows WHERE following_id = $1`, authorID,
+	).Scan(&followers)
+	if err != nil {
+		return false, err
+	}
+	if followers > maxFollowers {
+		_, err := tx.ExecContext(ctx, `UPDATE posts SET fanout_skipped = TRUE WHERE post_id = $1`, postID)
+		if err != nil {
+			return false, err
+		}
+		return false, tx.Commit()
+	}
+
+	const query = `
+		INSERT INTO timeline_entries (user_id, post_id, author_id, created_at)
+		SELECT f.follower_id, p.post_id, p.author_id, p.created_at
+		FROM posts p
+		JOIN follows f ON f.following_id = p.author_id
+		WHERE p.post_id = $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = f.follower_id AND b.blocked_id = p.author_id)
+			   OR (b.blocker_id = p.author_id AND b.blocked_id = f.follower_id)
+		  )
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query, postID); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE posts SET fanned_out = TRUE WHERE post_id = $1`, postID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// BackfillTimeline раскладывает посты, которые остались неразложенными:
+// созданные до появления timeline_entries и те, что не попали в очередь
+// раскладки. Посты авторов, у которых подписчиков больше maxFollowers,
+// помечаются fanout_skipped и при следующих запусках не перебираются —
+// они читаются при запросе ленты. Возвращает число разложенных постов.
+func (s *PostStorage) BackfillTimeline(ctx context.Context, maxFollowers int) (int, error) {
+	const (
+		batchSize = 500
+		query     = `
+			SELECT post_id FROM posts
+			WHERE NOT fanned_out AND NOT fanout_skipped AND post_id > $1
+			ORDER BY post_id
+			LIMIT $2
+		`
+	)
+
+	fannedOut, lastID := 0, 0
+	for {
+		rows, err := s.db.QueryContext(ctx, query, lastID, batchSize)
+		if err != nil {
+			return fannedOut, err
+		}
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fannedOut, err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fannedOut, err
+		}
+
+		for _, id := range ids {
+			ok, err := s.FanOutPost(ctx, strconv.Itoa(id), maxFollowers)
+			if err != nil {
+				return fannedOut, err
+			}
+			if ok {
+				fannedOut++
+			}
+			lastID = id
+		}
+		if len(ids) < batchSize {
+			return fannedOut, nil
+		}
+	}
+}
@@ -3,10 +3,12 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"time"
 )
 
+var ErrUserNotFound = errors.New("user not found")
+
 type UserStorage struct {
 	db *sql.DB
 }
@@ -37,19 +39,9 @@ func (s *UserStorage) CreateFullUser(ctx context.Context, email, password, name,
 	return nil
 }
 
-// Запись токена в таблицу user_tokens
-func (s *UserStorage) SaveUserToken(ctx context.Context, userID int, token string, expiresAt time.Time) error {
-	const query = `INSERT INTO user_tokens (token, user_id, expires_at) VALUES ($1, $2, $3)`
-	_, err := s.db.ExecContext(ctx, query, token, userID, expiresAt)
-	if err != nil {
-		return fmt.Errorf("saving token: %w", err)
-	}
-	return nil
-}
-
 // Получение информации о пользователе по user_id
 func (s *UserStorage) GetUserInfo(ctx context.Context, userID int) (map[string]interface{}, error) {
-	const query = `SELECT user_name, user_tag, theme, language, avatar_url, description FROM user_info WHERE user_id = $1`
+	const query = `SELECT user_name, user_tag, theme, language, avatar_url, avatar_images, description FROM user_info WHERE user_id = $1`
 	row := s.db.QueryRowContext(ctx, query, userID)
 
 	userInfo := make(map[string]interface{})
@@ -57,8 +49,9 @@ func (s *UserStorage) GetUserInfo(ctx context.Context, userID int) (map[string]i
 	// Declare variables to hold the column data
 	var userName, theme, userTag, language string
 	var avatarURL, description sql.NullString
+	var avatarImages Images
 	// Scan the row into variables
-	err := row.Scan(&userName, &userTag, &theme, &language, &avatarURL, &description)
+	err := row.Scan(&userName, &userTag, &theme, &language, &avatarURL, &avatarImages, &description)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Логируем отсутствие записи
@@ -75,6 +68,8 @@ func (s *UserStorage) GetUserInfo(ctx context.Context, userID int) (map[string]i
 		userInfo["avatar_url"] = ""
 	}
 
+	userInfo["images"] = avatarImages
+
 	if description.Valid {
 		userInfo["description"] = description.String
 	} else {
@@ -94,7 +89,7 @@ func (s *UserStorage) GetUserInfo(ctx context.Context, userID int) (map[string]i
 }
 
 func (s *UserStorage) GetUserInfoByTag(ctx context.Context, userTag string) (map[string]interface{}, error) {
-	const query = `SELECT user_id, user_name, user_tag, theme, language, avatar_url, description FROM user_info WHERE user_tag = $1`
+	const query = `SELECT user_id, user_name, user_tag, theme, language, avatar_url, avatar_images, description FROM user_info WHERE user_tag = $1`
 	row := s.db.QueryRowContext(ctx, query, userTag)
 
 	userInfo := make(map[string]interface{})
@@ -103,8 +98,9 @@ func (s *UserStorage) GetUserInfoByTag(ctx context.Context, userTag string) (map
 	var userID int
 	var userName, theme, language string
 	var avatarURL, description sql.NullString
+	var avatarImages Images
 	// Scan the row into variables
-	err := row.Scan(&userID, &userName, &userTag, &theme, &language, &avatarURL, &description)
+	err := row.Scan(&userID, &userName, &userTag, &theme, &language, &avatarURL, &avatarImages, &description)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// Логируем отсутствие записи
@@ -121,6 +117,8 @@ func (s *UserStorage) GetUserInfoByTag(ctx context.Context, userTag string) (map
 		userInfo["avatar_url"] = ""
 	}
 
+	userInfo["images"] = avatarImages
+
 	if description.Valid {
 		userInfo["description"] = description.String
 	} else {
@@ -190,3 +188,46 @@ func (s *UserStorage) UpdateUser(ctx context.Context, userID int, updates map[st
 	_, err := s.db.ExecContext(ctx, query, args...)
 	return err
 }
+
+func (s *UserStorage) GetUserRole(ctx context.Context, userID int) (string, error) {
+	const query = `SELECT role FROM users WHERE user_id = $1`
+	var role string
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("fetching role: %w", err)
+	}
+	return role, nil
+}
+
+func (s *UserStorage) SetUserRole(ctx context.Context, userID int, role string) error {
+	const query = `UPDATE users SET role = $2 WHERE user_id = $1`
+	res, err := s.db.ExecContext(ctx, query, userID, role)
+	if err != nil {
+		return fmt.Errorf("updating role: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// IsUserSuspended сообщает, заблокирован ли аккаунт модератором.
+func (s *UserStorage) IsUserSuspended(ctx context.Context, userID int) (bool, error) {
+	const query = `SELECT suspended_at IS NOT NULL FROM users WHERE user_id = $1`
+	var suspended bool
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&suspended)
+	if err == sql.ErrNoRows {
+		return false, ErrUserNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking suspension: %w", err)
+	}
+	return suspended, nil
+}
@@ -0,0 +1,62 @@
+// Package timeline раскладывает новые посты по домашним лентам подписчиков.
+package timeline
+
+import (
+	"context"
+	"log/slog"
+
+	"kursach/internal/logger/sl"
+)
+
+// Store — операция хранилища, которую выполняет Fanout.
+type Store interface {
+	FanOutPost(ctx context.Context, postID string, maxFollowers int) (bool, error)
+}
+
+// Fanout асинхронно раскладывает посты по лентам. Пост, который не успели
+// или не стали раскладывать (очередь полна, ошибка, у автора больше
+// maxFollowers подписчиков), не теряется: лента читает такие посты прямо
+// из posts.
+type Fanout struct {
+	log          *slog.Logger
+	store        Store
+	maxFollowers int
+	queue        chan string
+}
+
+func New(log *slog.Logger, store Store, maxFollowers, queueSize int) *Fanout {
+	return &Fanout{
+		log:          log.With(slog.String("component", "timeline-fanout")),
+		store:        store,
+		maxFollowers: maxFollowers,
+		queue:        make(chan string, queueSize),
+	}
+}
+
+// PostCreated ставит новый пост в очередь, не блокируя запрос.
+func (f *Fanout) PostCreated(postID string) {
+	select {
+	case f.queue <- postID:
+	default:
+		f.log.Warn("fanout queue is full, post left to read path", slog.String("post_id", postID))
+	}
+}
+
+// Run обрабатывает очередь, пока не отменён ctx.
+func (f *Fanout) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case postID := <-f.queue:
+			fannedOut, err := f.store.FanOutPost(ctx, postID, f.maxFollowers)
+			if err != nil {
+				if ctx.Err() == nil {
+					f.log.Error("failed to fan out post", slog.String("post_id", postID), sl.Err(err))
+				}
+				continue
+			}
+			f.log.Debug("post processed", slog.String("post_id", postID), slog.Bool("fanned_out", fannedOut))
+		}
+	}
+}